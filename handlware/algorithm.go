@@ -1,16 +1,17 @@
 package handlware
 
+import "math/rand"
+
 // Algorithm 广播算法接口
 // 所有广播算法（Random, BlockP2P, Perigee, Mercury, Mercator）都需要实现此接口
+// 算法实例只持有网络拓扑（图、K桶、坐标等），每次广播的可变状态保存在 Broadcast 会话中，
+// 因此同一拓扑可以同时服务多个广播（不同根节点的消息可以交错处理）
 type Algorithm interface {
-	// Respond 响应消息，返回转发节点列表
-	// msg: 接收到的消息
-	// 返回: 需要转发到的节点ID列表
-	Respond(msg *Message) []int
-
-	// SetRoot 设置广播根节点
+	// NewBroadcast 开启一次广播会话
 	// root: 广播树的根节点ID
-	SetRoot(root int)
+	// rng: 本次广播转发时使用的随机流（nil表示使用算法的默认固定种子）
+	// 返回: 持有本次广播全部可变状态（访问标记、K-ary信息等）的会话
+	NewBroadcast(root int, rng *rand.Rand) Broadcast
 
 	// GetAlgoName 获取算法名称
 	// 返回: 算法名称字符串，用于日志和结果输出
@@ -21,18 +22,150 @@ type Algorithm interface {
 	NeedSpecifiedRoot() bool
 }
 
+// Broadcast 一次广播的会话
+type Broadcast interface {
+	// Respond 响应本次广播中的消息，返回转发节点列表
+	// msg: 接收到的消息
+	// 返回: 需要转发到的节点ID列表
+	Respond(msg *Message) []int
+
+	// GetRoot 获取本次广播的根节点ID
+	GetRoot() int
+}
+
+// AdaptiveAlgorithm 在广播之间持续学习的算法（可选接口）
+// 这类算法的会话会更新共享的节点状态（如邻居统计、拓扑调整），
+// 多个会话可以在同一协程中交错执行，但不能被多个协程同时执行
+type AdaptiveAlgorithm interface {
+	Algorithm
+
+	// AdaptsAcrossBroadcasts 会话是否会修改共享状态
+	AdaptsAcrossBroadcasts() bool
+}
+
+// AnnouncingBroadcast 支持公告/请求两阶段传播的会话（可选接口）
+// 会话对每个转发目标选择推送完整消息或只发送公告（如ETH向√n个邻居推送、其余邻居公告），
+// 目标收到公告后若尚未持有消息，向公告方请求完整消息，多付出一个往返
+type AnnouncingBroadcast interface {
+	Broadcast
+
+	// RespondAnnounce 响应消息，返回推送完整消息的节点列表和只发送公告的节点列表
+	RespondAnnounce(msg *Message) (push []int, announce []int)
+}
+
+// JoiningAlgorithm 支持节点动态加入的算法（可选接口）
+type JoiningAlgorithm interface {
+	Algorithm
+
+	// JoinPeers 节点加入（或重新加入）网络时连接的邻居（路由表中的出边邻居）
+	// 只读取共享拓扑；会话未实现JoiningBroadcast时，加入后节点向其中的在线邻居请求正在传播的消息
+	JoinPeers(node int) []int
+}
+
+// HubAlgorithm 依赖少数中心节点（Hub、簇入口点等）的算法（可选接口）
+// 用于针对性攻击：攻击者优先移除这些节点
+type HubAlgorithm interface {
+	Algorithm
+
+	// HubNodes 按重要性从高到低排序的中心节点
+	HubNodes() []int
+}
+
+// RepresentativeAlgorithm 由组内代表节点负责组内分发的算法（可选接口）
+// 例如Mercator的大K0分组使用K-ary树，树的内部节点负责向组内其他节点转发
+type RepresentativeAlgorithm interface {
+	Algorithm
+
+	// K0Representatives 按重要性从高到低排序的代表节点
+	K0Representatives() []int
+}
+
+// Scheduler 模拟器提供给会话的事件调度接口
+type Scheduler interface {
+	// Now 获取当前事件的模拟时刻（ms）
+	Now() float64
+
+	// Has 节点是否已收到完整消息
+	Has(node int) bool
+
+	// SetTimer 设置定时器：delay毫秒后在node上触发会话的OnTimer(node, tag)
+	SetTimer(node int, delay float64, tag int)
+
+	// SendAfter 延迟发送：delay毫秒后由u向v发送msgType类型的消息
+	// 完整消息和公告只在u已收到消息时发送，请求可以由尚未收到消息的节点发出
+	SendAfter(u, v int, delay float64, msgType MessageType)
+}
+
+// TimedBroadcast 使用定时器和延迟发送的会话（可选接口）
+// 用于表达拉取补救、批量转发、分批扇出等不能在Respond中一次决定的协议
+type TimedBroadcast interface {
+	Broadcast
+
+	// SetScheduler 广播开始前由模拟器调用，会话保存调度器供Respond和OnTimer使用
+	SetScheduler(s Scheduler)
+
+	// OnTimer 定时器到期回调
+	// node: 设置定时器的节点
+	// tag: SetTimer时传入的标签
+	// 返回: 需要立即由node推送完整消息的节点列表
+	OnTimer(node, tag int) []int
+}
+
+// JoiningBroadcast 按算法自身的加入流程选择同步邻居的会话（可选接口）
+// 例如Mercator按K桶查找、Perigee按观测得分重选邻居，而不是直接沿用路由表中的出边邻居
+type JoiningBroadcast interface {
+	Broadcast
+
+	// OnJoin 节点node在广播过程中（重新）加入网络时调用
+	// limit: 最多返回的邻居数
+	// online: 判断节点当前是否在线且已知在网络中
+	// 返回: 按请求优先级排序的在线邻居，node随后向它们请求正在传播的消息
+	OnJoin(node, limit int, online func(int) bool) []int
+}
+
+// BaseBroadcast 会话基类，记录根节点
+type BaseBroadcast struct {
+	Root int
+}
+
+// GetRoot 默认实现
+func (bb *BaseBroadcast) GetRoot() int {
+	return bb.Root
+}
+
+// FloodBroadcast 泛洪会话：向所有出边邻居转发（除了消息来源）
+type FloodBroadcast struct {
+	BaseBroadcast
+	Graph *Graph
+}
+
+// Respond 实现Broadcast接口
+func (fb *FloodBroadcast) Respond(msg *Message) []int {
+	u := msg.Dst
+	nbU := fb.Graph.Outbound(u)
+	ret := make([]int, 0, len(nbU))
+	for _, v := range nbU {
+		if v != msg.Src {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
 // BaseAlgorithm 算法基类，提供默认实现
 type BaseAlgorithm struct {
 	Name            string
 	SpecifiedRoot   bool
 	Graph           *Graph
 	Coords          []LatLonCoordinate
-	Root            int
 }
 
-// SetRoot 默认实现
-func (ba *BaseAlgorithm) SetRoot(root int) {
-	ba.Root = root
+// NewBroadcast 默认实现：泛洪会话，需要其他转发策略的子类覆盖此方法
+func (ba *BaseAlgorithm) NewBroadcast(root int, rng *rand.Rand) Broadcast {
+	return &FloodBroadcast{
+		BaseBroadcast: BaseBroadcast{Root: root},
+		Graph:         ba.Graph,
+	}
 }
 
 // GetAlgoName 默认实现
@@ -45,17 +178,7 @@ func (ba *BaseAlgorithm) NeedSpecifiedRoot() bool {
 	return ba.SpecifiedRoot
 }
 
-// Respond 需要子类实现
-func (ba *BaseAlgorithm) Respond(msg *Message) []int {
-	// 默认返回所有出边邻居（除了消息来源）
-	u := msg.Dst
-	nbU := ba.Graph.Outbound(u)
-	ret := make([]int, 0, len(nbU))
-	for _, v := range nbU {
-		if v != msg.Src {
-			ret = append(ret, v)
-		}
-	}
-	return ret
+// JoinPeers 默认实现：出边邻居
+func (ba *BaseAlgorithm) JoinPeers(node int) []int {
+	return ba.Graph.Outbound(node)
 }
-
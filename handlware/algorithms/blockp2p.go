@@ -1,6 +1,9 @@
 package algorithms
 
 import (
+	"math/rand"
+	"sort"
+
 	hw "gomercator/handlware"
 )
 
@@ -16,7 +19,7 @@ type BlockP2P struct {
 	Graph         *hw.Graph              // 网络图
 	Coords        []hw.LatLonCoordinate  // 节点坐标
 	ClusterResult *hw.ClusterResult      // 聚类结果
-	TreeRoot      int                    // 当前广播树根节点（未使用，但保持接口兼容）
+	TreeRoot      int                    // 构建时指定的根节点（广播根节点由NewBroadcast指定）
 	Fanout        int                    // 扇出度参数
 }
 
@@ -42,6 +45,25 @@ func NewBlockP2P(n int, coords []hw.LatLonCoordinate, clusterResult *hw.ClusterR
 	return bp
 }
 
+// HubNodes 实现HubAlgorithm接口 - 各簇的入口点，按簇大小从大到小排序
+func (bp *BlockP2P) HubNodes() []int {
+	clusters := make([]int, 0, bp.ClusterResult.K)
+	for i := 0; i < bp.ClusterResult.K; i++ {
+		if len(bp.ClusterResult.ClusterList[i]) > 0 {
+			clusters = append(clusters, i)
+		}
+	}
+	sort.SliceStable(clusters, func(a, b int) bool {
+		return len(bp.ClusterResult.ClusterList[clusters[a]]) > len(bp.ClusterResult.ClusterList[clusters[b]])
+	})
+
+	hubs := make([]int, len(clusters))
+	for i, c := range clusters {
+		hubs[i] = bp.ClusterResult.ClusterList[c][0]
+	}
+	return hubs
+}
+
 // buildTopology 构建BlockP2P网络拓扑
 func (bp *BlockP2P) buildTopology() {
 	k := bp.ClusterResult.K
@@ -104,8 +126,8 @@ func (bp *BlockP2P) buildTopology() {
 	}
 }
 
-// Respond 实现Algorithm接口 - 响应消息
-func (bp *BlockP2P) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 响应消息
+func (bp *BlockP2PBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	nbU := bp.Graph.Outbound(u)
 	ret := make([]int, 0, len(nbU))
@@ -120,9 +142,18 @@ func (bp *BlockP2P) Respond(msg *hw.Message) []int {
 	return ret
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (bp *BlockP2P) SetRoot(root int) {
-	bp.TreeRoot = root
+// BlockP2PBroadcast BlockP2P的广播会话（BlockP2P没有广播状态，只记录根节点）
+type BlockP2PBroadcast struct {
+	*BlockP2P
+	hw.BaseBroadcast
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (bp *BlockP2P) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &BlockP2PBroadcast{
+		BlockP2P:      bp,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -135,6 +166,11 @@ func (bp *BlockP2P) NeedSpecifiedRoot() bool {
 	return false // BlockP2P不需要为每个根重建图
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接出边邻居
+func (bp *BlockP2P) JoinPeers(node int) []int {
+	return bp.Graph.Outbound(node)
+}
+
 // PrintInfo 打印图信息（调试用）
 func (bp *BlockP2P) PrintInfo() {
 	avgOutbound := 0.0
@@ -2,6 +2,7 @@ package algorithms
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 
 	hw "gomercator/handlware"
@@ -13,6 +14,7 @@ import (
 // 2. k-bucket 路由表：桶号由 XOR 距离的最高位位置决定
 // 3. 转发策略：从所有连接节点（PeerSet）中随机选择 X 个转发
 //    其中 X = 非空桶数量 × F
+// 4. 开启 AnnounceRest 后按 eth/65+ 协议：向 √n 个连接节点推送完整消息，其余节点只发送哈希公告
 
 // ETH ETH算法实现
 type ETH struct {
@@ -22,8 +24,8 @@ type ETH struct {
 	PeerSets         [][]int               // PeerSets[i] = 节点 i 的所有连接节点（所有桶的并集）
 	Coords           []hw.LatLonCoordinate // 真实坐标（用于 RTT 评估）
 	Config           hw.KBucketConfig      // k-bucket 配置
-	Visited          [][]bool              // 访问标记 Visited[nodeID][step]
 	Rng              *rand.Rand            // 随机数生成器
+	AnnounceRest     bool                  // 推送√n个节点、向其余连接节点公告（默认关闭，即全部推送）
 }
 
 // NewETH 创建新的 ETH 算法实例
@@ -31,37 +33,50 @@ type ETH struct {
 //   - n: 节点数
 //   - coords: 节点坐标数组
 //   - config: k-bucket 配置参数
+//   - rng: 随机流（节点ID生成和转发选择，nil表示固定种子42）
 //
 // 返回: ETH 算法实例
-func NewETH(n int, coords []hw.LatLonCoordinate, config hw.KBucketConfig) *ETH {
+func NewETH(n int, coords []hw.LatLonCoordinate, config hw.KBucketConfig, rng *rand.Rand) *ETH {
+	rng = hw.NewRngOrDefault(rng, 42)
+
+	fmt.Println("构建 ETH 拓扑...")
+
+	// 步骤1：为每个节点生成随机 128-bit NodeID
+	fmt.Printf("  步骤1: 生成 %d 个随机 NodeID...\n", n)
+	nodeIDs := make([]hw.NodeID128, n)
+	for i := 0; i < n; i++ {
+		nodeIDs[i] = hw.GenerateRandomNodeIDWithRng(rng)
+	}
+
+	return NewETHWithNodeIDs(n, coords, nodeIDs, config, rng)
+}
+
+// NewETHWithNodeIDs 使用给定的 NodeID 创建 ETH 算法实例
+// 用于攻击者可以选择 NodeID 的场景（如 Sybil/Eclipse 攻击）
+// 参数:
+//   - n: 节点数
+//   - coords: 节点坐标数组
+//   - nodeIDs: 每个节点的 128-bit ID
+//   - config: k-bucket 配置参数
+//   - rng: 随机流（转发选择，nil表示固定种子42）
+//
+// 返回: ETH 算法实例
+func NewETHWithNodeIDs(n int, coords []hw.LatLonCoordinate, nodeIDs []hw.NodeID128, config hw.KBucketConfig, rng *rand.Rand) *ETH {
 	eth := &ETH{
 		BaseAlgorithm: hw.BaseAlgorithm{
 			Name:          "eth",
 			SpecifiedRoot: false,
 			Graph:         hw.NewGraph(n),
 			Coords:        coords,
-			Root:          0,
 		},
-		NodeIDs:  make([]hw.NodeID128, n),
+		NodeIDs:  nodeIDs,
 		KBuckets: make([]hw.KBucketTable, n),
 		PeerSets: make([][]int, n),
 		Coords:   coords,
 		Config:   config,
-		Visited:  make([][]bool, n),
-		Rng:      rand.New(rand.NewSource(42)),
+		Rng:      hw.NewRngOrDefault(rng, 42),
 	}
 
-	// 初始化 Visited 数组
-	for i := 0; i < n; i++ {
-		eth.Visited[i] = make([]bool, hw.MaxDepth)
-	}
-
-	fmt.Println("构建 ETH 拓扑...")
-
-	// 步骤1：为每个节点生成随机 128-bit NodeID
-	fmt.Printf("  步骤1: 生成 %d 个随机 NodeID...\n", n)
-	eth.generateNodeIDs(n)
-
 	// 步骤2：预构建所有节点的 k-buckets
 	fmt.Printf("  步骤2: 构建 k-bucket 路由表（每桶最多 %d 个节点）...\n", config.K)
 	eth.buildKBuckets(n)
@@ -76,13 +91,6 @@ func NewETH(n int, coords []hw.LatLonCoordinate, config hw.KBucketConfig) *ETH {
 	return eth
 }
 
-// generateNodeIDs 为每个节点生成随机 128-bit NodeID
-func (eth *ETH) generateNodeIDs(n int) {
-	for i := 0; i < n; i++ {
-		eth.NodeIDs[i] = hw.GenerateRandomNodeID()
-	}
-}
-
 // buildKBuckets 预构建所有节点的 k-buckets
 func (eth *ETH) buildKBuckets(n int) {
 	// 初始化每个节点的 k-bucket 表
@@ -169,7 +177,7 @@ func (eth *ETH) printStatistics(n int) {
 //  1. 计算非空桶的数量
 //  2. X = 非空桶数量 × F
 //  3. 从 PeerSet 中随机选择 X 个节点转发
-func (eth *ETH) Respond(msg *hw.Message) []int {
+func (eth *ETHBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -202,8 +210,47 @@ func (eth *ETH) Respond(msg *hw.Message) []int {
 	return relayNodes
 }
 
+// RespondAnnounce 实现 AnnouncingBroadcast 接口
+// 未开启 AnnounceRest 时与 Respond 相同（全部推送完整消息）；
+// 开启后从 PeerSet 中随机选择 √n 个节点推送完整消息，其余连接节点只发送公告
+func (eth *ETHBroadcast) RespondAnnounce(msg *hw.Message) ([]int, []int) {
+	if !eth.AnnounceRest {
+		return eth.Respond(msg), nil
+	}
+
+	u := msg.Dst
+	push := make([]int, 0)
+	announce := make([]int, 0)
+
+	// 检查是否已访问过
+	if eth.Visited[u][msg.Step] {
+		return push, announce
+	}
+
+	eth.Visited[u][msg.Step] = true
+
+	peers := make([]int, 0, len(eth.PeerSets[u]))
+	for _, peer := range eth.PeerSets[u] {
+		if peer != msg.Src {
+			peers = append(peers, peer)
+		}
+	}
+
+	// 随机打乱后，前 √n 个推送，其余公告
+	pushN := int(math.Sqrt(float64(len(peers))))
+	for i, idx := range eth.Rng.Perm(len(peers)) {
+		if i < pushN {
+			push = append(push, peers[idx])
+		} else {
+			announce = append(announce, peers[idx])
+		}
+	}
+
+	return push, announce
+}
+
 // randomSelectN 从候选节点中随机选择 n 个
-func (eth *ETH) randomSelectN(candidates []int, n int) []int {
+func (eth *ETHBroadcast) randomSelectN(candidates []int, n int) []int {
 	if len(candidates) <= n {
 		return candidates
 	}
@@ -218,19 +265,33 @@ func (eth *ETH) randomSelectN(candidates []int, n int) []int {
 	return selected
 }
 
-// SetRoot 实现 Algorithm 接口 - 设置广播根节点
-func (eth *ETH) SetRoot(root int) {
-	eth.Root = root
-	// 重置 Visited 标记
-	for i := 0; i < len(eth.Visited); i++ {
-		for j := 0; j < len(eth.Visited[i]); j++ {
-			eth.Visited[i][j] = false
-		}
+// ETHBroadcast ETH 的广播会话 - 共享 NodeID 与路由表，访问标记和随机流独立
+type ETHBroadcast struct {
+	*ETH
+	hw.BaseBroadcast
+	Visited [][]bool   // 访问标记 Visited[nodeID][step]
+	Rng     *rand.Rand // 本次广播转发选择使用的随机流
+}
+
+// NewBroadcast 实现 Algorithm 接口 - 开启一次广播
+func (eth *ETH) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	b := &ETHBroadcast{
+		ETH:           eth,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Visited:       make([][]bool, eth.Graph.N),
+		Rng:           hw.NewRngOrDefault(rng, 42),
+	}
+	for i := range b.Visited {
+		b.Visited[i] = make([]bool, hw.MaxDepth)
 	}
+	return b
 }
 
 // GetAlgoName 实现 Algorithm 接口 - 获取算法名称
 func (eth *ETH) GetAlgoName() string {
+	if eth.AnnounceRest {
+		return fmt.Sprintf("eth_k%d_announce", eth.Config.K)
+	}
 	return fmt.Sprintf("eth_k%d_f%d", eth.Config.K, eth.Config.Fanout)
 }
 
@@ -239,8 +300,13 @@ func (eth *ETH) NeedSpecifiedRoot() bool {
 	return false
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接 PeerSet 中的所有节点
+func (eth *ETH) JoinPeers(node int) []int {
+	return eth.PeerSets[node]
+}
+
 // PrintInfo 打印算法信息（调试用）
 func (eth *ETH) PrintInfo() {
-	fmt.Printf("ETH: K=%d, Fanout=%d, NumBits=%d\n",
-		eth.Config.K, eth.Config.Fanout, eth.Config.NumBits)
+	fmt.Printf("ETH: K=%d, Fanout=%d, NumBits=%d, AnnounceRest=%v\n",
+		eth.Config.K, eth.Config.Fanout, eth.Config.NumBits, eth.AnnounceRest)
 }
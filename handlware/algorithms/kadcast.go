@@ -20,7 +20,6 @@ type Kadcast struct {
 	KBuckets         []hw.KBucketTable     // 每个节点的 k-bucket 路由表
 	Coords           []hw.LatLonCoordinate // 真实坐标（用于 RTT 评估）
 	Config           hw.KBucketConfig      // k-bucket 配置
-	Visited          [][]bool              // 访问标记 Visited[nodeID][step]
 	Rng              *rand.Rand            // 随机数生成器
 }
 
@@ -29,36 +28,49 @@ type Kadcast struct {
 //   - n: 节点数
 //   - coords: 节点坐标数组
 //   - config: k-bucket 配置参数
+//   - rng: 随机流（节点ID生成和转发选择，nil表示固定种子42）
 //
 // 返回: Kadcast 算法实例
-func NewKadcast(n int, coords []hw.LatLonCoordinate, config hw.KBucketConfig) *Kadcast {
+func NewKadcast(n int, coords []hw.LatLonCoordinate, config hw.KBucketConfig, rng *rand.Rand) *Kadcast {
+	rng = hw.NewRngOrDefault(rng, 42)
+
+	fmt.Println("构建 Kadcast 拓扑...")
+
+	// 步骤1：为每个节点生成随机 128-bit NodeID
+	fmt.Printf("  步骤1: 生成 %d 个随机 NodeID...\n", n)
+	nodeIDs := make([]hw.NodeID128, n)
+	for i := 0; i < n; i++ {
+		nodeIDs[i] = hw.GenerateRandomNodeIDWithRng(rng)
+	}
+
+	return NewKadcastWithNodeIDs(n, coords, nodeIDs, config, rng)
+}
+
+// NewKadcastWithNodeIDs 使用给定的 NodeID 创建 Kadcast 算法实例
+// 用于攻击者可以选择 NodeID 的场景（如 Sybil/Eclipse 攻击）
+// 参数:
+//   - n: 节点数
+//   - coords: 节点坐标数组
+//   - nodeIDs: 每个节点的 128-bit ID
+//   - config: k-bucket 配置参数
+//   - rng: 随机流（转发选择，nil表示固定种子42）
+//
+// 返回: Kadcast 算法实例
+func NewKadcastWithNodeIDs(n int, coords []hw.LatLonCoordinate, nodeIDs []hw.NodeID128, config hw.KBucketConfig, rng *rand.Rand) *Kadcast {
 	kc := &Kadcast{
 		BaseAlgorithm: hw.BaseAlgorithm{
 			Name:          "kadcast",
 			SpecifiedRoot: false,
 			Graph:         hw.NewGraph(n),
 			Coords:        coords,
-			Root:          0,
 		},
-		NodeIDs:  make([]hw.NodeID128, n),
+		NodeIDs:  nodeIDs,
 		KBuckets: make([]hw.KBucketTable, n),
 		Coords:   coords,
 		Config:   config,
-		Visited:  make([][]bool, n),
-		Rng:      rand.New(rand.NewSource(42)),
+		Rng:      hw.NewRngOrDefault(rng, 42),
 	}
 
-	// 初始化 Visited 数组
-	for i := 0; i < n; i++ {
-		kc.Visited[i] = make([]bool, hw.MaxDepth)
-	}
-
-	fmt.Println("构建 Kadcast 拓扑...")
-
-	// 步骤1：为每个节点生成随机 128-bit NodeID
-	fmt.Printf("  步骤1: 生成 %d 个随机 NodeID...\n", n)
-	kc.generateNodeIDs(n)
-
 	// 步骤2：预构建所有节点的 k-buckets
 	fmt.Printf("  步骤2: 构建 k-bucket 路由表（每桶最多 %d 个节点）...\n", config.K)
 	kc.buildKBuckets(n)
@@ -69,13 +81,6 @@ func NewKadcast(n int, coords []hw.LatLonCoordinate, config hw.KBucketConfig) *K
 	return kc
 }
 
-// generateNodeIDs 为每个节点生成随机 128-bit NodeID
-func (kc *Kadcast) generateNodeIDs(n int) {
-	for i := 0; i < n; i++ {
-		kc.NodeIDs[i] = hw.GenerateRandomNodeID()
-	}
-}
-
 // buildKBuckets 预构建所有节点的 k-buckets
 func (kc *Kadcast) buildKBuckets(n int) {
 	// 初始化每个节点的 k-bucket 表
@@ -141,7 +146,7 @@ func (kc *Kadcast) printStatistics(n int) {
 // Kadcast 转发策略：
 //  1. 计算消息来源所在的桶号 h
 //  2. 对桶 i=0..h-1，从每个桶随机选择 F 个节点转发
-func (kc *Kadcast) Respond(msg *hw.Message) []int {
+func (kc *KadcastBroadcast) Respond(msg *hw.Message) []int {
 
 	u := msg.Dst
 	relayNodes := make([]int, 0)
@@ -196,7 +201,7 @@ func (kc *Kadcast) Respond(msg *hw.Message) []int {
 }
 
 // randomSelectN 从候选节点中随机选择 n 个
-func (kc *Kadcast) randomSelectN(candidates []int, n int) []int {
+func (kc *KadcastBroadcast) randomSelectN(candidates []int, n int) []int {
 	if len(candidates) <= n {
 		return candidates
 	}
@@ -211,15 +216,26 @@ func (kc *Kadcast) randomSelectN(candidates []int, n int) []int {
 	return selected
 }
 
-// SetRoot 实现 Algorithm 接口 - 设置广播根节点
-func (kc *Kadcast) SetRoot(root int) {
-	kc.Root = root
-	// 重置 Visited 标记
-	for i := 0; i < len(kc.Visited); i++ {
-		for j := 0; j < len(kc.Visited[i]); j++ {
-			kc.Visited[i][j] = false
-		}
+// KadcastBroadcast Kadcast 的广播会话 - 共享 NodeID 与路由表，访问标记和随机流独立
+type KadcastBroadcast struct {
+	*Kadcast
+	hw.BaseBroadcast
+	Visited [][]bool   // 访问标记 Visited[nodeID][step]
+	Rng     *rand.Rand // 本次广播转发选择使用的随机流
+}
+
+// NewBroadcast 实现 Algorithm 接口 - 开启一次广播
+func (kc *Kadcast) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	b := &KadcastBroadcast{
+		Kadcast:       kc,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Visited:       make([][]bool, kc.Graph.N),
+		Rng:           hw.NewRngOrDefault(rng, 42),
 	}
+	for i := range b.Visited {
+		b.Visited[i] = make([]bool, hw.MaxDepth)
+	}
+	return b
 }
 
 // GetAlgoName 实现 Algorithm 接口 - 获取算法名称
@@ -232,6 +248,15 @@ func (kc *Kadcast) NeedSpecifiedRoot() bool {
 	return false
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接所有 k-bucket 中的节点
+func (kc *Kadcast) JoinPeers(node int) []int {
+	peers := make([]int, 0)
+	for _, bucket := range kc.KBuckets[node].Buckets {
+		peers = append(peers, bucket...)
+	}
+	return peers
+}
+
 // PrintInfo 打印算法信息（调试用）
 func (kc *Kadcast) PrintInfo() {
 	fmt.Printf("Kadcast: K=%d, Fanout=%d, NumBits=%d\n",
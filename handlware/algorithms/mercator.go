@@ -2,6 +2,7 @@ package algorithms
 
 import (
 	"fmt"
+	"math/rand"
 	"sort"
 	"strings"
 
@@ -28,14 +29,40 @@ type Mercator struct {
 	KBuckets       [][][]int             // K桶 [节点][桶ID][节点列表]
 	GeohashGroups  map[string][]int      // Geohash分组
 	PrefixTree     *hw.GeoPrefixNode     // 前缀树
-	TreeRoot       int                   // 当前广播树根节点
-	Visited        [][]bool              // 访问标记 [节点][Step]
+	TreeRoot       int                   // 构建时指定的根节点（广播根节点由NewBroadcast指定）
 	GeoPrec        int                   // Geohash精度
 	BucketSize     int                   // K桶大小
 	K0Threshold    int                   // K0桶阈值（超过则用K-ary树）
 	KaryFactor     int                   // K-ary树分支因子
 	TotalBits      int                   // Geohash总位数
-	KaryMsgInfo    []*hw.KaryMessage     // K-ary消息信息
+}
+
+// MercatorBroadcastState Mercator系列算法单次广播的可变状态
+// 嵌入Mercator的变体算法需要覆盖NewBroadcast，返回嵌入本状态的自有会话，否则会沿用Mercator的转发策略
+type MercatorBroadcastState struct {
+	hw.BaseBroadcast
+	Visited     [][]bool          // 访问标记 [节点][Step]
+	KaryMsgInfo []*hw.KaryMessage // K-ary消息信息
+}
+
+// NewMercatorBroadcastState 创建n个节点、根节点为root的广播状态
+func NewMercatorBroadcastState(n, root int) *MercatorBroadcastState {
+	st := &MercatorBroadcastState{
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Visited:       make([][]bool, n),
+		KaryMsgInfo:   make([]*hw.KaryMessage, n),
+	}
+	for i := 0; i < n; i++ {
+		st.Visited[i] = make([]bool, hw.MaxDepth+1)
+		st.KaryMsgInfo[i] = &hw.KaryMessage{RootNode: -1, IsKary: false}
+	}
+	return st
+}
+
+// MercatorBroadcast Mercator的广播会话（共享拓扑 + 独立状态）
+type MercatorBroadcast struct {
+	*Mercator
+	*MercatorBroadcastState
 }
 
 // NewMercator 创建新的Mercator算法实例
@@ -61,19 +88,11 @@ func NewMercator(n int, realCoords, displayCoords []hw.LatLonCoordinate, root in
 		NodeGeohashBin: make([]string, n),
 		GeohashGroups:  make(map[string][]int),
 		TreeRoot:       root,
-		Visited:        make([][]bool, n),
 		GeoPrec:        geoPrec,
 		BucketSize:     bucketSize,
 		K0Threshold:    k0Threshold,
 		KaryFactor:     karyFactor,
 		TotalBits:      totalBits,
-		KaryMsgInfo:    make([]*hw.KaryMessage, n),
-	}
-
-	// 初始化访问标记
-	for i := 0; i < n; i++ {
-		m.Visited[i] = make([]bool, hw.MaxDepth+1)
-		m.KaryMsgInfo[i] = &hw.KaryMessage{RootNode: -1, IsKary: false}
 	}
 
 	// 填充K桶并构建网络
@@ -96,6 +115,7 @@ func (m *Mercator) fillKBuckets(n int) {
 		m.GeohashGroups[m.NodeGeohash[i]] = append(m.GeohashGroups[m.NodeGeohash[i]], i)
 	}
 
+	sortGeohashGroups(m.GeohashGroups)
 	fmt.Printf("为%d个节点生成Geohash完成\n", n)
 
 	// 2. 初始化K桶,K桶结构 [节点][桶ID][节点列表]
@@ -141,23 +161,49 @@ func (m *Mercator) fillKBuckets(n int) {
 	fmt.Printf("网络连接构建完成，共%d条边\n", edges)
 }
 
-// ResetVisited 重置访问标记（在新的广播开始前调用）
-func (m *Mercator) ResetVisited() {
-	for i := 0; i < len(m.Visited); i++ {
-		for j := 0; j < len(m.Visited[i]); j++ {
-			m.Visited[i][j] = false
-		}
+// sortGeohashGroups 将每个Geohash分组内的节点按ID排序
+// K-ary树按组内下标计算子节点，排序在构建时一次完成，Respond中不再修改分组
+func sortGeohashGroups(groups map[string][]int) {
+	for _, nodes := range groups {
+		sort.Ints(nodes)
 	}
+}
 
-	// 重置K-ary消息信息
-	for i := 0; i < len(m.KaryMsgInfo); i++ {
-		m.KaryMsgInfo[i].RootNode = -1
-		m.KaryMsgInfo[i].IsKary = false
+// K0Representatives 实现RepresentativeAlgorithm接口
+// 超过K0Threshold的Geohash分组使用K-ary树传播，树的内部节点负责向组内其他节点转发。
+// 按树中的下标逐层排列（根节点优先），同一下标的分组按大小从大到小排列
+func (m *Mercator) K0Representatives() []int {
+	groups := make([][]int, 0)
+	for _, nodes := range m.GeohashGroups {
+		if len(nodes) > m.K0Threshold {
+			groups = append(groups, nodes)
+		}
 	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		if len(groups[a]) != len(groups[b]) {
+			return len(groups[a]) > len(groups[b])
+		}
+		return groups[a][0] < groups[b][0]
+	})
+
+	reps := make([]int, 0)
+	for idx := 0; ; idx++ {
+		found := false
+		for _, nodes := range groups {
+			if len(hw.ComputeKaryChildren(idx, len(nodes), m.KaryFactor)) > 0 {
+				reps = append(reps, nodes[idx])
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return reps
 }
 
-// Respond 实现Algorithm接口 - 响应消息
-func (m *Mercator) Respond2(msg *hw.Message) []int {
+// Respond2 实现Broadcast接口的另一种转发策略（K0桶flooding + 跨区域转发）
+func (m *MercatorBroadcast) Respond2(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -204,8 +250,8 @@ func (m *Mercator) Respond2(msg *hw.Message) []int {
 	return relayNodes
 }
 
-// Respond 实现Algorithm接口 - 响应消息
-func (m *Mercator) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 响应消息
+func (m *MercatorBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -229,8 +275,8 @@ func (m *Mercator) Respond(msg *hw.Message) []int {
 			}
 		} else {
 			// K0桶节点数量多，使用k-ary树
+			// 分组在构建时已按节点ID排序（Respond只读，多个广播可并发访问）
 			sameGeohashNodes := m.GeohashGroups[m.NodeGeohash[u]]
-			sort.Ints(sameGeohashNodes)
 
 			// 找到u在列表中的位置
 			uIdx := -1
@@ -286,7 +332,6 @@ func (m *Mercator) Respond(msg *hw.Message) []int {
 		if m.KaryMsgInfo[u].IsKary {
 			karyRoot := m.KaryMsgInfo[u].RootNode
 			sameGeohashNodes := m.GeohashGroups[m.NodeGeohash[karyRoot]]
-			sort.Ints(sameGeohashNodes)
 
 			// 找到u在列表中的位置
 			uIdx := -1
@@ -324,7 +369,6 @@ func (m *Mercator) Respond(msg *hw.Message) []int {
 				} else {
 					// K0桶k-ary树
 					sameGeohashNodes := m.GeohashGroups[m.NodeGeohash[u]]
-					sort.Ints(sameGeohashNodes)
 
 					uIdx := -1
 					for idx, node := range sameGeohashNodes {
@@ -411,12 +455,13 @@ func (m *Mercator) extraForwardByCharXOR(u, sender int, already map[int]struct{}
 
 	x := ui ^ si
 
-	targets := make(map[int]struct{})
+	// 按固定顺序处理目标（不使用map遍历，保证转发列表顺序可复现）
+	targets := make([]int, 0, 2)
 	if x == 2 || x == 8 || x == 10 {
-		targets[5] = struct{}{}
+		targets = append(targets, 5)
 	}
 	if x == 1 || x == 4 || x == 10 {
-		targets[10] = struct{}{}
+		targets = append(targets, 10)
 	}
 	if len(targets) == 0 {
 		return out
@@ -440,7 +485,7 @@ func (m *Mercator) extraForwardByCharXOR(u, sender int, already map[int]struct{}
 		out = append(out, v)
 	}
 
-	for tgt := range targets {
+	for _, tgt := range targets {
 		want := ui ^ tgt // b = a XOR x
 		// 在 5 个桶内查
 		found := 0
@@ -681,10 +726,12 @@ func (m *Mercator) EnsureXorAnchors(ensurePerTarget int) []hw.XorAnchorRecord {
 	return allRecords
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (m *Mercator) SetRoot(root int) {
-	m.TreeRoot = root
-	m.ResetVisited() // 重置访问标记
+// NewBroadcast 实现Algorithm接口 - 开启一次广播（Mercator转发不使用随机数）
+func (m *Mercator) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercatorBroadcast{
+		Mercator:               m,
+		MercatorBroadcastState: NewMercatorBroadcastState(m.Graph.N, root),
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -697,6 +744,70 @@ func (m *Mercator) NeedSpecifiedRoot() bool {
 	return false // Mercator可以复用网络拓扑
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接出边邻居
+func (m *Mercator) JoinPeers(node int) []int {
+	return m.Graph.Outbound(node)
+}
+
+// OnJoin 实现JoiningBroadcast接口 - K桶查找：节点上线后先在K0桶（相同Geohash），
+// 再由近到远在各K桶中选择在线节点，同一桶内按真实距离排序；
+// 某个桶中的节点都不在线时，查找该桶范围内（Geohash在对应位首次不同）距离最近的在线节点代替
+func (m *Mercator) OnJoin(node, limit int, online func(int) bool) []int {
+	peers := make([]int, 0, limit)
+	var nearest []int // 每个桶范围内最近的在线节点（首次需要查找时计算）
+	for b := 0; b < len(m.KBuckets[node]) && len(peers) < limit; b++ {
+		candidates := make([]int, 0)
+		for _, v := range m.KBuckets[node][b] {
+			if online(v) {
+				candidates = append(candidates, v)
+			}
+		}
+		if len(candidates) == 0 && b > 0 {
+			if nearest == nil {
+				nearest = m.nearestOnlineByBucket(node, online)
+			}
+			if nearest[b] >= 0 {
+				candidates = append(candidates, nearest[b])
+			}
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return hw.Distance(m.Coords[node], m.Coords[candidates[i]]) < hw.Distance(m.Coords[node], m.Coords[candidates[j]])
+		})
+		for _, v := range candidates {
+			if len(peers) >= limit {
+				break
+			}
+			peers = append(peers, v)
+		}
+	}
+	return peers
+}
+
+// nearestOnlineByBucket 查找node每个K桶范围内距离最近的在线节点（没有时为-1）
+func (m *Mercator) nearestOnlineByBucket(node int, online func(int) bool) []int {
+	nearest := make([]int, len(m.KBuckets[node]))
+	bestDist := make([]float64, len(nearest))
+	for b := range nearest {
+		nearest[b] = -1
+	}
+	for v := 0; v < m.Graph.N; v++ {
+		if !online(v) {
+			continue
+		}
+		b := hw.GetGeoBucketIndex(m.NodeGeohash[node], m.NodeGeohash[v], m.TotalBits)
+		if b >= len(nearest) {
+			continue
+		}
+		d := hw.Distance(m.Coords[node], m.Coords[v])
+		if nearest[b] < 0 || d < bestDist[b] {
+			nearest[b] = v
+			bestDist[b] = d
+		}
+	}
+	return nearest
+}
+
 // PrintInfo 打印图信息（调试用）
 func (m *Mercator) PrintInfo() {
 	avgOutbound := 0.0
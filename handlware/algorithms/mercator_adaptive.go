@@ -2,6 +2,7 @@ package algorithms
 
 import (
 	"fmt"
+	"math/rand"
 	"sort"
 
 	hw "gomercator/handlware"
@@ -168,6 +169,7 @@ func (ma *MercatorAdaptive) rebuildKBuckets() {
 		hash := ma.NodeGeohash[i]
 		ma.GeohashGroups[hash] = append(ma.GeohashGroups[hash], i)
 	}
+	sortGeohashGroups(ma.GeohashGroups)
 
 	// 填充K0桶（使用前缀匹配）
 	fmt.Println("填充K0桶（自适应前缀匹配）...")
@@ -359,7 +361,7 @@ func (ma *MercatorAdaptive) printPrecisionStats() {
 // 核心策略："每个节点按自己的精度看世界"
 // - K0桶：flooding所有在自己K0桶中的节点
 // - 其他桶：标准Mercator跨区域转发逻辑
-func (ma *MercatorAdaptive) Respond(msg *hw.Message) []int {
+func (ma *MercatorAdaptiveBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -454,6 +456,20 @@ func (ma *MercatorAdaptive) getAdaptiveBucketIndex(i, j int) int {
 	return totalBits - diffPos
 }
 
+// MercatorAdaptiveBroadcast 自适应Mercator的广播会话
+type MercatorAdaptiveBroadcast struct {
+	*MercatorAdaptive
+	*MercatorBroadcastState
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (ma *MercatorAdaptive) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercatorAdaptiveBroadcast{
+		MercatorAdaptive:       ma,
+		MercatorBroadcastState: NewMercatorBroadcastState(ma.Graph.N, root),
+	}
+}
+
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
 func (ma *MercatorAdaptive) GetAlgoName() string {
 	return "mercator_adaptive"
@@ -28,7 +28,7 @@ type MercatorGossip struct {
 //   - gossipFanout: Gossip扇出（每次随机选择的节点数，默认使用BucketSize）
 //
 // 返回: MercatorGossip实例
-func NewMercatorGossip(mercator *Mercator, gossipFanout int) *MercatorGossip {
+func NewMercatorGossip(mercator *Mercator, gossipFanout int, rng *rand.Rand) *MercatorGossip {
 	if gossipFanout <= 0 {
 		gossipFanout = mercator.BucketSize
 	}
@@ -39,12 +39,12 @@ func NewMercatorGossip(mercator *Mercator, gossipFanout int) *MercatorGossip {
 	return &MercatorGossip{
 		Mercator:     mercator,
 		GossipFanout: gossipFanout,
-		Rng:          rand.New(rand.NewSource(100)), // 固定种子确保可重复性
+		Rng:          hw.NewRngOrDefault(rng, 100), // 未指定随机流时使用固定种子
 	}
 }
 
-// Respond 实现Algorithm接口 - 响应消息（K0桶使用Gossip策略）
-func (mg *MercatorGossip) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 响应消息（K0桶使用Gossip策略）
+func (mg *MercatorGossipBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -118,7 +118,7 @@ func (mg *MercatorGossip) Respond(msg *hw.Message) []int {
 	return relayNodes
 }
 
-func gossipnodes(mg *MercatorGossip, u int, msg *hw.Message, relayNodes []int) []int {
+func gossipnodes(mg *MercatorGossipBroadcast, u int, msg *hw.Message, relayNodes []int) []int {
 	k0Nodes := make([]int, 0)
 	for _, v := range mg.KBuckets[u][0] {
 		if v != msg.Src {
@@ -140,7 +140,7 @@ func gossipnodes(mg *MercatorGossip, u int, msg *hw.Message, relayNodes []int) [
 //   - fanout: 需要选择的节点数
 //
 // 返回: 选中的节点列表
-func (mg *MercatorGossip) selectGossipNodes(nodes []int, fanout int) []int {
+func (mg *MercatorGossipBroadcast) selectGossipNodes(nodes []int, fanout int) []int {
 	if len(nodes) <= fanout {
 		// 如果候选节点数少于等于fanout，全部选择
 		return nodes
@@ -168,9 +168,20 @@ func (mg *MercatorGossip) extraForwardByCharXOR(u, sender int, already map[int]s
 	return mg.Mercator.extraForwardByCharXOR(u, sender, already)
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (mg *MercatorGossip) SetRoot(root int) {
-	mg.Mercator.SetRoot(root)
+// MercatorGossipBroadcast MercatorGossip的广播会话（独立的访问标记和Gossip随机流）
+type MercatorGossipBroadcast struct {
+	*MercatorGossip
+	*MercatorBroadcastState
+	Rng *rand.Rand // 本次广播的Gossip随机流
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (mg *MercatorGossip) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercatorGossipBroadcast{
+		MercatorGossip:         mg,
+		MercatorBroadcastState: NewMercatorBroadcastState(mg.Graph.N, root),
+		Rng:                    hw.NewRngOrDefault(rng, 100),
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -3,6 +3,7 @@ package algorithms
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"sort"
 
 	hw "gomercator/handlware"
@@ -133,6 +134,30 @@ func (mm *MercatorMercury) selectHubs() {
 	fmt.Printf("  总Hub数量: %d (占比%.2f%%)\n", hubCount, 100.0*float64(hubCount)/float64(len(mm.IsHub)))
 }
 
+// HubNodes 实现HubAlgorithm接口
+// Global Hubs在前、区域Hub在后，同一层按子节点数从多到少排序
+func (mm *MercatorMercury) HubNodes() []int {
+	isGlobal := make(map[int]bool, len(mm.GlobalHubs))
+	for _, hub := range mm.GlobalHubs {
+		isGlobal[hub] = true
+	}
+
+	hubs := make([]int, 0)
+	for i := 0; i < len(mm.IsHub); i++ {
+		if mm.IsHub[i] {
+			hubs = append(hubs, i)
+		}
+	}
+	sort.SliceStable(hubs, func(a, b int) bool {
+		ha, hb := hubs[a], hubs[b]
+		if isGlobal[ha] != isGlobal[hb] {
+			return isGlobal[ha]
+		}
+		return len(mm.HubChildren[ha]) > len(mm.HubChildren[hb])
+	})
+	return hubs
+}
+
 // selectHubFromGroup 从一组节点中选择Hub
 // 策略：选择地理中心最近的节点
 func (mm *MercatorMercury) selectHubFromGroup(nodes []int) int {
@@ -281,8 +306,8 @@ func (mm *MercatorMercury) connectNodesToHubs() {
 	fmt.Println("节点到Hub连接完成")
 }
 
-// Respond 实现Algorithm接口 - 生成中继节点列表
-func (mm *MercatorMercury) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 生成中继节点列表
+func (mm *MercatorMercuryBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -395,6 +420,20 @@ func (mm *MercatorMercury) isGlobalHub(nodeID int) bool {
 	return false
 }
 
+// MercatorMercuryBroadcast Mercator-Mercury的广播会话
+type MercatorMercuryBroadcast struct {
+	*MercatorMercury
+	*MercatorBroadcastState
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (mm *MercatorMercury) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercatorMercuryBroadcast{
+		MercatorMercury:        mm,
+		MercatorBroadcastState: NewMercatorBroadcastState(mm.Graph.N, root),
+	}
+}
+
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
 func (mm *MercatorMercury) GetAlgoName() string {
 	return "mercator_mercury"
@@ -2,6 +2,7 @@ package algorithms
 
 import (
 	"fmt"
+	"math/rand"
 	"sort"
 
 	hw "gomercator/handlware"
@@ -118,9 +119,9 @@ func (ms *MercatorSampled) distanceBasedSample(nodeID int, k0Bucket []int, k int
 	return selected
 }
 
-// Respond 实现Algorithm接口 - 生成中继节点列表
+// Respond 实现Broadcast接口 - 生成中继节点列表
 // 核心改变：使用采样后的K0Neighbors而非完整的KBuckets[u][0]
-func (ms *MercatorSampled) Respond(msg *hw.Message) []int {
+func (ms *MercatorSampledBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	relayNodes := make([]int, 0)
 
@@ -184,6 +185,20 @@ func (ms *MercatorSampled) Respond(msg *hw.Message) []int {
 	return relayNodes
 }
 
+// MercatorSampledBroadcast K0采样Mercator的广播会话
+type MercatorSampledBroadcast struct {
+	*MercatorSampled
+	*MercatorBroadcastState
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (ms *MercatorSampled) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercatorSampledBroadcast{
+		MercatorSampled:        ms,
+		MercatorBroadcastState: NewMercatorBroadcastState(ms.Graph.N, root),
+	}
+}
+
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
 func (ms *MercatorSampled) GetAlgoName() string {
 	return "mercator_sampled_k0"
@@ -23,7 +23,7 @@ type Mercury struct {
 	Coords        []hw.LatLonCoordinate  // 真实坐标
 	VivaldiModels []*hw.VivaldiModel     // Vivaldi模型
 	ClusterResult *hw.ClusterResult      // 聚类结果
-	TreeRoot      int                    // 当前广播树根节点
+	TreeRoot      int                    // 构建时指定的根节点（广播根节点由NewBroadcast指定）
 	RootFanout    int                    // 根节点扇出度
 	SecondFanout  int                    // 第二层扇出度
 	Fanout        int                    // 普通节点扇出度
@@ -42,8 +42,9 @@ type Mercury struct {
 //   - rootFanout, secondFanout, fanout: 扇出度参数
 //   - innerDeg: 簇内连接度
 //   - enableNearest: 是否启用最近邻策略
+//   - rng: 随机流（nil表示固定种子100）
 func NewMercury(n int, coords []hw.LatLonCoordinate, vmodels []*hw.VivaldiModel, clusterResult *hw.ClusterResult,
-	root int, rootFanout, secondFanout, fanout, innerDeg int, enableNearest bool) *Mercury {
+	root int, rootFanout, secondFanout, fanout, innerDeg int, enableNearest bool, rng *rand.Rand) *Mercury {
 
 	m := &Mercury{
 		Graph:         hw.NewGraph(n),
@@ -57,7 +58,7 @@ func NewMercury(n int, coords []hw.LatLonCoordinate, vmodels []*hw.VivaldiModel,
 		Fanout:        fanout,
 		InnerDeg:      innerDeg,
 		EnableNearest: enableNearest,
-		Rng:           rand.New(rand.NewSource(100)),
+		Rng:           hw.NewRngOrDefault(rng, 100),
 	}
 
 	// 构建网络拓扑
@@ -88,8 +89,8 @@ func (m *Mercury) buildTopology(n int) {
 				clusterPeers := make([]hw.PairFloatInt, 0)
 
 				for trial := 0; trial < 100 && len(clusterPeers) < m.InnerDeg; trial++ {
-					j := m.ClusterResult.ClusterList[c][rand.Intn(clusterSize)]
-					j1 := m.ClusterResult.ClusterList[c][rand.Intn(clusterSize)]
+					j := m.ClusterResult.ClusterList[c][m.Rng.Intn(clusterSize)]
+					j1 := m.ClusterResult.ClusterList[c][m.Rng.Intn(clusterSize)]
 
 					// 选择更近的节点
 					distJ := hw.DistanceEuclidean(m.VivaldiModels[i].Vector(), m.VivaldiModels[j].Vector())
@@ -147,8 +148,8 @@ func (m *Mercury) buildTopology(n int) {
 	}
 }
 
-// Respond 实现Algorithm接口 - 响应消息
-func (m *Mercury) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 响应消息
+func (m *MercuryBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	ret := make([]int, 0)
 
@@ -194,9 +195,20 @@ func (m *Mercury) Respond(msg *hw.Message) []int {
 	return ret
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (m *Mercury) SetRoot(root int) {
-	m.TreeRoot = root
+// MercuryBroadcast Mercury的广播会话（图结构共享，随机补充转发使用独立随机流）
+type MercuryBroadcast struct {
+	*Mercury
+	hw.BaseBroadcast
+	Rng *rand.Rand // 本次广播的随机流
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (m *Mercury) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercuryBroadcast{
+		Mercury:       m,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Rng:           hw.NewRngOrDefault(rng, 100),
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -212,6 +224,11 @@ func (m *Mercury) NeedSpecifiedRoot() bool {
 	return false
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接出边邻居
+func (m *Mercury) JoinPeers(node int) []int {
+	return m.Graph.Outbound(node)
+}
+
 // PrintInfo 打印图信息（调试用）
 func (m *Mercury) PrintInfo() {
 	avgOutbound := 0.0
@@ -31,7 +31,7 @@ type MercuryLocal struct {
 	ClusterID         []int     // ClusterID[i] 存储节点i在自己的局部聚类中属于哪个簇
 	NeighborClusterID [][]int   // NeighborClusterID[i][j] 存储节点i的第j个邻居属于哪个局部簇
 
-	TreeRoot      int        // 当前广播树根节点
+	TreeRoot      int        // 构建时指定的根节点（广播根节点由NewBroadcast指定）
 	RootFanout    int        // 根节点扇出度
 	SecondFanout  int        // 第二层扇出度
 	Fanout        int        // 普通节点扇出度
@@ -39,6 +39,8 @@ type MercuryLocal struct {
 	EnableNearest bool       // 是否启用最近邻策略
 	Rng           *rand.Rand // 随机数生成器
 	K             int        // 局部聚类K值
+
+	RTTModel hw.LatencyModel // 本地Vivaldi测量使用的RTT模型
 }
 
 // NewMercuryLocal 创建新的MercuryLocal算法实例
@@ -52,8 +54,14 @@ type MercuryLocal struct {
 //   - rootFanout, secondFanout, fanout: 扇出度参数
 //   - innerDeg: 簇内连接度
 //   - enableNearest: 是否启用最近邻策略
+//   - rttModel: Vivaldi测量使用的RTT模型（nil表示 Distance + FixedDelay）
+//   - rng: 随机流（nil表示固定种子100）
 func NewMercuryLocal(n int, coords []hw.LatLonCoordinate, root int, neighborCount, k, vivaldiRounds int,
-	rootFanout, secondFanout, fanout, innerDeg int, enableNearest bool) *MercuryLocal {
+	rootFanout, secondFanout, fanout, innerDeg int, enableNearest bool, rttModel hw.LatencyModel, rng *rand.Rand) *MercuryLocal {
+
+	if rttModel == nil {
+		rttModel = hw.NewGeoRTTModel(coords)
+	}
 
 	ml := &MercuryLocal{
 		Graph:             hw.NewGraph(n),
@@ -70,8 +78,9 @@ func NewMercuryLocal(n int, coords []hw.LatLonCoordinate, root int, neighborCoun
 		Fanout:            fanout,
 		InnerDeg:          innerDeg,
 		EnableNearest:     enableNearest,
-		Rng:               rand.New(rand.NewSource(100)),
+		Rng:               hw.NewRngOrDefault(rng, 100),
 		K:                 k,
+		RTTModel:          rttModel,
 	}
 
 	// 步骤1：选择邻居
@@ -130,9 +139,9 @@ func (ml *MercuryLocal) buildLocalVivaldi(n int, rounds int) {
 
 		// 初始化随机坐标
 		for d := 0; d < dim; d++ {
-			ml.VivaldiModels[i].LocalCoord.Vector[d] = hw.RandomBetween01() * 1000
+			ml.VivaldiModels[i].LocalCoord.Vector[d] = ml.Rng.Float64() * 1000
 		}
-		ml.VivaldiModels[i].LocalCoord.Height = hw.RandomBetween01() * 100
+		ml.VivaldiModels[i].LocalCoord.Height = ml.Rng.Float64() * 100
 	}
 
 	// 迭代更新坐标
@@ -146,8 +155,8 @@ func (ml *MercuryLocal) buildLocalVivaldi(n int, rounds int) {
 
 			// 对每个邻居进行观测和更新
 			for _, y := range neighbors {
-				// 计算真实RTT（基于地理距离）
-				rtt := hw.Distance(ml.Coords[x], ml.Coords[y]) + hw.FixedDelay
+				// 计算真实RTT（由RTT模型给出）
+				rtt := ml.RTTModel.Delay(x, y)
 
 				// 观测并更新坐标
 				hw.Observe(ml.VivaldiModels[x], y, ml.VivaldiModels[y].LocalCoord, rtt)
@@ -428,9 +437,9 @@ func (ml *MercuryLocal) buildTopology(n int) {
 	fmt.Printf("  拓扑构建完成：平均出度 = %.2f\n", avgOutbound)
 }
 
-// Respond 实现Algorithm接口 - 响应消息
+// Respond 实现Broadcast接口 - 响应消息
 // 策略：优先转发给簇内邻居（InnerDeg个），然后转发给簇外邻居（Fanout-InnerDeg个）
-func (ml *MercuryLocal) Respond(msg *hw.Message) []int {
+func (ml *MercuryLocalBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	ret := make([]int, 0)
 
@@ -504,9 +513,20 @@ func (ml *MercuryLocal) Respond(msg *hw.Message) []int {
 	return ret
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (ml *MercuryLocal) SetRoot(root int) {
-	ml.TreeRoot = root
+// MercuryLocalBroadcast MercuryLocal的广播会话（图结构共享，随机补充转发使用独立随机流）
+type MercuryLocalBroadcast struct {
+	*MercuryLocal
+	hw.BaseBroadcast
+	Rng *rand.Rand // 本次广播的随机流
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (ml *MercuryLocal) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &MercuryLocalBroadcast{
+		MercuryLocal:  ml,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Rng:           hw.NewRngOrDefault(rng, 100),
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -522,6 +542,11 @@ func (ml *MercuryLocal) NeedSpecifiedRoot() bool {
 	return false
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接出边邻居
+func (ml *MercuryLocal) JoinPeers(node int) []int {
+	return ml.Graph.Outbound(node)
+}
+
 // PrintInfo 打印图信息（调试用）
 func (ml *MercuryLocal) PrintInfo() {
 	avgOutbound := 0.0
@@ -3,6 +3,7 @@ package algorithms
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 
 	hw "gomercator/handlware"
 )
@@ -26,11 +27,12 @@ const (
 type PerigeeUCB struct {
 	Graph        *hw.Graph                  // 网络图
 	Coords       []hw.LatLonCoordinate      // 节点坐标
-	TreeRoot     int                        // 当前广播树根节点
+	TreeRoot     int                        // 构建时指定的根节点（广播根节点由NewBroadcast指定）
 	RootFanout   int                        // 根节点扇出度
 	Fanout       int                        // 普通节点扇出度
 	MaxOutbound  int                        // 最大出度
 	Observations [][]*hw.PerigeeObservation // 观测数据 [节点][观测索引]
+	Latency      hw.LatencyModel            // 链路延迟模型（与模拟器一致）
 	Rng          *rand.Rand                 // 随机数生成器
 }
 
@@ -42,7 +44,14 @@ type PerigeeUCB struct {
 //   - rootFanout: 根节点扇出度
 //   - fanout: 普通节点扇出度
 //   - maxOutbound: 最大出度
-func NewPerigeeUCB(n int, coords []hw.LatLonCoordinate, root int, rootFanout, fanout, maxOutbound int) *PerigeeUCB {
+//   - latency: 链路延迟模型（nil表示使用地理距离模型）
+//   - rng: 随机流（nil表示以root为种子）
+func NewPerigeeUCB(n int, coords []hw.LatLonCoordinate, root int, rootFanout, fanout, maxOutbound int,
+	latency hw.LatencyModel, rng *rand.Rand) *PerigeeUCB {
+	if latency == nil {
+		latency = hw.NewGeoLatencyModel(coords)
+	}
+
 	pg := &PerigeeUCB{
 		Graph:        hw.NewGraph(n),
 		Coords:       coords,
@@ -51,7 +60,8 @@ func NewPerigeeUCB(n int, coords []hw.LatLonCoordinate, root int, rootFanout, fa
 		Fanout:       fanout,
 		MaxOutbound:  maxOutbound,
 		Observations: make([][]*hw.PerigeeObservation, n),
-		Rng:          rand.New(rand.NewSource(int64(root))),
+		Latency:      latency,
+		Rng:          hw.NewRngOrDefault(rng, int64(root)),
 	}
 
 	// 初始化观测数据结构
@@ -65,7 +75,7 @@ func NewPerigeeUCB(n int, coords []hw.LatLonCoordinate, root int, rootFanout, fa
 
 	// 构建初始图并执行warmup
 	pg.buildInitialGraph(n, fanout)
-	pg.warmupPhase(n, coords)
+	pg.warmupPhase(n)
 
 	return pg
 }
@@ -106,7 +116,7 @@ func (pg *PerigeeUCB) buildInitialGraph(n, fanout int) {
 }
 
 // warmupPhase Warmup阶段 - 发送随机消息并优化拓扑
-func (pg *PerigeeUCB) warmupPhase(n int, coords []hw.LatLonCoordinate) {
+func (pg *PerigeeUCB) warmupPhase(n int) {
 	fmt.Println("开始Warmup Phase...")
 
 	// Warmup状态
@@ -130,6 +140,8 @@ func (pg *PerigeeUCB) warmupPhase(n int, coords []hw.LatLonCoordinate) {
 		// 初始化消息队列
 		msgQueue := hw.NewPriorityQueue()
 		msgQueue.Push(hw.NewMessage(root, root, root, 0, 0, 0))
+		// 预热广播沿用算法自身的随机流
+		bc := pg.NewBroadcast(root, pg.Rng)
 
 		// 模拟消息传播
 		for !msgQueue.Empty() {
@@ -142,7 +154,7 @@ func (pg *PerigeeUCB) warmupPhase(n int, coords []hw.LatLonCoordinate) {
 				recvTime[u] = msg.RecvTime
 
 				// 获取转发列表
-				relayList := pg.Respond(msg)
+				relayList := bc.Respond(msg)
 				delayTime := 0.0
 				if u == root {
 					delayTime = 0
@@ -150,7 +162,7 @@ func (pg *PerigeeUCB) warmupPhase(n int, coords []hw.LatLonCoordinate) {
 
 				// 向邻居转发
 				for _, v := range relayList {
-					dist := hw.Distance(coords[u], coords[v])*3 + hw.FixedDelay
+					dist := pg.Latency.Delay(u, v) + hw.FixedDelay
 					newMsg := hw.NewMessage(root, u, v, msg.Step+1,
 						recvTime[u]+delayTime, recvTime[u]+dist+delayTime)
 					msgQueue.Push(newMsg)
@@ -247,8 +259,8 @@ func (pg *PerigeeUCB) neighborReselection(nodeID int, n int) bool {
 	return false
 }
 
-// Respond 实现Algorithm接口 - 响应消息
-func (pg *PerigeeUCB) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 响应消息
+func (pg *PerigeeUCBBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	nbU := pg.Graph.Outbound(u)
 	ret := make([]int, 0, len(nbU))
@@ -274,9 +286,20 @@ func (pg *PerigeeUCB) Respond(msg *hw.Message) []int {
 	return ret
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (pg *PerigeeUCB) SetRoot(root int) {
-	pg.TreeRoot = root
+// PerigeeUCBBroadcast PerigeeUCB的广播会话（图结构共享，根节点额外转发使用独立随机流）
+type PerigeeUCBBroadcast struct {
+	*PerigeeUCB
+	hw.BaseBroadcast
+	Rng *rand.Rand // 本次广播的随机流
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (pg *PerigeeUCB) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &PerigeeUCBBroadcast{
+		PerigeeUCB:    pg,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Rng:           hw.NewRngOrDefault(rng, int64(pg.TreeRoot)),
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -289,6 +312,49 @@ func (pg *PerigeeUCB) NeedSpecifiedRoot() bool {
 	return false
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接出边邻居
+func (pg *PerigeeUCB) JoinPeers(node int) []int {
+	return pg.Graph.Outbound(node)
+}
+
+// OnJoin 实现JoiningBroadcast接口 - 邻居重选：节点上线后按预热阶段的观测得分（UCB，越小越好）
+// 保留仍在线的已观测邻居，不足limit个时用随机在线节点补齐（与重选时随机替换最差邻居一致）
+func (pg *PerigeeUCBBroadcast) OnJoin(node, limit int, online func(int) bool) []int {
+	type scored struct {
+		src int
+		ucb float64
+	}
+	observed := make([]scored, 0, len(pg.Observations[node]))
+	for _, obs := range pg.Observations[node] {
+		if online(obs.Src) {
+			_, ucb := obs.GetLCBUCB()
+			observed = append(observed, scored{src: obs.Src, ucb: ucb})
+		}
+	}
+	sort.SliceStable(observed, func(i, j int) bool {
+		return observed[i].ucb < observed[j].ucb
+	})
+
+	peers := make([]int, 0, limit)
+	for _, o := range observed {
+		if len(peers) >= limit {
+			break
+		}
+		if !hw.Contains(peers, o.src) {
+			peers = append(peers, o.src)
+		}
+	}
+
+	// 随机补齐（尝试次数有限，在线节点很少时可能不足limit个）
+	for attempt := 0; len(peers) < limit && attempt < pg.Graph.N; attempt++ {
+		v := pg.Rng.Intn(pg.Graph.N)
+		if online(v) && !hw.Contains(peers, v) {
+			peers = append(peers, v)
+		}
+	}
+	return peers
+}
+
 // PrintInfo 打印图信息（调试用）
 func (pg *PerigeeUCB) PrintInfo() {
 	avgOutbound := 0.0
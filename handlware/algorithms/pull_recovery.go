@@ -0,0 +1,128 @@
+package algorithms
+
+import (
+	"fmt"
+	"math/rand"
+
+	hw "gomercator/handlware"
+)
+
+// ==================== 拉取补救（Pull Recovery） ====================
+// 包装任意广播算法：节点u向v推送或公告消息后，在v上设置超时定时器；
+// 超时时v仍未收到完整消息，则v向u发送请求拉取完整消息（每条转发边最多补救一次）。
+// 用于弥补推送阶段因丢包等原因遗漏的节点
+
+// PullRecovery 拉取补救包装算法
+type PullRecovery struct {
+	Inner   hw.Algorithm // 被包装的广播算法
+	Timeout float64      // 超时时间（ms，应大于一次转发的传播+传输延迟）
+}
+
+// NewPullRecovery 创建拉取补救包装算法
+// 参数:
+//   - inner: 被包装的广播算法
+//   - timeout: 超时时间（ms）
+func NewPullRecovery(inner hw.Algorithm, timeout float64) *PullRecovery {
+	return &PullRecovery{
+		Inner:   inner,
+		Timeout: timeout,
+	}
+}
+
+// PullRecoveryBroadcast 拉取补救会话，包装内部算法的会话
+type PullRecoveryBroadcast struct {
+	hw.Broadcast               // 内部算法的会话
+	*PullRecovery              // 共享配置
+	Sched         hw.Scheduler // 模拟器提供的调度器
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (pr *PullRecovery) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &PullRecoveryBroadcast{
+		Broadcast:    pr.Inner.NewBroadcast(root, rng),
+		PullRecovery: pr,
+	}
+}
+
+// GetAlgoName 实现Algorithm接口 - 获取算法名称
+func (pr *PullRecovery) GetAlgoName() string {
+	return fmt.Sprintf("%s_pull", pr.Inner.GetAlgoName())
+}
+
+// NeedSpecifiedRoot 实现Algorithm接口 - 与内部算法一致
+func (pr *PullRecovery) NeedSpecifiedRoot() bool {
+	return pr.Inner.NeedSpecifiedRoot()
+}
+
+// JoinPeers 实现JoiningAlgorithm接口 - 与内部算法一致（内部算法不支持时不连接邻居）
+func (pr *PullRecovery) JoinPeers(node int) []int {
+	if ja, ok := pr.Inner.(hw.JoiningAlgorithm); ok {
+		return ja.JoinPeers(node)
+	}
+	return nil
+}
+
+// AdaptsAcrossBroadcasts 实现AdaptiveAlgorithm接口 - 与内部算法一致
+func (pr *PullRecovery) AdaptsAcrossBroadcasts() bool {
+	if aa, ok := pr.Inner.(hw.AdaptiveAlgorithm); ok {
+		return aa.AdaptsAcrossBroadcasts()
+	}
+	return false
+}
+
+// OnJoin 实现JoiningBroadcast接口 - 与内部算法的加入流程一致
+func (pb *PullRecoveryBroadcast) OnJoin(node, limit int, online func(int) bool) []int {
+	return hw.JoinRequestPeers(pb.Inner, pb.Broadcast, node, limit, online)
+}
+
+// SetScheduler 实现TimedBroadcast接口
+func (pb *PullRecoveryBroadcast) SetScheduler(s hw.Scheduler) {
+	pb.Sched = s
+	if tb, ok := pb.Broadcast.(hw.TimedBroadcast); ok {
+		tb.SetScheduler(s)
+	}
+}
+
+// RespondAnnounce 实现AnnouncingBroadcast接口
+// 转发决策由内部会话给出，并为每个目标设置补救定时器
+func (pb *PullRecoveryBroadcast) RespondAnnounce(msg *hw.Message) ([]int, []int) {
+	var push, announce []int
+	if ab, ok := pb.Broadcast.(hw.AnnouncingBroadcast); ok {
+		push, announce = ab.RespondAnnounce(msg)
+	} else {
+		push = pb.Broadcast.Respond(msg)
+	}
+
+	// 标签记录补救时请求的节点，使用负数与内部会话的定时器区分
+	u := msg.Dst
+	for _, v := range push {
+		pb.Sched.SetTimer(v, pb.Timeout, -(u + 1))
+	}
+	for _, v := range announce {
+		pb.Sched.SetTimer(v, pb.Timeout, -(u + 1))
+	}
+	return push, announce
+}
+
+// Respond 实现Broadcast接口 - 只返回推送列表（公告列表需通过RespondAnnounce获取）
+func (pb *PullRecoveryBroadcast) Respond(msg *hw.Message) []int {
+	push, _ := pb.RespondAnnounce(msg)
+	return push
+}
+
+// OnTimer 实现TimedBroadcast接口
+// 补救定时器到期时，若节点仍未收到消息，向转发方请求完整消息
+func (pb *PullRecoveryBroadcast) OnTimer(node, tag int) []int {
+	if tag >= 0 {
+		// 内部会话的定时器
+		if tb, ok := pb.Broadcast.(hw.TimedBroadcast); ok {
+			return tb.OnTimer(node, tag)
+		}
+		return nil
+	}
+
+	if !pb.Sched.Has(node) {
+		pb.Sched.SendAfter(node, -tag-1, 0, hw.MsgRequest)
+	}
+	return nil
+}
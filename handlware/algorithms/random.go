@@ -16,20 +16,22 @@ import (
 type RandomFlood struct {
 	Graph        *hw.Graph              // 随机图
 	Coords       []hw.LatLonCoordinate  // 节点坐标
-	TreeRoot     int                    // 当前广播树根节点
+	TreeRoot     int                    // 构建时指定的根节点（广播根节点由NewBroadcast指定）
 	RootFanout   int                    // 根节点扇出度
 	SecondFanout int                    // 第二层扇出度（未使用）
 	Fanout       int                    // 普通节点扇出度
+	Rng          *rand.Rand             // 随机数生成器
 }
 
 // NewRandomFlood 创建新的Random Flood算法实例
 // 参数:
 //   - n: 节点数
 //   - coords: 节点坐标数组
-//   - root: 广播根节点（用于初始化，可后续通过SetRoot更改）
+//   - root: 构建时的根节点（每次广播的根节点由NewBroadcast指定）
 //   - rootFanout: 根节点扇出度
 //   - fanout: 普通节点扇出度
-func NewRandomFlood(n int, coords []hw.LatLonCoordinate, root int, rootFanout, fanout int) *RandomFlood {
+//   - rng: 随机流（nil表示固定种子100）
+func NewRandomFlood(n int, coords []hw.LatLonCoordinate, root int, rootFanout, fanout int, rng *rand.Rand) *RandomFlood {
 	rf := &RandomFlood{
 		Graph:        hw.NewGraph(n),
 		Coords:       coords,
@@ -37,6 +39,7 @@ func NewRandomFlood(n int, coords []hw.LatLonCoordinate, root int, rootFanout, f
 		RootFanout:   rootFanout,
 		SecondFanout: fanout, // 未使用，保持兼容性
 		Fanout:       fanout,
+		Rng:          hw.NewRngOrDefault(rng, 100),
 	}
 
 	// 构建随机图
@@ -50,17 +53,17 @@ func (rf *RandomFlood) buildRandomGraph(n, fanout int) {
 	// 为每个节点随机选择fanout个出边邻居
 	for u := 0; u < n; u++ {
 		for k := 0; k < fanout; k++ {
-			v := rand.Intn(n)
+			v := rf.Rng.Intn(n)
 			// 尝试添加边，避免自环和重边
 			for !rf.Graph.AddEdge(u, v) {
-				v = rand.Intn(n)
+				v = rf.Rng.Intn(n)
 			}
 		}
 	}
 }
 
-// Respond 实现Algorithm接口 - 响应消息
-func (rf *RandomFlood) Respond(msg *hw.Message) []int {
+// Respond 实现Broadcast接口 - 响应消息
+func (rf *RandomFloodBroadcast) Respond(msg *hw.Message) []int {
 	u := msg.Dst
 	nbU := rf.Graph.Outbound(u)
 	ret := make([]int, 0, len(nbU))
@@ -73,10 +76,10 @@ func (rf *RandomFlood) Respond(msg *hw.Message) []int {
 	}
 
 	// 如果是根节点，可能需要增加额外的随机转发
-	if u == rf.TreeRoot && msg.Step == 0 {
+	if u == rf.Root && msg.Step == 0 {
 		remainDeg := rf.RootFanout - len(ret)
 		for i := 0; i < remainDeg; i++ {
-			v := rand.Intn(rf.Graph.N)
+			v := rf.Rng.Intn(rf.Graph.N)
 			if v != msg.Src && !hw.Contains(ret, v) {
 				ret = append(ret, v)
 			}
@@ -86,9 +89,20 @@ func (rf *RandomFlood) Respond(msg *hw.Message) []int {
 	return ret
 }
 
-// SetRoot 实现Algorithm接口 - 设置广播根节点
-func (rf *RandomFlood) SetRoot(root int) {
-	rf.TreeRoot = root
+// RandomFloodBroadcast RandomFlood的广播会话（图结构共享，根节点额外转发使用独立随机流）
+type RandomFloodBroadcast struct {
+	*RandomFlood
+	hw.BaseBroadcast
+	Rng *rand.Rand // 本次广播的随机流
+}
+
+// NewBroadcast 实现Algorithm接口 - 开启一次广播
+func (rf *RandomFlood) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &RandomFloodBroadcast{
+		RandomFlood:   rf,
+		BaseBroadcast: hw.BaseBroadcast{Root: root},
+		Rng:           hw.NewRngOrDefault(rng, 100),
+	}
 }
 
 // GetAlgoName 实现Algorithm接口 - 获取算法名称
@@ -101,6 +115,11 @@ func (rf *RandomFlood) NeedSpecifiedRoot() bool {
 	return false // Random Flood不需要为每个根重建图
 }
 
+// JoinPeers 实现JoiningAlgorithm接口 - 节点加入时连接出边邻居
+func (rf *RandomFlood) JoinPeers(node int) []int {
+	return rf.Graph.Outbound(node)
+}
+
 // PrintInfo 打印图信息（调试用）
 func (rf *RandomFlood) PrintInfo() {
 	avgOutbound := 0.0
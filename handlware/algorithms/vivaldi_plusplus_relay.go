@@ -116,6 +116,17 @@ type NodeRelayState struct {
 	RelearnEndTime time.Time
 	PeersHistory   [][]int // churn检测窗口（最近N个时间点的peers）
 	LastClusterID  int     // 上次的clusterID（用于检测变化）
+
+	Now time.Time  // 节点本地模拟时钟（由仿真推进，不使用墙上时钟）
+	Rng *rand.Rand // 随机兜底选择使用的随机流
+}
+
+// simEpoch 模拟时钟起点（模拟时间t毫秒对应 simEpoch + t）
+var simEpoch = time.Unix(0, 0)
+
+// simTime 将模拟时间（毫秒）转换为 time.Time
+func simTime(ms float64) time.Time {
+	return simEpoch.Add(time.Duration(ms * float64(time.Millisecond)))
 }
 
 // TransactionMessage 交易消息
@@ -127,19 +138,19 @@ type TransactionMessage struct {
 	Arrivals   map[int]time.Time // 从各邻居到达的时间（用于rank计算）
 }
 
-// NewTransactionMessage 创建新的交易消息
-func NewTransactionMessage(txID string, sourceNode int) *TransactionMessage {
+// NewTransactionMessage 创建新的交易消息（now为模拟时间）
+func NewTransactionMessage(txID string, sourceNode int, now time.Time) *TransactionMessage {
 	return &TransactionMessage{
 		TxID:       txID,
 		SourceNode: sourceNode,
-		Timestamp:  time.Now(),
+		Timestamp:  now,
 		SeenBy:     make(map[int]time.Time),
 		Arrivals:   make(map[int]time.Time),
 	}
 }
 
 // NewNodeRelayState 创建新的节点转发状态
-func NewNodeRelayState(nodeID int, clusterID int, peers []int, config *RelayStrategyConfig) *NodeRelayState {
+func NewNodeRelayState(nodeID int, clusterID int, peers []int, config *RelayStrategyConfig, rng *rand.Rand) *NodeRelayState {
 	if config == nil {
 		config = NewDefaultRelayStrategyConfig()
 	}
@@ -149,7 +160,7 @@ func NewNodeRelayState(nodeID int, clusterID int, peers []int, config *RelayStra
 		stats[peerID] = &NeighborStats{
 			EBar:         config.NeutralPrior,
 			FObs:         config.NeutralPrior,
-			LastUpdate:   time.Now(),
+			LastUpdate:   simEpoch,
 			MessageRanks: make([]RankRecord, 0),
 		}
 	}
@@ -163,6 +174,8 @@ func NewNodeRelayState(nodeID int, clusterID int, peers []int, config *RelayStra
 		InRelearnMode: false,
 		PeersHistory:  make([][]int, 0),
 		LastClusterID: clusterID,
+		Now:           simEpoch,
+		Rng:           hw.NewRngOrDefault(rng, int64(nodeID)),
 	}
 }
 
@@ -191,7 +204,7 @@ func calibrateSigmoid(eBar, alpha, mu float64) float64 {
 }
 
 // selectRandomSubset 从集合中随机选择指定数量的元素
-func selectRandomSubset(candidates []int, count int) []int {
+func selectRandomSubset(candidates []int, count int, rng *rand.Rand) []int {
 	if count <= 0 {
 		return []int{}
 	}
@@ -200,7 +213,7 @@ func selectRandomSubset(candidates []int, count int) []int {
 	}
 
 	selected := make([]int, count)
-	indices := rand.Perm(len(candidates))
+	indices := rng.Perm(len(candidates))
 	for i := 0; i < count; i++ {
 		selected[i] = candidates[indices[i]]
 	}
@@ -231,6 +244,16 @@ func partitionByCluster(peers []int, clusterIDs map[int]int, selfClusterID int)
 	return sameCluster, otherClusters
 }
 
+// sortedClusterKeys 按簇ID升序返回簇列表（避免map遍历顺序带来的不确定性）
+func sortedClusterKeys(clusters map[int][]int) []int {
+	keys := make([]int, 0, len(clusters))
+	for c := range clusters {
+		keys = append(keys, c)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
 // computeRanks 计算消息到达排名
 func computeRanks(arrivals map[int]time.Time) map[int]int {
 	// 按到达时间排序
@@ -245,6 +268,9 @@ func computeRanks(arrivals map[int]time.Time) map[int]int {
 	}
 
 	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].time.Equal(pairs[j].time) {
+			return pairs[i].peerID < pairs[j].peerID
+		}
 		return pairs[i].time.Before(pairs[j].time)
 	})
 
@@ -264,8 +290,8 @@ func computeRanks(arrivals map[int]time.Time) map[int]int {
 
 // ==================== 模块 A: 聚类集成 ====================
 
-// ComputeClusterAssignments 基于稳定节点计算簇分配
-func ComputeClusterAssignments(states []*hw.VivaldiPlusPlusState, k int) map[int]int {
+// ComputeClusterAssignments 基于稳定节点计算簇分配（seed为K-means随机种子）
+func ComputeClusterAssignments(states []*hw.VivaldiPlusPlusState, k int, seed int64) map[int]int {
 	n := len(states)
 	clusterIDs := make(map[int]int)
 
@@ -291,7 +317,7 @@ func ComputeClusterAssignments(states []*hw.VivaldiPlusPlusState, k int) map[int
 	}
 
 	// 调用 KMeansVirtual 进行聚类
-	clusterResult := hw.KMeansVirtual(allModels, k, 100, time.Now().UnixNano())
+	clusterResult := hw.KMeansVirtual(allModels, k, 100, seed)
 
 	// 构建 nodeID -> clusterID 映射
 	for i := 0; i < n; i++ {
@@ -375,11 +401,11 @@ func SelectRelays(
 			stats = &NeighborStats{
 				EBar:       config.NeutralPrior,
 				FObs:       config.NeutralPrior,
-				LastUpdate: time.Now(),
+				LastUpdate: state.Now,
 			}
 			state.Stats[peerID] = stats
 		}
-		probMap[peerID] = ComputeRelayProbability(stats, senderStats, config, time.Now())
+		probMap[peerID] = ComputeRelayProbability(stats, senderStats, config, state.Now)
 	}
 
 	// B) 随机兜底
@@ -391,14 +417,15 @@ func SelectRelays(
 	if dRand > len(candidates) {
 		dRand = len(candidates)
 	}
-	L_rand := selectRandomSubset(candidates, dRand)
+	L_rand := selectRandomSubset(candidates, dRand, state.Rng)
 
 	// C) 跨簇最小配额
 	L_cross := make([]int, 0)
 	sameCluster, otherClusters := partitionByCluster(candidates, allClusterIDs, state.ClusterID)
 
 	// 对每个异簇，按 P 升序取 min_cross_per_cluster 个
-	for _, clusterPeers := range otherClusters {
+	for _, c := range sortedClusterKeys(otherClusters) {
+		clusterPeers := otherClusters[c]
 		// 按概率排序（升序，优先选概率小的，保证跨簇扩散）
 		sort.Slice(clusterPeers, func(i, j int) bool {
 			return probMap[clusterPeers[i]] < probMap[clusterPeers[j]]
@@ -425,8 +452,8 @@ func SelectRelays(
 	if D_remaining > 0 {
 		// 优先异簇，再本簇
 		remainingOther := make([]int, 0)
-		for _, clusterPeers := range otherClusters {
-			for _, peerID := range clusterPeers {
+		for _, c := range sortedClusterKeys(otherClusters) {
+			for _, peerID := range otherClusters[c] {
 				if !used[peerID] {
 					remainingOther = append(remainingOther, peerID)
 				}
@@ -542,7 +569,7 @@ func UpdateNeighborStats(
 			stats = &NeighborStats{
 				EBar:       config.NeutralPrior,
 				FObs:       config.NeutralPrior,
-				LastUpdate: time.Now(),
+				LastUpdate: state.Now,
 			}
 			state.Stats[peerID] = stats
 		}
@@ -570,7 +597,7 @@ func UpdateNeighborStats(
 				TxID:        msg.TxID,
 				Rank:        rank,
 				Score:       score,
-				ArrivalTime: time.Now(),
+				ArrivalTime: state.Now,
 			})
 			// 保持窗口大小（例如最近100条）
 			if len(stats.MessageRanks) > 100 {
@@ -578,7 +605,7 @@ func UpdateNeighborStats(
 			}
 		}
 
-		stats.LastUpdate = time.Now()
+		stats.LastUpdate = state.Now
 	}
 
 	// 更新可观测转发率（对来源邻居）
@@ -586,9 +613,9 @@ func UpdateNeighborStats(
 	if len(arrivals) > 0 {
 		// 找到最早到达的邻居（可能是来源）
 		earliestPeer := -1
-		earliestTime := time.Now()
+		earliestTime := state.Now
 		for peerID, t := range arrivals {
-			if t.Before(earliestTime) {
+			if t.Before(earliestTime) || (t.Equal(earliestTime) && (earliestPeer < 0 || peerID < earliestPeer)) {
 				earliestTime = t
 				earliestPeer = peerID
 			}
@@ -703,7 +730,7 @@ func computeChurnRate(oldPeers, newPeers []int) float64 {
 // EnterRelearnMode 进入 relearn 模式
 func EnterRelearnMode(state *NodeRelayState, duration time.Duration) {
 	state.InRelearnMode = true
-	state.RelearnEndTime = time.Now().Add(duration)
+	state.RelearnEndTime = state.Now.Add(duration)
 
 	// 向中性先验轻度回拉（可选）
 	kappa := 0.1 // 回拉系数
@@ -728,6 +755,8 @@ func WarmupSimulation(
 	config *RelayStrategyConfig,
 	rounds int,
 	txPerRound int,
+	latency hw.LatencyModel,
+	rng *rand.Rand,
 ) []*NodeRelayState {
 	n := len(coords)
 	fmt.Printf("开始预热仿真：%d轮 × %d交易/轮\n", rounds, txPerRound)
@@ -739,14 +768,14 @@ func WarmupSimulation(
 		peers := make([]int, 0)
 		// 简化：使用随机邻居（实际应该从稳定集合或网络拓扑获取）
 		for j := 0; j < 20; j++ {
-			peerID := rand.Intn(n)
+			peerID := rng.Intn(n)
 			if peerID != i && !hw.Contains(peers, peerID) {
 				peers = append(peers, peerID)
 			}
 		}
 
 		clusterID := clusterIDs[i]
-		relayStates[i] = NewNodeRelayState(i, clusterID, peers, config)
+		relayStates[i] = NewNodeRelayState(i, clusterID, peers, config, rand.New(rand.NewSource(rng.Int63())))
 	}
 
 	// 预热循环
@@ -758,14 +787,14 @@ func WarmupSimulation(
 
 		for tx := 0; tx < txPerRound; tx++ {
 			// 随机选择源节点
-			sourceNode := rand.Intn(n)
+			sourceNode := rng.Intn(n)
 
-			// 创建交易消息
+			// 创建交易消息（每笔交易间隔1秒模拟时间）
 			txID := fmt.Sprintf("warmup_tx_%d_%d", round, tx)
-			msg := NewTransactionMessage(txID, sourceNode)
+			msg := NewTransactionMessage(txID, sourceNode, simTime(float64(txCounter)*1000))
 
 			// 模拟消息传播（事件驱动）
-			simulateMessagePropagation(relayStates, msg, coords, clusterIDs, config)
+			simulateMessagePropagation(relayStates, msg, latency, clusterIDs, config)
 
 			txCounter++
 		}
@@ -779,7 +808,7 @@ func WarmupSimulation(
 func simulateMessagePropagation(
 	relayStates []*NodeRelayState,
 	msg *TransactionMessage,
-	coords []hw.LatLonCoordinate,
+	latency hw.LatencyModel,
 	clusterIDs map[int]int,
 	config *RelayStrategyConfig,
 ) {
@@ -787,16 +816,18 @@ func simulateMessagePropagation(
 	//msgQueue := handlware.NewPriorityQueue()
 
 	// 初始化：源节点收到消息
+	now := msg.Timestamp
 	sourceNode := msg.SourceNode
-	msg.SeenBy[sourceNode] = time.Now()
-	msg.Arrivals[sourceNode] = time.Now()
+	msg.SeenBy[sourceNode] = now
+	msg.Arrivals[sourceNode] = now
 
 	// 源节点选择转发列表
+	relayStates[sourceNode].Now = now
 	relayList := SelectRelays(relayStates[sourceNode], msg, -1, clusterIDs)
 	for _, peerID := range relayList {
 		// 计算传播延迟
-		delay := hw.Distance(coords[sourceNode], coords[peerID]) + hw.FixedDelay
-		arrivalTime := time.Now().Add(time.Duration(delay) * time.Millisecond)
+		delay := latency.Delay(sourceNode, peerID) + hw.FixedDelay
+		arrivalTime := now.Add(time.Duration(delay) * time.Millisecond)
 		msg.Arrivals[peerID] = arrivalTime
 		// 这里简化：直接记录到达时间，实际应该用事件队列
 	}
@@ -808,7 +839,7 @@ func simulateMessagePropagation(
 	// 收集到达时间窗口内的所有到达
 	collectionWindow := time.Duration(config.ArrivalCollectionWindow * float64(time.Second))
 	_ = collectionWindow // 用于后续扩展
-	windowEnd := time.Now().Add(collectionWindow)
+	windowEnd := now.Add(collectionWindow)
 
 	// 模拟传播（简化：直接处理所有转发）
 	for _, relayNodeID := range relayList {
@@ -817,8 +848,9 @@ func simulateMessagePropagation(
 		}
 
 		// 节点收到消息
-		msg.SeenBy[relayNodeID] = time.Now()
+		msg.SeenBy[relayNodeID] = now
 		processed[relayNodeID] = true
+		relayStates[relayNodeID].Now = now
 
 		// 更新统计（收集窗口内的到达）
 		arrivals := make(map[int]time.Time)
@@ -837,8 +869,8 @@ func simulateMessagePropagation(
 		if len(msg.SeenBy) < n/10 { // 限制传播范围
 			for _, nextPeerID := range newRelayList {
 				if !processed[nextPeerID] {
-					delay := hw.Distance(coords[relayNodeID], coords[nextPeerID]) + hw.FixedDelay
-					arrivalTime := time.Now().Add(time.Duration(delay) * time.Millisecond)
+					delay := latency.Delay(relayNodeID, nextPeerID) + hw.FixedDelay
+					arrivalTime := now.Add(time.Duration(delay) * time.Millisecond)
 					msg.Arrivals[nextPeerID] = arrivalTime
 				}
 			}
@@ -900,12 +932,14 @@ func SimulateVivaldiPlusPlusRelay(
 	fmt.Println("步骤 2/5: 提取稳定节点并聚类...")
 	// 简化：直接使用所有节点进行聚类
 	k := 8 // 默认簇数
-	clusterIDs := ComputeClusterAssignments(states, k)
+	ctx := hw.NewSimContext(vivaldiConfig.RandSeed)
+	clusterIDs := ComputeClusterAssignments(states, k, hw.DeriveSeed(ctx.Seed, "relay/kmeans"))
 	fmt.Printf("聚类完成，共 %d 个簇\n", k)
 
 	// 3. 预热阶段
 	fmt.Println("步骤 3/5: 预热阶段...")
-	relayStates := WarmupSimulation(coords, states, clusterIDs, relayConfig, warmupRounds, txPerRound)
+	relayStates := WarmupSimulation(coords, states, clusterIDs, relayConfig, warmupRounds, txPerRound,
+		hw.NewGeoLatencyModel(coords), ctx.Derive("relay/warmup"))
 	fmt.Println("预热完成")
 
 	// 4. 正式仿真阶段（简化：这里只做统计收集）
@@ -933,7 +967,7 @@ func collectSimulationMetrics(relayStates []*NodeRelayState, clusterIDs map[int]
 
 	for _, state := range relayStates {
 		for peerID, stats := range state.Stats {
-			prob := ComputeRelayProbability(stats, nil, state.Config, time.Now())
+			prob := ComputeRelayProbability(stats, nil, state.Config, state.Now)
 			probs = append(probs, prob)
 
 			// 检查是否跨簇
@@ -994,6 +1028,13 @@ type VivaldiPlusPlusRelay struct {
 	ClusterIDs     map[int]int
 	Config         *RelayStrategyConfig
 	VivaldiConfig  *hw.VivaldiPlusPlusConfig
+}
+
+// VivaldiPlusPlusRelayBroadcast Vivaldi++ 传播策略的广播会话
+// 消息到达记录按广播隔离；邻居统计和拓扑调整写入共享的 RelayStates（策略在广播之间持续学习）
+type VivaldiPlusPlusRelayBroadcast struct {
+	*VivaldiPlusPlusRelay
+	hw.BaseBroadcast
 	MessageHistory map[int]map[string]time.Time // 节点ID -> (TxID -> 首次到达时间)
 	ArrivalHistory map[string]map[int]time.Time // TxID -> (节点ID -> 到达时间)
 }
@@ -1006,7 +1047,14 @@ func NewVivaldiPlusPlusRelay(
 	relayConfig *RelayStrategyConfig,
 	warmupRounds int,
 	txPerRound int,
+	latency hw.LatencyModel,
+	rng *rand.Rand,
 ) *VivaldiPlusPlusRelay {
+	if latency == nil {
+		// 与模拟器默认延迟模型一致（SimulatorConfig.GetLatencyModel）
+		latency = hw.NewGeoLatencyModel(coords)
+	}
+	rng = hw.NewRngOrDefault(rng, 100)
 	if vivaldiConfig == nil {
 		vivaldiConfig = hw.NewVivaldiPlusPlusConfig()
 	}
@@ -1034,11 +1082,11 @@ func NewVivaldiPlusPlusRelay(
 	// 聚类
 	fmt.Println("进行 K-means 聚类...")
 	k := 8
-	clusterIDs := ComputeClusterAssignments(states, k)
+	clusterIDs := ComputeClusterAssignments(states, k, rng.Int63())
 
 	// 预热
 	fmt.Printf("预热阶段：%d轮 × %d交易/轮...\n", warmupRounds, txPerRound)
-	relayStates := WarmupSimulation(coords, states, clusterIDs, relayConfig, warmupRounds, txPerRound)
+	relayStates := WarmupSimulation(coords, states, clusterIDs, relayConfig, warmupRounds, txPerRound, latency, rng)
 	fmt.Println("预热完成")
 
 	// 构建网络图（用于兼容 Algorithm 接口）
@@ -1055,7 +1103,6 @@ func NewVivaldiPlusPlusRelay(
 			SpecifiedRoot: false,
 			Graph:         graph,
 			Coords:        coords,
-			Root:          0,
 		},
 		Coords:         coords,
 		VivaldiStates:  states,
@@ -1063,13 +1110,27 @@ func NewVivaldiPlusPlusRelay(
 		ClusterIDs:     clusterIDs,
 		Config:         relayConfig,
 		VivaldiConfig:  vivaldiConfig,
-		MessageHistory: make(map[int]map[string]time.Time),
-		ArrivalHistory: make(map[string]map[int]time.Time),
 	}
 }
 
-// Respond 实现 Algorithm 接口
-func (v *VivaldiPlusPlusRelay) Respond(msg *hw.Message) []int {
+// NewBroadcast 实现 Algorithm 接口 - 开启一次广播
+func (v *VivaldiPlusPlusRelay) NewBroadcast(root int, rng *rand.Rand) hw.Broadcast {
+	return &VivaldiPlusPlusRelayBroadcast{
+		VivaldiPlusPlusRelay: v,
+		BaseBroadcast:        hw.BaseBroadcast{Root: root},
+		MessageHistory:       make(map[int]map[string]time.Time),
+		ArrivalHistory:       make(map[string]map[int]time.Time),
+	}
+}
+
+// AdaptsAcrossBroadcasts 实现 AdaptiveAlgorithm 接口
+// 每次转发都会更新节点的邻居统计和拓扑，多个广播不能在多个协程中同时执行
+func (v *VivaldiPlusPlusRelay) AdaptsAcrossBroadcasts() bool {
+	return true
+}
+
+// Respond 实现 Broadcast 接口
+func (v *VivaldiPlusPlusRelayBroadcast) Respond(msg *hw.Message) []int {
 	nodeID := msg.Dst
 	sourceNode := msg.Src
 
@@ -1102,8 +1163,9 @@ func (v *VivaldiPlusPlusRelay) Respond(msg *hw.Message) []int {
 		return []int{}
 	}
 
-	// 记录首次到达时间
+	// 记录首次到达时间，并推进节点本地模拟时钟
 	v.MessageHistory[nodeID][txID] = recvTime
+	state.Now = recvTime
 
 	// 记录到达历史（用于 rank 计算）
 	if v.ArrivalHistory[txID] == nil {
@@ -0,0 +1,198 @@
+package handlware
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ==================== 带宽模型 ====================
+// 默认情况下每次转发都使用相同的 CalculateTransmissionDelay(dataSize, bandwidth)，
+// 与节点的扇出度无关。BandwidthModel 为每个节点设置上传/下载带宽，
+// 并让节点的上行链路按转发列表顺序依次发送（可限制并行流数量），
+// 使大扇出节点（如Mercator根节点）在大数据包下付出真实的发送代价；
+// 同时到达同一节点的多条流共享该节点的下载带宽（见DownlinkScheduler）。
+
+// BandwidthModel 每个节点的上下行带宽
+type BandwidthModel struct {
+	Upload      []float64 // 每个节点的上传带宽（bps）
+	Download    []float64 // 每个节点的下载带宽（bps，<=0表示不限制）
+	MaxParallel int       // 上行链路最大并行流数（每条流平分上传带宽，<=0表示1）
+}
+
+// NewBandwidthModel 创建所有节点带宽相同的模型
+// 参数:
+//   - n: 节点数
+//   - upload: 上传带宽（bps）
+//   - download: 下载带宽（bps，<=0表示不限制）
+//   - maxParallel: 上行最大并行流数
+func NewBandwidthModel(n int, upload, download float64, maxParallel int) *BandwidthModel {
+	bm := &BandwidthModel{
+		Upload:      make([]float64, n),
+		Download:    make([]float64, n),
+		MaxParallel: maxParallel,
+	}
+	for i := 0; i < n; i++ {
+		bm.Upload[i] = upload
+		bm.Download[i] = download
+	}
+	return bm
+}
+
+// BandwidthClass 节点带宽等级（如家庭宽带、数据中心）
+type BandwidthClass struct {
+	Name     string  // 等级名称
+	Ratio    float64 // 节点比例
+	Upload   float64 // 上传带宽（bps）
+	Download float64 // 下载带宽（bps，<=0表示不限制）
+}
+
+// NewBandwidthModelFromClasses 按带宽等级比例随机为节点分配带宽
+// 比例之和不足1时，剩余节点使用最后一个等级
+func NewBandwidthModelFromClasses(n int, classes []BandwidthClass, maxParallel int, rng *rand.Rand) *BandwidthModel {
+	bm := &BandwidthModel{
+		Upload:      make([]float64, n),
+		Download:    make([]float64, n),
+		MaxParallel: maxParallel,
+	}
+	if len(classes) == 0 {
+		return bm
+	}
+
+	for i := 0; i < n; i++ {
+		r := rng.Float64()
+		chosen := classes[len(classes)-1]
+		acc := 0.0
+		for _, c := range classes {
+			acc += c.Ratio
+			if r < acc {
+				chosen = c
+				break
+			}
+		}
+		bm.Upload[i] = chosen.Upload
+		bm.Download[i] = chosen.Download
+	}
+	return bm
+}
+
+// parallel 获取有效的并行流数
+func (bm *BandwidthModel) parallel() int {
+	if bm.MaxParallel <= 0 {
+		return 1
+	}
+	return bm.MaxParallel
+}
+
+// TransferTime 计算u的一条上行流向v发送dataSize字节所需时间（ms）
+// 单流速率 = min(Upload[u] / MaxParallel, Download[v])
+// 这里只限制单条流的速率，多条流争用v的下行链路由DownlinkScheduler处理
+func (bm *BandwidthModel) TransferTime(u, v int, dataSize float64) float64 {
+	rate := bm.Upload[u] / float64(bm.parallel())
+	if bm.Download != nil && bm.Download[v] > 0 && bm.Download[v] < rate {
+		rate = bm.Download[v]
+	}
+	return CalculateTransmissionDelay(dataSize, rate)
+}
+
+// PrintInfo 打印带宽模型信息
+func (bm *BandwidthModel) PrintInfo() {
+	n := len(bm.Upload)
+	if n == 0 {
+		return
+	}
+	minUp, maxUp, sumUp := bm.Upload[0], bm.Upload[0], 0.0
+	for _, up := range bm.Upload {
+		sumUp += up
+		minUp = MinFloat64(minUp, up)
+		maxUp = MaxFloat64(maxUp, up)
+	}
+	fmt.Printf("带宽模型: %d个节点, 上传带宽 平均%.2f Mbps / 最小%.2f Mbps / 最大%.2f Mbps, 并行流数=%d\n",
+		n, sumUp/float64(n)/1e6, minUp/1e6, maxUp/1e6, bm.parallel())
+}
+
+// ==================== 上行链路调度 ====================
+
+// UplinkScheduler 上行链路调度器
+// 每个节点有MaxParallel条发送流，每次发送占用最早空闲的一条流，
+// 因此同一节点的转发列表会被依次串行化发送
+type UplinkScheduler struct {
+	Model    *BandwidthModel
+	SlotFree map[int][]float64 // 节点ID -> 每条流的空闲时刻（ms）
+}
+
+// NewUplinkScheduler 创建上行链路调度器
+func NewUplinkScheduler(model *BandwidthModel) *UplinkScheduler {
+	return &UplinkScheduler{
+		Model:    model,
+		SlotFree: make(map[int][]float64),
+	}
+}
+
+// Schedule 安排节点u在ready时刻之后向v发送dataSize字节
+// 返回: 实际开始发送时刻、发送完成时刻（ms）
+func (us *UplinkScheduler) Schedule(u, v int, ready, dataSize float64) (float64, float64) {
+	slots, ok := us.SlotFree[u]
+	if !ok {
+		slots = make([]float64, us.Model.parallel())
+		us.SlotFree[u] = slots
+	}
+
+	// 选择最早空闲的流
+	best := 0
+	for i := 1; i < len(slots); i++ {
+		if slots[i] < slots[best] {
+			best = i
+		}
+	}
+
+	start := MaxFloat64(ready, slots[best])
+	finish := start + us.Model.TransferTime(u, v, dataSize)
+	slots[best] = finish
+	return start, finish
+}
+
+// Reset 清空所有节点的发送状态
+func (us *UplinkScheduler) Reset() {
+	us.SlotFree = make(map[int][]float64)
+}
+
+// ==================== 下行链路调度 ====================
+
+// DownlinkScheduler 下行链路调度器
+// 每个节点只有一条下行链路，到达该节点的流按调度顺序依次接收：
+// 一次接收至少占用 dataSize / Download[v]，且不早于该流在上行端发送完成后到达的时刻
+type DownlinkScheduler struct {
+	Model  *BandwidthModel
+	FreeAt map[int]float64 // 节点ID -> 下行链路的空闲时刻（ms）
+}
+
+// NewDownlinkScheduler 创建下行链路调度器
+func NewDownlinkScheduler(model *BandwidthModel) *DownlinkScheduler {
+	return &DownlinkScheduler{
+		Model:  model,
+		FreeAt: make(map[int]float64),
+	}
+}
+
+// Schedule 安排v接收一条dataSize字节的流
+// 参数:
+//   - v: 接收节点
+//   - start: 流的第一个字节到达v的时刻（上行开始发送时刻 + 传播延迟，ms）
+//   - finish: 无争用时流接收完成的时刻（上行发送完成时刻 + 传播延迟，ms）
+//   - dataSize: 数据大小（Bytes）
+//
+// 返回: 考虑下行链路争用后的接收完成时刻（ms）
+func (ds *DownlinkScheduler) Schedule(v int, start, finish, dataSize float64) float64 {
+	if ds.Model.Download == nil || ds.Model.Download[v] <= 0 {
+		return finish
+	}
+	begin := MaxFloat64(start, ds.FreeAt[v])
+	done := MaxFloat64(finish, begin+CalculateTransmissionDelay(dataSize, ds.Model.Download[v]))
+	ds.FreeAt[v] = done
+	return done
+}
+
+// Reset 清空所有节点的接收状态
+func (ds *DownlinkScheduler) Reset() {
+	ds.FreeAt = make(map[int]float64)
+}
@@ -0,0 +1,114 @@
+package handlware
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ==================== 拜占庭转发节点 ====================
+// 恶意节点（malFlags）直接丢弃消息，容易被发现。拜占庭节点照常接收并参与协议，
+// 但以更隐蔽的方式破坏传播，对Perigee、Vivaldi++中继等按延迟优化邻居的协议影响更大：
+//   - 延迟转发：每次转发前增加固定延迟和随机延迟
+//   - 选择性转发：对每个目标以一定概率扣留不转发
+//   - 合谋转发：只向其他拜占庭节点转发
+// 行为作用于节点发出的所有完整消息和公告（推送、定时器转发、延迟发送、响应请求），
+// 因此对所有算法通用。拜占庭节点与恶意节点一样不计入统计。
+// 拜占庭节点从在线的正常节点中选出：不与恶意节点、离开节点重叠，也不参与广播过程中的churn（始终在线）。
+
+// ByzantineNodes 一次重复实验中的拜占庭节点及其行为（由AttackConfig生成）
+type ByzantineNodes struct {
+	Flags         []bool  // 是否为拜占庭节点
+	Delay         float64 // 固定延迟（ms）
+	DelayJitter   float64 // 随机延迟上限（ms，均匀分布）
+	WithholdRatio float64 // 对每个目标扣留的概率
+	Colluding     bool    // 只向其他拜占庭节点转发
+}
+
+// NewByzantineNodes 按攻击配置生成拜占庭节点，未配置拜占庭节点时返回nil
+// 参数:
+//   - n: 节点数
+//   - attackConfig: 攻击配置
+//   - excluded: 不能成为拜占庭节点的节点（恶意节点、离开节点，拜占庭节点从其余节点中选出）
+//   - rng: 随机流
+func NewByzantineNodes(n int, attackConfig *AttackConfig, excluded []bool, rng *rand.Rand) *ByzantineNodes {
+	if attackConfig.ByzantineRatio <= 0 {
+		return nil
+	}
+	return &ByzantineNodes{
+		Flags:         GenerateByzantineNodes(n, attackConfig.ByzantineRatio, excluded, rng),
+		Delay:         attackConfig.ByzantineDelay,
+		DelayJitter:   attackConfig.ByzantineDelayJitter,
+		WithholdRatio: attackConfig.ByzantineWithholdRatio,
+		Colluding:     attackConfig.ByzantineColluding,
+	}
+}
+
+// GenerateByzantineNodes 从未排除的节点中生成拜占庭节点标记
+// 拜占庭节点数为 n*ratio（候选节点不足时取全部候选节点），与排除的恶意/离开节点互不重叠，
+// 因此同时配置恶意节点或离开节点时实际的拜占庭比例仍为ratio
+func GenerateByzantineNodes(n int, ratio float64, excluded []bool, rng *rand.Rand) []bool {
+	flags := make([]bool, n)
+	candidates := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !excluded[i] {
+			candidates = append(candidates, i)
+		}
+	}
+	count := Min(int(float64(n)*ratio), len(candidates))
+
+	for i := 0; i < count; i++ {
+		node := candidates[rng.Intn(len(candidates))]
+		for flags[node] {
+			node = candidates[rng.Intn(len(candidates))]
+		}
+		flags[node] = true
+	}
+
+	if count > 0 {
+		fmt.Printf("生成 %d 个拜占庭节点 (%.1f%%)\n", count, ratio*100)
+	}
+
+	return flags
+}
+
+// Withhold 拜占庭节点u对转发列表的处理
+// 参数:
+//   - u: 发送节点
+//   - targets: 协议给出的目标列表
+//   - rng: 随机流
+//
+// 返回: 实际转发的目标和额外延迟（u不是拜占庭节点时原样返回）
+func (bn *ByzantineNodes) Withhold(u int, targets []int, rng *rand.Rand) ([]int, float64) {
+	if bn == nil || !bn.Flags[u] {
+		return targets, 0
+	}
+
+	kept := make([]int, 0, len(targets))
+	for _, v := range targets {
+		if bn.Colluding && !bn.Flags[v] {
+			continue
+		}
+		if bn.WithholdRatio > 0 && rng.Float64() < bn.WithholdRatio {
+			continue
+		}
+		kept = append(kept, v)
+	}
+
+	delay := bn.Delay
+	if bn.DelayJitter > 0 {
+		delay += rng.Float64() * bn.DelayJitter
+	}
+	return kept, delay
+}
+
+// excludeFlags 合并恶意标记与拜占庭标记（拜占庭节点不计入统计）
+func (bn *ByzantineNodes) excludeFlags(malFlags []bool) []bool {
+	if bn == nil {
+		return malFlags
+	}
+	flags := make([]bool, len(malFlags))
+	for i := range flags {
+		flags[i] = malFlags[i] || bn.Flags[i]
+	}
+	return flags
+}
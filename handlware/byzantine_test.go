@@ -0,0 +1,35 @@
+package handlware_test
+
+import (
+	"math/rand"
+	"testing"
+
+	hw "gomercator/handlware"
+)
+
+// TestGenerateByzantineNodesExcluded 拜占庭节点不与恶意/离开节点重叠，数量仍为 n*ratio
+func TestGenerateByzantineNodesExcluded(t *testing.T) {
+	n := 200
+	rng := rand.New(rand.NewSource(5))
+	malFlags := hw.GenerateMaliciousNodes(n, 0.2, rng)
+	leaveFlags := hw.GenerateLeaveNodes(n, 0.3, rng)
+	excluded := make([]bool, n)
+	for i := range excluded {
+		excluded[i] = malFlags[i] || leaveFlags[i]
+	}
+
+	flags := hw.GenerateByzantineNodes(n, 0.25, excluded, rng)
+	count := 0
+	for i, byz := range flags {
+		if !byz {
+			continue
+		}
+		count++
+		if excluded[i] {
+			t.Errorf("节点 %d 同时是拜占庭节点和恶意/离开节点", i)
+		}
+	}
+	if count != 50 {
+		t.Errorf("拜占庭节点数 %d，期望50", count)
+	}
+}
@@ -0,0 +1,263 @@
+package handlware
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// ==================== 广播过程中的节点动态（Churn） ====================
+// GenerateLeaveNodes 在广播开始前固定离开节点。ChurnSchedule 描述广播过程中
+// 按模拟时间发生的节点崩溃、主动离开、重新加入和新节点加入，由事件循环在对应时刻应用：
+//   - 崩溃：节点立即离线，邻居不知情，仍会向其发送（消息丢失）
+//   - 主动离开：节点离线并通知邻居，邻居不再向其转发
+//   - 重新加入 / 新节点加入：节点上线，邻居开始向其转发，
+//     并按算法的加入流程（JoinRequestPeers）向邻居请求正在传播的消息
+
+// JoinRequestPeers 节点在广播过程中上线时向其请求消息的邻居（最多limit个在线节点）
+// 会话实现JoiningBroadcast时按算法的加入流程选择，否则取JoiningAlgorithm.JoinPeers中的在线邻居，
+// 两者都未实现时返回nil
+// 参数:
+//   - algo: 广播算法
+//   - broadcast: 当前广播会话
+//   - node: 上线的节点
+//   - limit: 最多请求的邻居数
+//   - online: 判断节点当前是否在线且已知在网络中
+func JoinRequestPeers(algo Algorithm, broadcast Broadcast, node, limit int, online func(int) bool) []int {
+	if jb, ok := broadcast.(JoiningBroadcast); ok {
+		return jb.OnJoin(node, limit, online)
+	}
+	ja, ok := algo.(JoiningAlgorithm)
+	if !ok {
+		return nil
+	}
+	peers := make([]int, 0, limit)
+	for _, v := range ja.JoinPeers(node) {
+		if len(peers) >= limit {
+			break
+		}
+		if online(v) {
+			peers = append(peers, v)
+		}
+	}
+	return peers
+}
+
+// ChurnKind churn事件类型
+type ChurnKind int
+
+const (
+	ChurnCrash  ChurnKind = iota // 崩溃
+	ChurnLeave                   // 主动离开
+	ChurnRejoin                  // 重新加入（崩溃或离开后恢复）
+	ChurnJoin                    // 新节点加入（广播开始时不在网络中）
+)
+
+// String 获取事件类型名称
+func (k ChurnKind) String() string {
+	switch k {
+	case ChurnCrash:
+		return "crash"
+	case ChurnLeave:
+		return "leave"
+	case ChurnRejoin:
+		return "rejoin"
+	default:
+		return "join"
+	}
+}
+
+// ChurnEvent 一次churn事件
+type ChurnEvent struct {
+	Time float64   // 发生时刻（ms，相对广播开始）
+	Node int       // 节点ID
+	Kind ChurnKind // 事件类型
+}
+
+// ChurnConfig churn配置
+type ChurnConfig struct {
+	Horizon     float64 // churn事件发生的时间范围 [0, Horizon)（ms）
+	CrashRatio  float64 // 崩溃节点比例
+	LeaveRatio  float64 // 主动离开节点比例
+	RejoinRatio float64 // 崩溃/离开的节点中重新加入的比例
+	RejoinDelay float64 // 离线到重新加入的平均间隔（ms，指数分布）
+	JoinRatio   float64 // 广播过程中新加入的节点比例
+	SyncPeers   int     // 节点上线后向多少个在线邻居请求正在传播的消息
+}
+
+// NewChurnConfig 按churn率创建默认配置
+// 崩溃、主动离开、新加入各占rate/3，离线节点中一半在平均500ms后重新加入，
+// 上线的节点向3个在线邻居请求消息
+// 参数:
+//   - rate: 广播过程中发生状态变化的节点比例
+func NewChurnConfig(rate float64) *ChurnConfig {
+	return &ChurnConfig{
+		Horizon:     3000,
+		CrashRatio:  rate / 3,
+		LeaveRatio:  rate / 3,
+		RejoinRatio: 0.5,
+		RejoinDelay: 500,
+		JoinRatio:   rate / 3,
+		SyncPeers:   3,
+	}
+}
+
+// ChurnSchedule 一次广播的churn时间线
+type ChurnSchedule struct {
+	Events []ChurnEvent // 按时间排序的事件
+	Absent []bool       // 广播开始时尚未加入的新节点
+}
+
+// GenerateChurnSchedule 生成churn时间线
+// 参数:
+//   - n: 节点数
+//   - cc: churn配置
+//   - excluded: 不参与churn的节点（根节点、恶意/离开节点等，可为nil）
+//   - rng: 随机流
+//
+// 返回: churn时间线
+func GenerateChurnSchedule(n int, cc *ChurnConfig, excluded []bool, rng *rand.Rand) *ChurnSchedule {
+	cs := &ChurnSchedule{
+		Events: make([]ChurnEvent, 0),
+		Absent: make([]bool, n),
+	}
+
+	candidates := make([]int, 0, n)
+	for _, u := range rng.Perm(n) {
+		if excluded == nil || !excluded[u] {
+			candidates = append(candidates, u)
+		}
+	}
+
+	crashN := int(float64(n) * cc.CrashRatio)
+	leaveN := int(float64(n) * cc.LeaveRatio)
+	joinN := int(float64(n) * cc.JoinRatio)
+	if crashN+leaveN+joinN > len(candidates) {
+		fmt.Printf("警告: churn节点数 %d 超过可用节点数 %d，已截断\n", crashN+leaveN+joinN, len(candidates))
+	}
+
+	next := 0
+	pick := func(count int, kind ChurnKind) {
+		for i := 0; i < count && next < len(candidates); i++ {
+			u := candidates[next]
+			next++
+			t := rng.Float64() * cc.Horizon
+			cs.Events = append(cs.Events, ChurnEvent{Time: t, Node: u, Kind: kind})
+
+			if kind == ChurnJoin {
+				cs.Absent[u] = true
+			} else if rng.Float64() < cc.RejoinRatio {
+				cs.Events = append(cs.Events, ChurnEvent{Time: t + rng.ExpFloat64()*cc.RejoinDelay, Node: u, Kind: ChurnRejoin})
+			}
+		}
+	}
+	pick(crashN, ChurnCrash)
+	pick(leaveN, ChurnLeave)
+	pick(joinN, ChurnJoin)
+
+	sort.SliceStable(cs.Events, func(i, j int) bool {
+		return cs.Events[i].Time < cs.Events[j].Time
+	})
+	return cs
+}
+
+// ==================== 事件循环中的节点状态 ====================
+
+// churnState 事件循环中的节点在线状态
+type churnState struct {
+	events []ChurnEvent
+	next   int    // 下一个待应用的事件
+	online []bool // 节点是否在线（接收并处理消息）
+	known  []bool // 邻居是否认为该节点在网络中（主动离开和尚未加入的节点不会被发送）
+}
+
+// newChurnState 根据时间线初始化节点状态
+func newChurnState(n int, schedule *ChurnSchedule) *churnState {
+	cs := &churnState{
+		events: schedule.Events,
+		online: make([]bool, n),
+		known:  make([]bool, n),
+	}
+	for i := 0; i < n; i++ {
+		cs.online[i] = !schedule.Absent[i]
+		cs.known[i] = !schedule.Absent[i]
+	}
+	return cs
+}
+
+// due 是否有时刻不晚于t的待应用事件
+func (cs *churnState) due(t float64) bool {
+	return cs.next < len(cs.events) && cs.events[cs.next].Time <= t
+}
+
+// apply 应用下一个事件并返回该事件
+func (cs *churnState) apply() ChurnEvent {
+	ev := cs.events[cs.next]
+	cs.next++
+	switch ev.Kind {
+	case ChurnCrash:
+		cs.online[ev.Node] = false
+	case ChurnLeave:
+		cs.online[ev.Node] = false
+		cs.known[ev.Node] = false
+	default:
+		cs.online[ev.Node] = true
+		cs.known[ev.Node] = true
+	}
+	return ev
+}
+
+// offlineFlags 合并离开标记与当前离线节点（离线节点不计入覆盖率统计）
+func (cs *churnState) offlineFlags(leaveFlags []bool) []bool {
+	flags := make([]bool, len(leaveFlags))
+	for i := range flags {
+		flags[i] = leaveFlags[i] || !cs.online[i]
+	}
+	return flags
+}
+
+// ==================== Churn率扫描 ====================
+
+// ChurnSweepResult 一个算法在一个churn率下的结果
+type ChurnSweepResult struct {
+	AlgoName string      // 算法名称
+	Rate     float64     // churn率
+	Result   *TestResult // 模拟结果
+}
+
+// ChurnSweep 在不同churn率下运行各算法，比较覆盖率和延迟的退化
+// 参数:
+//   - reptTime: 每个churn率的重复次数
+//   - coords: 节点坐标数组
+//   - attackConfig: 攻击配置
+//   - algos: 参与比较的算法
+//   - config: 模拟器配置（Churn字段由扫描覆盖）
+//   - rates: churn率列表（见NewChurnConfig）
+//
+// 返回: 每个(算法, churn率)的结果
+func ChurnSweep(
+	reptTime int,
+	coords []LatLonCoordinate,
+	attackConfig *AttackConfig,
+	algos []Algorithm,
+	config *SimulatorConfig,
+	rates []float64,
+) []*ChurnSweepResult {
+
+	results := make([]*ChurnSweepResult, 0, len(algos)*len(rates))
+	for _, algo := range algos {
+		for _, rate := range rates {
+			fmt.Printf("Churn扫描: %s, churn率 %.2f\n", algo.GetAlgoName(), rate)
+			rateConfig := *config
+			rateConfig.Churn = NewChurnConfig(rate)
+			result := Simulation(reptTime, coords, attackConfig, algo, &rateConfig, nil)
+			results = append(results, &ChurnSweepResult{
+				AlgoName: algo.GetAlgoName(),
+				Rate:     rate,
+				Result:   result,
+			})
+			fmt.Printf("  覆盖率 %.2f%%, 平均延迟 %.2f ms\n", result.Coverage*100, result.AvgLatency)
+		}
+	}
+	return results
+}
@@ -0,0 +1,77 @@
+package handlware_test
+
+import (
+	"testing"
+
+	hw "gomercator/handlware"
+	"gomercator/handlware/algorithms"
+)
+
+// TestJoinRequestPeersOutbound 会话未实现JoiningBroadcast时，按路由表取前limit个在线出边邻居
+func TestJoinRequestPeersOutbound(t *testing.T) {
+	coords := syntheticCoords(100)
+	n := len(coords)
+	algo := algorithms.NewRandomFlood(n, coords, 0, 8, 8, hw.NewSimContext(1).Derive("random"))
+	outbound := algo.JoinPeers(5)
+	offline := outbound[0]
+	online := func(v int) bool { return v != 5 && v != offline }
+
+	peers := hw.JoinRequestPeers(algo, algo.NewBroadcast(0, nil), 5, 3, online)
+	if len(peers) != 3 {
+		t.Fatalf("邻居数 %d，期望3", len(peers))
+	}
+	for i, v := range peers {
+		if v != outbound[i+1] {
+			t.Errorf("第%d个邻居 %d，期望出边邻居 %d", i, v, outbound[i+1])
+		}
+	}
+}
+
+// TestMercatorJoinLookup Mercator按K桶查找：先取K0桶中最近的在线节点，
+// 某个桶中的节点都不在线时查找该桶范围内的在线节点代替
+func TestMercatorJoinLookup(t *testing.T) {
+	coords := syntheticCoords(300)
+	n := len(coords)
+	m := algorithms.NewMercator(n, coords, coords, 0, 2, 6, 20, 3)
+
+	node := -1
+	for u := 0; u < n && node < 0; u++ {
+		if len(m.KBuckets[u][0]) >= 2 {
+			node = u
+		}
+	}
+	if node < 0 {
+		t.Skip("没有K0桶中有至少2个节点的节点")
+	}
+
+	// 全部在线：优先返回K0桶中的节点，按距离排序
+	all := func(v int) bool { return v != node }
+	peers := hw.JoinRequestPeers(m, m.NewBroadcast(0, nil), node, 2, all)
+	if len(peers) != 2 || !hw.Contains(m.KBuckets[node][0], peers[0]) || !hw.Contains(m.KBuckets[node][0], peers[1]) {
+		t.Fatalf("邻居 %v 不在K0桶 %v 中", peers, m.KBuckets[node][0])
+	}
+	if hw.Distance(coords[node], coords[peers[0]]) > hw.Distance(coords[node], coords[peers[1]]) {
+		t.Errorf("K0桶中的邻居没有按距离排序: %v", peers)
+	}
+
+	// 所有路由表中的节点离线：每个非空桶范围通过查找得到一个不在路由表中的在线节点
+	inTable := make(map[int]bool)
+	for _, bucket := range m.KBuckets[node] {
+		for _, v := range bucket {
+			inTable[v] = true
+		}
+	}
+	rest := func(v int) bool { return v != node && !inTable[v] }
+	peers = hw.JoinRequestPeers(m, m.NewBroadcast(0, nil), node, 100, rest)
+	if len(peers) == 0 {
+		t.Fatal("路由表节点全部离线时没有找到替代节点")
+	}
+	seen := make(map[int]bool)
+	for _, v := range peers {
+		b := hw.GetGeoBucketIndex(m.NodeGeohash[node], m.NodeGeohash[v], m.TotalBits)
+		if !rest(v) || b == 0 || seen[b] {
+			t.Errorf("替代节点 %d（桶%d）不合法", v, b)
+		}
+		seen[b] = true
+	}
+}
@@ -0,0 +1,325 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ==================== 根节点样本与置信区间 ====================
+// AccumulateResults/AverageResults 只保留各指标的均值，无法判断两组配置之间的差异是否只是噪声。
+// 每次单根模拟结束时把该根节点的指标保存为一个 RootSample，按(重复, 根节点)收集到
+// TestResult.Samples，由此计算标准差和自助法（bootstrap）置信区间；
+// 两个算法在相同种子下运行时根节点序列相同，可以用 ComparePaired 做配对比较。
+
+const (
+	ConfidenceLevel    = 0.95 // 默认置信水平
+	BootstrapResamples = 1000 // 默认自助法重抽样次数
+)
+
+// RootSample 一个根节点的模拟指标（该根节点所有重复的平均值）
+type RootSample struct {
+	Run   int // 所属重复实验编号（Simulation的rept）
+	Index int // 根节点在本次重复中的序号
+	Root  int // 根节点
+
+	Coverage         float64   // 覆盖率
+	AvgLatency       float64   // 平均延迟（ms）
+	AvgBandwidth     float64   // 带宽消耗（重复消息率）
+	AvgDropped       float64   // 丢失消息率
+	AvgBytes         float64   // 全网发送字节数
+	Latency          []float64 // 全体节点的延迟百分位（未达到为NaN）
+	DeliveredLatency []float64 // 收到消息节点的延迟百分位
+}
+
+// newRootSample 由单根模拟的结果创建样本（复制数组）
+func newRootSample(root int, result *TestResult) *RootSample {
+	return &RootSample{
+		Root:             root,
+		Coverage:         result.Coverage,
+		AvgLatency:       result.AvgLatency,
+		AvgBandwidth:     result.AvgBandwidth,
+		AvgDropped:       result.AvgDropped,
+		AvgBytes:         result.AvgBytes,
+		Latency:          append([]float64(nil), result.Latency...),
+		DeliveredLatency: append([]float64(nil), result.DeliveredLatency...),
+	}
+}
+
+// SampleMetric 从根节点样本中取出的一个指标
+type SampleMetric struct {
+	Name          string                      // 指标名称
+	LowerIsBetter bool                        // 越小越好（延迟、带宽）还是越大越好（覆盖率）
+	Value         func(s *RootSample) float64 // 取值函数（NaN表示该样本中缺失）
+}
+
+// SampleMetrics 获取全部样本指标：标量指标以及全体/收到消息节点的各延迟百分位
+func SampleMetrics() []*SampleMetric {
+	metrics := []*SampleMetric{
+		{"Coverage", false, func(s *RootSample) float64 { return s.Coverage }},
+		{"AvgLatency", true, func(s *RootSample) float64 { return s.AvgLatency }},
+		{"Bandwidth", true, func(s *RootSample) float64 { return s.AvgBandwidth }},
+		{"Dropped", true, func(s *RootSample) float64 { return s.AvgDropped }},
+		{"Bytes", true, func(s *RootSample) float64 { return s.AvgBytes }},
+	}
+	for i := 0; i < NumPercentiles; i++ {
+		i := i
+		metrics = append(metrics, &SampleMetric{fmt.Sprintf("Latency%.2f", PercentileLevel(i)), true,
+			func(s *RootSample) float64 { return s.Latency[i] }})
+	}
+	for i := 0; i < NumPercentiles; i++ {
+		i := i
+		metrics = append(metrics, &SampleMetric{fmt.Sprintf("DeliveredLatency%.2f", PercentileLevel(i)), true,
+			func(s *RootSample) float64 { return s.DeliveredLatency[i] }})
+	}
+	return metrics
+}
+
+// FindSampleMetric 按名称查找样本指标，不存在时返回nil
+func FindSampleMetric(name string) *SampleMetric {
+	for _, m := range SampleMetrics() {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// ==================== 标准差与自助法置信区间 ====================
+
+// StdDev 计算样本标准差（n-1），样本数少于2时返回0
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := Mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// BootstrapCI 计算样本均值的自助法百分位置信区间
+// 参数:
+//   - values: 样本
+//   - level: 置信水平（如0.95）
+//   - resamples: 重抽样次数
+//   - rng: 随机流
+//
+// 返回: (下界, 上界)，空样本返回(NaN, NaN)
+func BootstrapCI(values []float64, level float64, resamples int, rng *rand.Rand) (float64, float64) {
+	n := len(values)
+	if n == 0 {
+		return math.NaN(), math.NaN()
+	}
+	means := make([]float64, resamples)
+	for r := 0; r < resamples; r++ {
+		sum := 0.0
+		for k := 0; k < n; k++ {
+			sum += values[rng.Intn(n)]
+		}
+		means[r] = sum / float64(n)
+	}
+	alpha := (1 - level) / 2
+	return Percentile(means, alpha), Percentile(means, 1-alpha)
+}
+
+// MetricSummary 一个指标在全部根节点样本上的统计
+type MetricSummary struct {
+	Metric string  // 指标名称
+	N      int     // 有效样本数（缺失的样本不计入）
+	Mean   float64 // 均值
+	Std    float64 // 标准差
+	CILow  float64 // 置信区间下界
+	CIHigh float64 // 置信区间上界
+}
+
+// SummarizeMetric 计算一个指标的均值、标准差和自助法置信区间（跳过缺失值）
+func SummarizeMetric(samples []*RootSample, metric *SampleMetric, level float64, resamples int, rng *rand.Rand) *MetricSummary {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if v := metric.Value(s); !math.IsNaN(v) {
+			values = append(values, v)
+		}
+	}
+	ms := &MetricSummary{Metric: metric.Name, N: len(values), Mean: math.NaN()}
+	if len(values) > 0 {
+		ms.Mean = Mean(values)
+	}
+	ms.Std = StdDev(values)
+	ms.CILow, ms.CIHigh = BootstrapCI(values, level, resamples, rng)
+	return ms
+}
+
+// Summaries 计算结果中全部样本指标的统计
+// 参数:
+//   - level: 置信水平
+//   - resamples: 自助法重抽样次数
+//   - rng: 随机流（如config.GetContext().Derive("bootstrap")）
+//
+// 返回: 按SampleMetrics顺序的指标统计（没有样本时为nil）
+func (r *TestResult) Summaries(level float64, resamples int, rng *rand.Rand) []*MetricSummary {
+	if len(r.Samples) == 0 {
+		return nil
+	}
+	metrics := SampleMetrics()
+	summaries := make([]*MetricSummary, len(metrics))
+	for i, m := range metrics {
+		summaries[i] = SummarizeMetric(r.Samples, m, level, resamples, rng)
+	}
+	return summaries
+}
+
+// defaultSummaries 结果文件使用的统计（固定随机流，同一结果的输出可重复）
+func defaultSummaries(r *TestResult) map[string]*MetricSummary {
+	out := make(map[string]*MetricSummary)
+	for _, ms := range r.Summaries(ConfidenceLevel, BootstrapResamples, NewSimContext(DefaultSeed).Derive("bootstrap")) {
+		out[ms.Metric] = ms
+	}
+	return out
+}
+
+// ==================== 配对比较 ====================
+
+// PairedComparison 两个算法在相同根节点上的配对比较（差值 = A - B）
+type PairedComparison struct {
+	Metric   string  // 指标名称
+	AlgoA    string  // 算法A
+	AlgoB    string  // 算法B
+	N        int     // 配对数（两边都有效的根节点）
+	Unpaired int     // 无法配对的A样本数（根节点不一致或B中缺失）
+	MeanA    float64 // A的均值
+	MeanB    float64 // B的均值
+	MeanDiff float64 // 配对差值的均值
+	StdDiff  float64 // 配对差值的标准差
+	CILow    float64 // 差值均值的自助法置信区间下界
+	CIHigh   float64 // 差值均值的自助法置信区间上界
+	Wins     int     // A优于B的根节点数
+	Losses   int     // A劣于B的根节点数
+	Ties     int     // 相同的根节点数
+	PValue   float64 // 符号翻转置换检验的双侧p值
+	ABetter  bool    // 置信区间不含0且偏向A
+	BBetter  bool    // 置信区间不含0且偏向B
+}
+
+// ComparePaired 比较算法A和B在相同(重复, 根节点)上的一个指标
+// 两次模拟需要使用相同的种子和攻击配置，使根节点序列一致；根节点不一致的样本不参与比较
+// 参数:
+//   - algoA/algoB: 算法名称（用于输出）
+//   - a/b: 两个算法的模拟结果
+//   - metric: 比较的指标
+//   - level: 置信水平
+//   - resamples: 自助法重抽样和置换检验的次数
+//   - rng: 随机流
+//
+// 返回: 配对比较结果
+func ComparePaired(algoA, algoB string, a, b *TestResult, metric *SampleMetric,
+	level float64, resamples int, rng *rand.Rand) *PairedComparison {
+
+	type sampleKey struct{ run, index int }
+	bySlot := make(map[sampleKey]*RootSample, len(b.Samples))
+	for _, s := range b.Samples {
+		bySlot[sampleKey{s.Run, s.Index}] = s
+	}
+
+	pc := &PairedComparison{Metric: metric.Name, AlgoA: algoA, AlgoB: algoB}
+	var valuesA, valuesB, diffs []float64
+	for _, sa := range a.Samples {
+		sb, ok := bySlot[sampleKey{sa.Run, sa.Index}]
+		if !ok || sb.Root != sa.Root {
+			pc.Unpaired++
+			continue
+		}
+		va, vb := metric.Value(sa), metric.Value(sb)
+		if math.IsNaN(va) || math.IsNaN(vb) {
+			continue
+		}
+		valuesA = append(valuesA, va)
+		valuesB = append(valuesB, vb)
+		diffs = append(diffs, va-vb)
+
+		better := va < vb
+		if !metric.LowerIsBetter {
+			better = va > vb
+		}
+		switch {
+		case va == vb:
+			pc.Ties++
+		case better:
+			pc.Wins++
+		default:
+			pc.Losses++
+		}
+	}
+	pc.N = len(diffs)
+	if pc.N == 0 {
+		pc.MeanA, pc.MeanB, pc.MeanDiff, pc.CILow, pc.CIHigh, pc.PValue = math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		return pc
+	}
+	pc.MeanA, pc.MeanB, pc.MeanDiff = Mean(valuesA), Mean(valuesB), Mean(diffs)
+	pc.StdDiff = StdDev(diffs)
+	pc.CILow, pc.CIHigh = BootstrapCI(diffs, level, resamples, rng)
+	pc.PValue = signFlipPValue(diffs, resamples, rng)
+	lower, higher := pc.CIHigh < 0, pc.CILow > 0
+	if metric.LowerIsBetter {
+		pc.ABetter, pc.BBetter = lower, higher
+	} else {
+		pc.ABetter, pc.BBetter = higher, lower
+	}
+	return pc
+}
+
+// CompareAllPaired 对全部样本指标做配对比较
+func CompareAllPaired(algoA, algoB string, a, b *TestResult, level float64, resamples int, rng *rand.Rand) []*PairedComparison {
+	metrics := SampleMetrics()
+	out := make([]*PairedComparison, len(metrics))
+	for i, m := range metrics {
+		out[i] = ComparePaired(algoA, algoB, a, b, m, level, resamples, rng)
+	}
+	if len(out) > 0 && out[0].Unpaired > 0 {
+		fmt.Printf("警告: %s 与 %s 有 %d 个根节点无法配对（种子或攻击配置不同？）\n", algoA, algoB, out[0].Unpaired)
+	}
+	return out
+}
+
+// signFlipPValue 配对差值均值为0的符号翻转置换检验（双侧）
+// 配对数不超过16时枚举全部符号组合，否则随机抽取resamples次
+func signFlipPValue(diffs []float64, resamples int, rng *rand.Rand) float64 {
+	n := len(diffs)
+	if n == 0 {
+		return math.NaN()
+	}
+	observed := math.Abs(Mean(diffs))
+	const eps = 1e-12
+
+	extreme, total := 0, 0
+	flipped := func(mask func(k int) bool) {
+		sum := 0.0
+		for k, d := range diffs {
+			if mask(k) {
+				sum -= d
+			} else {
+				sum += d
+			}
+		}
+		if math.Abs(sum/float64(n)) >= observed-eps {
+			extreme++
+		}
+		total++
+	}
+
+	if n <= 16 {
+		for bits := 0; bits < 1<<n; bits++ {
+			flipped(func(k int) bool { return bits&(1<<k) != 0 })
+		}
+	} else {
+		for r := 0; r < resamples; r++ {
+			flipped(func(int) bool { return rng.Intn(2) == 1 })
+		}
+		// 计入观测值本身，避免p值为0
+		extreme++
+		total++
+	}
+	return float64(extreme) / float64(total)
+}
@@ -0,0 +1,66 @@
+package handlware
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// ==================== 模拟上下文 ====================
+// 所有随机性都来自 SimContext 派生的子随机流：
+//   - 同一种子 -> 逐位相同的结果
+//   - 不同种子 -> 相互独立的重复实验
+//
+// 子随机流按名称派生（而不是按调用顺序），因此新增或调整某个模块的随机调用
+// 不会影响其他模块的随机序列。
+
+// DefaultSeed 默认随机种子（与原先的 rand.Seed(100) 保持一致）
+const DefaultSeed int64 = 100
+
+// SimContext 模拟上下文，携带种子和随机数生成器
+type SimContext struct {
+	Seed int64      // 本上下文的种子
+	Rng  *rand.Rand // 本上下文的主随机流
+}
+
+// NewSimContext 根据种子创建模拟上下文
+func NewSimContext(seed int64) *SimContext {
+	return &SimContext{
+		Seed: seed,
+		Rng:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// DeriveSeed 根据父种子和名称计算子种子
+// 名称经FNV-1a哈希后与父种子混合，再经过SplitMix64扰动
+func DeriveSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(splitMix64(uint64(seed) ^ h.Sum64()))
+}
+
+// splitMix64 SplitMix64扰动函数
+func splitMix64(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Derive 派生名为name的子随机流（相同名称总是得到相同序列）
+func (ctx *SimContext) Derive(name string) *rand.Rand {
+	return rand.New(rand.NewSource(DeriveSeed(ctx.Seed, name)))
+}
+
+// Sub 派生名为name的子上下文（用于每次重复实验、每个根节点等）
+func (ctx *SimContext) Sub(name string) *SimContext {
+	return NewSimContext(DeriveSeed(ctx.Seed, name))
+}
+
+// NewRngOrDefault rng为nil时返回以seed初始化的随机数生成器
+// 供各算法构造函数在调用方未提供子随机流时保持原有的固定种子行为
+func NewRngOrDefault(rng *rand.Rand, seed int64) *rand.Rand {
+	if rng == nil {
+		return rand.New(rand.NewSource(seed))
+	}
+	return rng
+}
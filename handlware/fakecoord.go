@@ -0,0 +1,248 @@
+package handlware
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ==================== 伪造坐标攻击实验 ====================
+// Mercator按节点声明的坐标（displayCoords）计算Geohash并组织K桶和K-ary树，
+// 而消息的实际延迟由真实坐标（realCoords）决定。谎报坐标的节点会被放进错误的
+// 地理分组，既拖慢自己收到消息的时间，也可能拖慢以它为中继的诚实节点。
+//
+// FakeCoordSweep 对每个Mercator变体扫描谎报比例和偏移度数：
+//   - 基线：同一变体在无谎报（display = real）时的结果
+//   - 攻击：GenerateFakeCoordinates生成的声明坐标构建的算法
+// 两次运行使用相同的根节点序列，并按 0=诚实 / 1=谎报 分组统计平均延迟，
+// 由此得到诚实节点和谎报节点各自的延迟膨胀。
+// 配置了坐标验证（Verify）时，再用验证后的声明坐标构建算法运行一次，
+// 报告检出率、误报率以及验证挽回的延迟。
+
+// CoordAlgorithmFactory 按真实坐标和声明坐标构建算法
+type CoordAlgorithmFactory func(realCoords, displayCoords []LatLonCoordinate) Algorithm
+
+// FakeCoordVariant 参与伪造坐标实验的Mercator变体
+type FakeCoordVariant struct {
+	Name        string                // 变体名称
+	GeoPrec     int                   // Geohash精度（写入Mercator结果）
+	BucketSize  int                   // K桶大小
+	K0Threshold int                   // K0桶阈值
+	KaryFactor  int                   // K-ary树分支因子
+	Build       CoordAlgorithmFactory // 算法构建函数
+}
+
+// FakeCoordConfig 伪造坐标实验配置
+type FakeCoordConfig struct {
+	Ratios    []float64     // 谎报坐标节点比例
+	Offsets   []float64     // 偏移度数（<=0表示完全随机坐标）
+	GeoPrec   int           // Geohash对比文件使用的精度
+	OutputDir string        // CSV输出目录（空表示当前目录）
+	Verify    *VerifyConfig // 基于RTT的坐标验证（nil表示不验证）
+}
+
+// NewFakeCoordConfig 创建默认伪造坐标实验配置
+func NewFakeCoordConfig() *FakeCoordConfig {
+	return &FakeCoordConfig{
+		Ratios:    []float64{0.05, 0.1, 0.2, 0.3},
+		Offsets:   []float64{5, 20, 60, 0},
+		GeoPrec:   GeoPrecisionDefault,
+		OutputDir: "",
+		Verify:    nil,
+	}
+}
+
+// FakeCoordResult 一个变体在一组(比例, 偏移)下的结果
+type FakeCoordResult struct {
+	Variant string      // 变体名称
+	Ratio   float64     // 谎报坐标节点比例
+	Offset  float64     // 偏移度数（<=0表示完全随机）
+	Liars   int         // 谎报节点数
+	Result  *TestResult // 攻击下的模拟结果
+
+	HonestLatency   float64 // 诚实节点平均延迟（ms）
+	HonestBaseline  float64 // 无谎报时同一批诚实节点的平均延迟（ms）
+	HonestInflation float64 // 诚实节点延迟膨胀（HonestLatency / HonestBaseline - 1）
+	HonestCoverage  float64 // 诚实节点覆盖率
+	LiarLatency     float64 // 谎报节点平均延迟（ms）
+	LiarBaseline    float64 // 无谎报时同一批节点的平均延迟（ms）
+	LiarInflation   float64 // 谎报节点延迟膨胀
+	LiarCoverage    float64 // 谎报节点覆盖率
+
+	// 坐标验证（未配置时为零值）
+	Verification          *CoordVerification // 验证结果
+	VerifiedResult        *TestResult        // 使用验证后坐标的模拟结果
+	VerifiedHonestLatency float64            // 验证后诚实节点平均延迟（ms）
+	VerifiedLiarLatency   float64            // 验证后谎报节点平均延迟（ms）
+	HonestRecovered       float64            // 验证挽回的诚实节点延迟膨胀比例（无膨胀时为0）
+}
+
+// OffsetName 获取偏移度数的名称（用于输出）
+func (r *FakeCoordResult) OffsetName() string {
+	return fakeOffsetName(r.Offset)
+}
+
+// fakeOffsetName 偏移度数<=0时为完全随机
+func fakeOffsetName(offset float64) string {
+	if offset <= 0 {
+		return "random"
+	}
+	return fmt.Sprintf("%.0f", offset)
+}
+
+// FakeCoordSweep 运行伪造坐标攻击实验，并自动输出对比CSV：
+//   - fake_coord_sweep.csv: 每个(变体, 比例, 偏移)的延迟膨胀汇总
+//   - geohash_comparison_r<比例>_o<偏移>.csv: 真实与声明坐标的Geohash对比
+//   - mercator_results.csv: 每次攻击运行的Mercator结果（追加写入）
+//   - coord_verification_r<比例>_o<偏移>.csv: 配置了坐标验证时，各节点的验证结果
+//
+// 参数:
+//   - reptTime: 每组参数的重复次数
+//   - coords: 真实坐标数组
+//   - attackConfig: 攻击配置（FakeCoordRatio由扫描覆盖）
+//   - variants: 参与比较的Mercator变体
+//   - config: 模拟器配置
+//   - fc: 实验配置
+//
+// 返回: 全部结果
+func FakeCoordSweep(
+	reptTime int,
+	coords []LatLonCoordinate,
+	attackConfig *AttackConfig,
+	variants []*FakeCoordVariant,
+	config *SimulatorConfig,
+	fc *FakeCoordConfig,
+) []*FakeCoordResult {
+
+	n := len(coords)
+	if fc.OutputDir != "" {
+		if err := os.MkdirAll(fc.OutputDir, 0755); err != nil {
+			fmt.Printf("警告: 创建输出目录失败: %v\n", err)
+		}
+	}
+	output := func(name string) string {
+		return filepath.Join(fc.OutputDir, name)
+	}
+
+	// 诚实节点的Geohash与谎报设置无关
+	encoder := NewGeohashEncoder(fc.GeoPrec)
+	realHash := make([]string, n)
+	for i, c := range coords {
+		realHash[i] = encoder.Encode(c.Lat, c.Lon)
+	}
+
+	// 同一(比例, 偏移)下各变体使用相同的谎报节点和伪造坐标
+	type fakeSetting struct {
+		ratio, offset float64
+		display       []LatLonCoordinate
+		flags         []bool
+		groups        *ClusterResult
+		verification  *CoordVerification
+	}
+	settings := make([]*fakeSetting, 0, len(fc.Ratios)*len(fc.Offsets))
+	for _, ratio := range fc.Ratios {
+		for _, offset := range fc.Offsets {
+			rng := config.GetContext().Derive(fmt.Sprintf("fakecoord/%.3f/%s", ratio, fakeOffsetName(offset)))
+			display, flags := GenerateFakeCoordinates(coords, ratio, offset, rng)
+
+			labels := make([]int, n)
+			fakeHash := make([]string, n)
+			for i, c := range display {
+				if flags[i] {
+					labels[i] = 1
+				}
+				fakeHash[i] = encoder.Encode(c.Lat, c.Lon)
+			}
+			// 保证两个分组都存在（比例为0时谎报分组为空）
+			groups := RegionClusters(labels)
+			if groups.K < 2 {
+				groups = NewClusterResult(2, n)
+				for i := range labels {
+					groups.ClusterList[0] = append(groups.ClusterList[0], i)
+				}
+				groups.ClusterCnt[0] = n
+			}
+
+			filename := output(fmt.Sprintf("geohash_comparison_r%.2f_o%s.csv", ratio, fakeOffsetName(offset)))
+			if err := WriteGeohashComparison(filename, n, realHash, fakeHash, flags); err != nil {
+				fmt.Printf("警告: 写入Geohash对比失败: %v\n", err)
+			}
+			var verification *CoordVerification
+			if fc.Verify != nil {
+				verifyRng := config.GetContext().Derive(fmt.Sprintf("fakecoord/verify/%.3f/%s", ratio, fakeOffsetName(offset)))
+				verification = VerifyCoordinates(display, config.GetRTTModel(coords), fc.Verify, flags, verifyRng)
+				filename := output(fmt.Sprintf("coord_verification_r%.2f_o%s.csv", ratio, fakeOffsetName(offset)))
+				if err := WriteCoordVerificationCSV(filename, display, flags, verification); err != nil {
+					fmt.Printf("警告: 写入坐标验证结果失败: %v\n", err)
+				}
+			}
+			settings = append(settings, &fakeSetting{ratio, offset, display, flags, groups, verification})
+		}
+	}
+
+	results := make([]*FakeCoordResult, 0, len(variants)*len(settings))
+	for _, variant := range variants {
+		fmt.Printf("伪造坐标实验: %s\n", variant.Name)
+		baseline := variant.Build(coords, coords)
+
+		for _, s := range settings {
+			fmt.Printf("  谎报比例 %.2f, 偏移 %s\n", s.ratio, fakeOffsetName(s.offset))
+
+			// 基线只依赖分组，与攻击运行使用相同根节点
+			baseResult := Simulation(reptTime, coords, attackConfig, baseline, config, s.groups)
+
+			fakeAttack := *attackConfig
+			fakeAttack.FakeCoordRatio = s.ratio
+			algo := variant.Build(coords, s.display)
+			result := Simulation(reptTime, coords, &fakeAttack, algo, config, s.groups)
+
+			r := &FakeCoordResult{
+				Variant:        variant.Name,
+				Ratio:          s.ratio,
+				Offset:         s.offset,
+				Liars:          CountFlags(s.flags),
+				Result:         result,
+				HonestLatency:  result.ClusterAvgLatency[0],
+				HonestBaseline: baseResult.ClusterAvgLatency[0],
+				HonestCoverage: result.RegionCoverage(0),
+				LiarLatency:    result.ClusterAvgLatency[1],
+				LiarBaseline:   baseResult.ClusterAvgLatency[1],
+				LiarCoverage:   result.RegionCoverage(1),
+			}
+			if r.HonestBaseline > 0 {
+				r.HonestInflation = r.HonestLatency/r.HonestBaseline - 1
+			}
+			if r.LiarBaseline > 0 {
+				r.LiarInflation = r.LiarLatency/r.LiarBaseline - 1
+			}
+			results = append(results, r)
+
+			fmt.Printf("  诚实节点延迟 %.2f ms (膨胀 %+.2f%%), 谎报节点延迟 %.2f ms (膨胀 %+.2f%%)\n",
+				r.HonestLatency, r.HonestInflation*100, r.LiarLatency, r.LiarInflation*100)
+
+			if s.verification != nil {
+				verifiedAlgo := variant.Build(coords, s.verification.Coords)
+				verified := Simulation(reptTime, coords, &fakeAttack, verifiedAlgo, config, s.groups)
+				r.Verification = s.verification
+				r.VerifiedResult = verified
+				r.VerifiedHonestLatency = verified.ClusterAvgLatency[0]
+				r.VerifiedLiarLatency = verified.ClusterAvgLatency[1]
+				if inflation := r.HonestLatency - r.HonestBaseline; inflation > 0 {
+					r.HonestRecovered = (r.HonestLatency - r.VerifiedHonestLatency) / inflation
+				}
+				fmt.Printf("  验证后诚实节点延迟 %.2f ms (挽回 %.2f%%)\n", r.VerifiedHonestLatency, r.HonestRecovered*100)
+			}
+
+			err := WriteMercatorResults(output("mercator_results.csv"), result, n, attackConfig.MaliciousRatio, s.ratio,
+				variant.GeoPrec, variant.BucketSize, variant.K0Threshold, variant.KaryFactor)
+			if err != nil {
+				fmt.Printf("警告: 写入Mercator结果失败: %v\n", err)
+			}
+		}
+	}
+
+	if err := WriteFakeCoordSweepCSV(output("fake_coord_sweep.csv"), results); err != nil {
+		fmt.Printf("警告: 写入伪造坐标实验结果失败: %v\n", err)
+	}
+	return results
+}
@@ -170,6 +170,22 @@ func ToBinary(geohash string) string {
 	return binary.String()
 }
 
+// BinaryToGeohash 将二进制字符串转换为Geohash字符串（ToBinary的逆运算，不足5位的尾部补0）
+func BinaryToGeohash(binary string) string {
+	geohash := strings.Builder{}
+	for i := 0; i < len(binary); i += 5 {
+		idx := 0
+		for j := i; j < i+5; j++ {
+			idx <<= 1
+			if j < len(binary) && binary[j] == '1' {
+				idx |= 1
+			}
+		}
+		geohash.WriteByte(Base32Charset[idx])
+	}
+	return geohash.String()
+}
+
 // ==================== 邻居查找 ====================
 
 // GetNeighbors 获取Geohash的8个邻居（北、东北、东、东南、南、西南、西、西北）
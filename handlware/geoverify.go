@@ -0,0 +1,232 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ==================== 基于RTT的坐标验证 ====================
+// Mercator直接使用节点自报的声明坐标计算Geohash，谎报坐标的节点可以进入任意桶。
+// 坐标验证借鉴基于约束的地理定位（CBG）：节点加入时由若干验证者测量到它的RTT，
+// 信号在光纤中的传播速度给出了距离上限：
+//
+//	LightRTT(声明坐标, 验证者坐标) <= (RTT(u, v) - b) / m + Slack
+//
+// 其中 RTT = m*LightRTT + b 是验证者的"最佳下界线"（bestline）：由验证者到校准节点的
+// (LightRTT, RTT) 样本拟合，位于全部样本下方且斜率不小于1（不快于光速）、截距不小于0，
+// 截距对应处理开销，斜率对应路由绕行。
+// 违反约束的验证者数不少于MinViolations时，节点被标记。按策略：
+//   - VerifyFlag:   只标记，仍使用声明坐标
+//   - VerifyReject: 拒绝声明坐标，改用最短RTT验证者的坐标（最短ping定位）
+//
+// 验证者只知道其他节点的声明坐标，谎报节点作为校准节点或验证者时会引入误差。
+
+// FiberSpeed 光纤中的信号传播速度（km/ms，约2/3光速）
+const FiberSpeed = 200.0
+
+// LightRTT 两个坐标间的物理RTT下界（ms）
+// Distance 按 dist/100km*2 换算为ms（即 dist = Distance*50 km），光纤往返下界为 2*dist/FiberSpeed
+func LightRTT(a, b LatLonCoordinate) float64 {
+	return 2 * Distance(a, b) * 50.0 / FiberSpeed
+}
+
+// VerifyPolicy 检测到违反约束后的处理策略
+type VerifyPolicy int
+
+const (
+	VerifyFlag   VerifyPolicy = iota // 只标记
+	VerifyReject                     // 拒绝声明坐标，使用RTT定位的坐标
+)
+
+// String 获取策略名称
+func (p VerifyPolicy) String() string {
+	if p == VerifyReject {
+		return "reject"
+	}
+	return "flag"
+}
+
+// VerifyConfig 坐标验证配置
+type VerifyConfig struct {
+	Verifiers     int          // 每个加入节点的验证者数
+	MinViolations int          // 判定为谎报所需的违反约束验证者数
+	Slack         float64      // 约束容差（ms）
+	Calibration   int          // 每个验证者拟合bestline使用的校准节点数
+	Policy        VerifyPolicy // 处理策略
+}
+
+// NewVerifyConfig 创建默认坐标验证配置
+func NewVerifyConfig() *VerifyConfig {
+	return &VerifyConfig{
+		Verifiers:     8,
+		MinViolations: 2,
+		Slack:         2.0,
+		Calibration:   16,
+		Policy:        VerifyReject,
+	}
+}
+
+// CoordVerification 一次坐标验证的结果
+type CoordVerification struct {
+	Flagged        []bool             // 被标记的节点
+	Coords         []LatLonCoordinate // 验证后的声明坐标（VerifyReject下被标记节点替换为定位坐标）
+	Violations     []int              // 每个节点违反约束的验证者数
+	Liars          int                // 实际谎报节点数（提供真值时）
+	Detected       int                // 被标记的谎报节点数
+	FalsePositives int                // 被标记的诚实节点数
+}
+
+// DetectionRate 谎报节点的检出率
+func (cv *CoordVerification) DetectionRate() float64 {
+	if cv.Liars == 0 {
+		return 0
+	}
+	return float64(cv.Detected) / float64(cv.Liars)
+}
+
+// FalsePositiveRate 诚实节点的误报率
+func (cv *CoordVerification) FalsePositiveRate() float64 {
+	honest := len(cv.Flagged) - cv.Liars
+	if honest <= 0 {
+		return 0
+	}
+	return float64(cv.FalsePositives) / float64(honest)
+}
+
+// VerifyCoordinates 用RTT约束验证全部节点的声明坐标
+// 参数:
+//   - claimed: 声明坐标
+//   - rtt: RTT模型（由真实位置决定，如config.GetRTTModel(realCoords)）
+//   - vc: 验证配置
+//   - liarFlags: 谎报真值（用于统计检出率和误报，可为nil）
+//   - rng: 随机流（选择验证者和校准节点）
+//
+// 返回: 验证结果
+func VerifyCoordinates(claimed []LatLonCoordinate, rtt LatencyModel, vc *VerifyConfig,
+	liarFlags []bool, rng *rand.Rand) *CoordVerification {
+
+	n := len(claimed)
+	cv := &CoordVerification{
+		Flagged:    make([]bool, n),
+		Coords:     make([]LatLonCoordinate, n),
+		Violations: make([]int, n),
+	}
+	copy(cv.Coords, claimed)
+
+	// 每个验证者的bestline
+	slope := make([]float64, n)
+	intercept := make([]float64, n)
+	for v := 0; v < n; v++ {
+		peers := samplePeers(n, v, vc.Calibration, rng)
+		xs := make([]float64, len(peers))
+		ys := make([]float64, len(peers))
+		for i, w := range peers {
+			xs[i] = LightRTT(claimed[v], claimed[w])
+			ys[i] = rtt.Delay(v, w)
+		}
+		slope[v], intercept[v] = fitBestline(xs, ys)
+	}
+
+	for u := 0; u < n; u++ {
+		nearest, nearestRTT := -1, 0.0
+		for _, v := range samplePeers(n, u, vc.Verifiers, rng) {
+			bound := (rtt.Delay(u, v) - intercept[v]) / slope[v]
+			if LightRTT(claimed[u], claimed[v]) > bound+vc.Slack {
+				cv.Violations[u]++
+			}
+			if nearest < 0 || bound < nearestRTT {
+				nearest, nearestRTT = v, bound
+			}
+		}
+
+		if cv.Violations[u] >= vc.MinViolations {
+			cv.Flagged[u] = true
+			if vc.Policy == VerifyReject && nearest >= 0 {
+				cv.Coords[u] = claimed[nearest]
+			}
+		}
+	}
+
+	if liarFlags != nil {
+		for u := 0; u < n; u++ {
+			if liarFlags[u] {
+				cv.Liars++
+				if cv.Flagged[u] {
+					cv.Detected++
+				}
+			} else if cv.Flagged[u] {
+				cv.FalsePositives++
+			}
+		}
+	}
+
+	fmt.Printf("坐标验证(%s): 标记 %d 个节点, 检出率 %.2f%%, 误报率 %.2f%%\n",
+		vc.Policy, CountFlags(cv.Flagged), cv.DetectionRate()*100, cv.FalsePositiveRate()*100)
+	return cv
+}
+
+// fitBestline 拟合位于全部样本下方、斜率>=1、截距>=0的直线 y = m*x + b，
+// 在经过两个样本点的候选直线中选择与样本总距离最小的一条
+// 返回: (斜率, 截距)
+func fitBestline(xs, ys []float64) (float64, float64) {
+	const eps = 1e-9
+
+	// 斜率为1、经过最低点的直线总是可行的
+	bestM, bestB := 1.0, math.Inf(1)
+	for i := range xs {
+		bestB = math.Min(bestB, ys[i]-xs[i])
+	}
+	if len(xs) == 0 {
+		return 1, 0
+	}
+	bestB = math.Max(0, bestB)
+
+	residual := func(m, b float64) float64 {
+		sum := 0.0
+		for k := range xs {
+			r := ys[k] - (m*xs[k] + b)
+			if r < -eps {
+				return math.Inf(1)
+			}
+			sum += r
+		}
+		return sum
+	}
+	bestRes := residual(bestM, bestB)
+
+	for i := range xs {
+		for j := i + 1; j < len(xs); j++ {
+			if math.Abs(xs[i]-xs[j]) < eps {
+				continue
+			}
+			m := (ys[j] - ys[i]) / (xs[j] - xs[i])
+			b := ys[i] - m*xs[i]
+			if m < 1 || b < 0 {
+				continue
+			}
+			if res := residual(m, b); res < bestRes {
+				bestM, bestB, bestRes = m, b, res
+			}
+		}
+	}
+	return bestM, bestB
+}
+
+// samplePeers 从 [0, n) 中随机选择最多count个不同于self的节点
+func samplePeers(n, self, count int, rng *rand.Rand) []int {
+	if count > n-1 {
+		count = n - 1
+	}
+	peers := make([]int, 0, count)
+	chosen := make(map[int]bool, count)
+	for len(peers) < count {
+		v := rng.Intn(n)
+		if v == self || chosen[v] {
+			continue
+		}
+		chosen[v] = true
+		peers = append(peers, v)
+	}
+	return peers
+}
@@ -0,0 +1,203 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+)
+
+// ==================== 按节点分组的结果分解 ====================
+// TestResult中的簇统计（ClusterAvgLatency等）依赖编译期常量K，且必须在模拟时传入ClusterResult。
+// NodeGrouping 描述任意的节点分组（geohash前缀、大洲、用户标签或任意K的ClusterResult），
+// 在模拟结束后由每个节点的累积统计（TestResult.Nodes）计算各组的覆盖率、延迟、跳数和负载，
+// 同一次模拟可以按多种分组分解，不需要重新运行。
+
+// NodeGrouping 节点分组
+type NodeGrouping struct {
+	Name    string   // 分组方式名称（如 "geohash2"、"continent"）
+	Labels  []string // 每个组的名称
+	GroupID []int    // 每个节点所属的组（-1表示不属于任何组）
+}
+
+// NewNodeGrouping 由每个节点的组ID创建分组
+// 参数:
+//   - name: 分组方式名称
+//   - groupID: 每个节点所属的组（-1表示不属于任何组）
+//   - labels: 组名称（可为nil或不完整，缺少的组使用组ID）
+func NewNodeGrouping(name string, groupID []int, labels []string) *NodeGrouping {
+	k := len(labels)
+	for _, g := range groupID {
+		if g+1 > k {
+			k = g + 1
+		}
+	}
+	names := make([]string, k)
+	for g := 0; g < k; g++ {
+		if g < len(labels) {
+			names[g] = labels[g]
+		} else {
+			names[g] = fmt.Sprintf("%d", g)
+		}
+	}
+	return &NodeGrouping{Name: name, Labels: names, GroupID: groupID}
+}
+
+// GroupByGeohash 按指定精度的geohash前缀分组
+func GroupByGeohash(coords []LatLonCoordinate, precision int) *NodeGrouping {
+	regions, prefixes := RegionsByGeohash(coords, precision)
+	return NewNodeGrouping(fmt.Sprintf("geohash%d", precision), regions, prefixes)
+}
+
+// ContinentBoxes 大洲查找表（粗略划分，按顺序匹配，不在任何区域内的节点属于 "other"）
+var ContinentBoxes = []*BoundingBox{
+	BoxEurope,
+	NewBoundingBox("africa", -35, 37, -20, 52),
+	NewBoundingBox("south_america", -60, 12, -82, -30),
+	NewBoundingBox("north_america", 7, 85, -170, -50),
+	BoxOceania,
+	NewBoundingBox("asia", -10, 80, 26, 180),
+}
+
+// GroupByBoxes 按经纬度矩形区域分组，节点属于第一个包含它的区域，其余节点属于 "other"
+func GroupByBoxes(name string, coords []LatLonCoordinate, boxes ...*BoundingBox) *NodeGrouping {
+	labels := make([]string, len(boxes)+1)
+	for r, box := range boxes {
+		labels[r] = box.Name
+	}
+	labels[len(boxes)] = "other"
+	return NewNodeGrouping(name, RegionsByBoxes(coords, boxes...), labels)
+}
+
+// GroupByContinent 按大洲查找表ContinentBoxes分组
+func GroupByContinent(coords []LatLonCoordinate) *NodeGrouping {
+	return GroupByBoxes("continent", coords, ContinentBoxes...)
+}
+
+// GroupByLabels 按用户提供的节点标签分组（标签为空字符串的节点不属于任何组，组按标签首次出现的顺序编号）
+func GroupByLabels(name string, labels []string) *NodeGrouping {
+	groupID := make([]int, len(labels))
+	names := make([]string, 0)
+	index := make(map[string]int)
+	for i, label := range labels {
+		if label == "" {
+			groupID[i] = -1
+			continue
+		}
+		g, ok := index[label]
+		if !ok {
+			g = len(names)
+			index[label] = g
+			names = append(names, label)
+		}
+		groupID[i] = g
+	}
+	return NewNodeGrouping(name, groupID, names)
+}
+
+// GroupByCluster 按聚类结果分组（任意K）
+func GroupByCluster(name string, clusterResult *ClusterResult) *NodeGrouping {
+	groupID := append([]int(nil), clusterResult.ClusterID...)
+	grouping := NewNodeGrouping(name, groupID, nil)
+	// 保留没有节点的簇
+	for g := len(grouping.Labels); g < clusterResult.K; g++ {
+		grouping.Labels = append(grouping.Labels, fmt.Sprintf("%d", g))
+	}
+	return grouping
+}
+
+// ==================== 分组统计 ====================
+
+// GroupBreakdown 一个节点组在所有广播中的统计
+type GroupBreakdown struct {
+	Grouping   string // 分组方式名称
+	Group      int    // 组ID
+	Label      string // 组名称
+	Nodes      int    // 组内节点数
+	Broadcasts int    // 组内节点计入统计的广播次数之和
+	Received   int    // 组内节点收到消息的次数之和
+
+	Coverage      float64 // 覆盖率（收到次数 / 计入统计的次数）
+	MeanLatency   float64 // 收到消息时的平均接收时刻（ms，从未收到为NaN）
+	P50Latency    float64 // 接收时刻的中位数
+	P90Latency    float64 // 接收时刻的90分位
+	P95Latency    float64 // 接收时刻的95分位
+	MaxLatency    float64 // 最晚的接收时刻
+	AvgDepth      float64 // 收到消息时的平均跳数
+	AvgDuplicates float64 // 每个节点每次广播收到的平均重复消息数
+
+	AvgMsgsSent  float64 // 每个节点每次广播发送的平均消息数
+	AvgBytesSent float64 // 每个节点每次广播的平均上行字节数
+	AvgMsgsRecv  float64 // 每个节点每次广播到达的平均消息数
+	AvgBytesRecv float64 // 每个节点每次广播的平均下行字节数
+	SentShare    float64 // 组内节点承担的上行字节占全部节点的比例
+}
+
+// Breakdown 按分组计算每个组的统计（组按组ID顺序排列，包含没有节点的组）
+// 参数:
+//   - grouping: 节点分组（节点数应与模拟一致）
+//
+// 返回: 每个组的统计
+func (ns *NodeStats) Breakdown(grouping *NodeGrouping) []*GroupBreakdown {
+	k := len(grouping.Labels)
+	breakdowns := make([]*GroupBreakdown, k)
+	times := make([][]float64, k)
+	depthSum := make([]int, k)
+	duplicates := make([]int, k)
+	msgsSent := make([]int, k)
+	msgsRecv := make([]int, k)
+	bytesSent := make([]float64, k)
+	bytesRecv := make([]float64, k)
+	for g := 0; g < k; g++ {
+		breakdowns[g] = &GroupBreakdown{Grouping: grouping.Name, Group: g, Label: grouping.Labels[g]}
+	}
+
+	totalSent := 0.0
+	for i, g := range grouping.GroupID {
+		totalSent += ns.BytesSent[i]
+		if g < 0 {
+			continue
+		}
+		b := breakdowns[g]
+		b.Nodes++
+		b.Broadcasts += ns.Broadcasts[i]
+		b.Received += ns.Received[i]
+		times[g] = append(times[g], ns.RecvTimes[i]...)
+		depthSum[g] += ns.DepthSum[i]
+		duplicates[g] += ns.Duplicates[i]
+		msgsSent[g] += ns.MsgsSent[i]
+		msgsRecv[g] += ns.MsgsRecv[i]
+		bytesSent[g] += ns.BytesSent[i]
+		bytesRecv[g] += ns.BytesRecv[i]
+	}
+
+	for g, b := range breakdowns {
+		b.Coverage, b.AvgDuplicates = math.NaN(), math.NaN()
+		b.MeanLatency, b.P50Latency, b.P90Latency, b.P95Latency, b.MaxLatency = math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		b.AvgDepth = math.NaN()
+		b.AvgMsgsSent, b.AvgBytesSent, b.AvgMsgsRecv, b.AvgBytesRecv = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		b.SentShare = math.NaN()
+
+		if b.Broadcasts > 0 {
+			b.Coverage = float64(b.Received) / float64(b.Broadcasts)
+			b.AvgDuplicates = float64(duplicates[g]) / float64(b.Broadcasts)
+		}
+		if b.Received > 0 {
+			b.MeanLatency = Mean(times[g])
+			b.P50Latency = Percentile(times[g], 0.5)
+			b.P90Latency = Percentile(times[g], 0.9)
+			b.P95Latency = Percentile(times[g], 0.95)
+			b.MaxLatency = Percentile(times[g], 1)
+			b.AvgDepth = float64(depthSum[g]) / float64(b.Received)
+		}
+		if b.Nodes > 0 && ns.Recorded > 0 {
+			scale := float64(b.Nodes * ns.Recorded)
+			b.AvgMsgsSent = float64(msgsSent[g]) / scale
+			b.AvgBytesSent = bytesSent[g] / scale
+			b.AvgMsgsRecv = float64(msgsRecv[g]) / scale
+			b.AvgBytesRecv = bytesRecv[g] / scale
+		}
+		if totalSent > 0 {
+			b.SentShare = bytesSent[g] / totalSent
+		}
+	}
+	return breakdowns
+}
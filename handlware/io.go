@@ -3,6 +3,7 @@ package handlware
 import (
 	"bufio"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -68,6 +69,282 @@ func ReadGeoCoordinates(filename string) ([]LatLonCoordinate, error) {
 	return coords, nil
 }
 
+// parseRTTValue 解析RTT矩阵中的单个值
+// "-"、"NaN"、"x" 以及负数均视为缺失（返回-1）
+func parseRTTValue(token string) (float64, error) {
+	switch strings.ToLower(token) {
+	case "-", "nan", "x", "":
+		return -1, nil
+	}
+	v, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return -1, nil
+	}
+	return v, nil
+}
+
+// splitRTTLine 按空白或逗号切分一行
+func splitRTTLine(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// ReadRTTMatrixDense 从文件读取稠密RTT矩阵
+// 文件格式:
+// 第一行: 测量点数量 m
+// 接下来m行: 每行m个RTT值（ms），空白或逗号分隔，"-"/NaN/负数表示缺失
+// 以#开头的行为注释；测量点标签为行号（"0" ~ "m-1"）
+func ReadRTTMatrixDense(filename string) (*RTTMatrix, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	lineNo := 0
+	nextLine := func() (string, bool) {
+		for scanner.Scan() {
+			lineNo++
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			return line, true
+		}
+		return "", false
+	}
+
+	// 读取第一行：测量点数量
+	line, ok := nextLine()
+	if !ok {
+		return nil, fmt.Errorf("文件为空")
+	}
+	size, err := strconv.Atoi(line)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析测量点数量: %v", err)
+	}
+
+	labels := make([]string, size)
+	for i := 0; i < size; i++ {
+		labels[i] = strconv.Itoa(i)
+	}
+	m := NewRTTMatrix(labels)
+
+	for i := 0; i < size; i++ {
+		line, ok := nextLine()
+		if !ok {
+			return nil, fmt.Errorf("RTT矩阵不完整，期望%d行，只读取到%d行", size, i)
+		}
+		parts := splitRTTLine(line)
+		if len(parts) != size {
+			return nil, fmt.Errorf("第%d行格式错误: 期望%d个值，实际%d个", lineNo, size, len(parts))
+		}
+		for j := 0; j < size; j++ {
+			v, err := parseRTTValue(parts[j])
+			if err != nil {
+				return nil, fmt.Errorf("第%d行第%d列RTT解析失败: %v", lineNo, j+1, err)
+			}
+			m.RTT[i][j] = v
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件出错: %v", err)
+	}
+
+	return m, nil
+}
+
+// ReadRTTMatrixSparse 从文件读取稀疏RTT测量记录
+// 文件格式: 每行 "src dst rtt"（空白或逗号分隔），src/dst为测量点标签
+// 以#开头的行为注释；同一节点对出现多次时取平均值
+// symmetric=true时，只测量了单向的节点对用同一RTT补齐反向
+func ReadRTTMatrixSparse(filename string, symmetric bool) (*RTTMatrix, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	type record struct {
+		src, dst string
+		rtt      float64
+	}
+
+	var records []record
+	var labels []string
+	seen := make(map[string]bool)
+	addLabel := func(label string) {
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := splitRTTLine(line)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("第%d行格式错误: %s", lineNo, line)
+		}
+		rtt, err := parseRTTValue(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行RTT解析失败: %v", lineNo, err)
+		}
+		addLabel(parts[0])
+		addLabel(parts[1])
+		if rtt >= 0 {
+			records = append(records, record{src: parts[0], dst: parts[1], rtt: rtt})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件出错: %v", err)
+	}
+
+	m := NewRTTMatrix(labels)
+	size := m.Size()
+	sum := make([][]float64, size)
+	cnt := make([][]int, size)
+	for i := 0; i < size; i++ {
+		sum[i] = make([]float64, size)
+		cnt[i] = make([]int, size)
+	}
+	for _, r := range records {
+		i, j := m.Index[r.src], m.Index[r.dst]
+		sum[i][j] += r.rtt
+		cnt[i][j]++
+	}
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			if cnt[i][j] > 0 {
+				m.RTT[i][j] = sum[i][j] / float64(cnt[i][j])
+			}
+		}
+	}
+	if symmetric {
+		for i := 0; i < size; i++ {
+			for j := 0; j < size; j++ {
+				if m.RTT[i][j] < 0 && m.RTT[j][i] >= 0 {
+					m.RTT[i][j] = m.RTT[j][i]
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// ReadNodeMatrixMapping 读取节点到RTT矩阵行的映射
+// 文件格式: 每行 "nodeID label"，label为矩阵中的测量点标签
+// 未出现在文件中的节点映射为-1（由延迟模型的Fallback补齐）
+func ReadNodeMatrixMapping(filename string, m *RTTMatrix, n int) ([]int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	nodeMap := make([]int, n)
+	for i := range nodeMap {
+		nodeMap[i] = -1
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := splitRTTLine(line)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("第%d行格式错误: %s", lineNo, line)
+		}
+		node, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行节点ID解析失败: %v", lineNo, err)
+		}
+		if node < 0 || node >= n {
+			return nil, fmt.Errorf("第%d行节点ID越界: %d", lineNo, node)
+		}
+		row, ok := m.Index[parts[1]]
+		if !ok {
+			return nil, fmt.Errorf("第%d行测量点标签不存在: %s", lineNo, parts[1])
+		}
+		nodeMap[node] = row
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件出错: %v", err)
+	}
+
+	return nodeMap, nil
+}
+
+// ReadMeasurementPointCoords 读取RTT矩阵测量点的地理坐标
+// 文件格式: 每行 "label lat lon"
+// 返回与矩阵行对齐的坐标，没有坐标的测量点为NaN（MapNodesToNearest会跳过）
+func ReadMeasurementPointCoords(filename string, m *RTTMatrix) ([]LatLonCoordinate, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	points := make([]LatLonCoordinate, m.Size())
+	for i := range points {
+		points[i] = LatLonCoordinate{Lat: math.NaN(), Lon: math.NaN()}
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := splitRTTLine(line)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("第%d行格式错误: %s", lineNo, line)
+		}
+		row, ok := m.Index[parts[0]]
+		if !ok {
+			continue // 矩阵中没有该测量点
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行纬度解析失败: %v", lineNo, err)
+		}
+		lon, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行经度解析失败: %v", lineNo, err)
+		}
+		points[row] = LatLonCoordinate{Lat: lat, Lon: lon}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件出错: %v", err)
+	}
+
+	return points, nil
+}
+
 // ==================== 输出函数 ====================
 
 // WriteSimulationResults 写入模拟结果到CSV文件
@@ -87,17 +364,16 @@ func WriteSimulationResults(filename string, result *TestResult, algoName string
 
 	// 写入表头
 	fmt.Fprintf(writer, "#node, mal node, Bandwidth, ")
-	for p := 0.05; p <= 1.0; p += 0.05 {
-		fmt.Fprintf(writer, "%.2f, ", p)
-	}
-	fmt.Fprintf(writer, "\n")
+	writePercentileHeader(writer)
 
-	// 写入数据
+	// 写入数据（全体节点的延迟百分位，未达到的百分位为空）
 	fmt.Fprintf(writer, "%d, %.2f, %.2f, ", n, malNode, result.AvgBandwidth)
-	for i := 0; i < len(result.Latency); i++ {
-		fmt.Fprintf(writer, "%.2f, ", result.Latency[i])
-	}
-	fmt.Fprintf(writer, "\n")
+	writeMetricValues(writer, result.Latency, 2)
+	writeMetricRow(writer, "latency reached", 3, result.LatencyReached, 4)
+	writeMetricRow(writer, "delivered latency", 3, result.DeliveredLatency, 2)
+	summaries := defaultSummaries(result)
+	writeSpreadRows(writer, summaries, 3)
+	writeOracleRows(writer, result, 3)
 
 	// 写入深度分布
 	fmt.Fprintf(writer, "depth pdf\n")
@@ -116,6 +392,23 @@ func WriteSimulationResults(filename string, result *TestResult, algoName string
 	// 写入平均统计
 	fmt.Fprintf(writer, "avg depth = %.2f\n", avgDepth)
 	fmt.Fprintf(writer, "avg latency = %.2f\n", result.AvgLatency)
+	fmt.Fprintf(writer, "coverage = %.4f\n", result.Coverage)
+	for _, name := range []string{"AvgLatency", "Coverage", "Bandwidth"} {
+		if ms, ok := summaries[name]; ok {
+			fmt.Fprintf(writer, "%s std = %s, %.0f%% ci = [%s, %s], n = %d\n", name, formatMetric(ms.Std, 4),
+				ConfidenceLevel*100, formatMetric(ms.CILow, 4), formatMetric(ms.CIHigh, 4), ms.N)
+		}
+	}
+	fmt.Fprintf(writer, "avg bytes = %.0f\n", result.AvgBytes)
+	fmt.Fprintf(writer, "avg bytes saved = %.0f\n", result.AvgBytesSaved)
+	fmt.Fprintf(writer, "avg dropped = %.4f\n", result.AvgDropped)
+	fmt.Fprintf(writer, "avg offline dropped = %.4f\n", result.AvgOfflineDropped)
+	if result.Nodes != nil {
+		for _, lf := range result.Nodes.LoadFairness() {
+			fmt.Fprintf(writer, "%s load max = %.2f, mean = %.2f, gini = %.4f, top1%% share = %.4f\n",
+				lf.Metric, lf.Max, lf.Mean, lf.Gini, lf.Top1Share)
+		}
+	}
 
 	// 写入簇统计
 	fmt.Fprintf(writer, "cluster avg depth\n")
@@ -135,11 +428,87 @@ func WriteSimulationResults(filename string, result *TestResult, algoName string
 	for i := 0; i < MaxDepth; i++ {
 		fmt.Fprintf(writer, "%.2f, ", result.AvgDist[i])
 	}
-	fmt.Fprintf(writer, "\n\n")
+	fmt.Fprintf(writer, "\n")
+
+	// 写入覆盖时间曲线
+	fmt.Fprintf(writer, "time to coverage\n")
+	for i := 0; i < CoverageLevels; i++ {
+		fmt.Fprintf(writer, "%.2f, ", CoverageLevel(i))
+	}
+	fmt.Fprintf(writer, "\n")
+	writeMetricValues(writer, result.CoverageTime, 2)
+	writeMetricValues(writer, result.CoverageReached, 4)
+	fmt.Fprintf(writer, "\n")
 
 	return nil
 }
 
+// writePercentileHeader 写入延迟百分位表头（0.05, 0.10, ..., 1.00）
+func writePercentileHeader(writer *bufio.Writer) {
+	for i := 0; i < NumPercentiles; i++ {
+		fmt.Fprintf(writer, "%.2f, ", PercentileLevel(i))
+	}
+	fmt.Fprintf(writer, "\n")
+}
+
+// formatMetric 格式化可能缺失的指标（NaN写为空字段）
+func formatMetric(v float64, prec int) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
+
+// writeMetricValues 写入一行指标值（NaN写为空字段）
+func writeMetricValues(writer *bufio.Writer, values []float64, prec int) {
+	for _, v := range values {
+		fmt.Fprintf(writer, "%s, ", formatMetric(v, prec))
+	}
+	fmt.Fprintf(writer, "\n")
+}
+
+// writeMetricRow 写入一行带名称的指标，以空列对齐到数据行的百分位列
+// lead: 数据行中百分位之前的列数
+func writeMetricRow(writer *bufio.Writer, name string, lead int, values []float64, prec int) {
+	fmt.Fprintf(writer, "%s, ", name)
+	for i := 1; i < lead; i++ {
+		fmt.Fprintf(writer, ", ")
+	}
+	writeMetricValues(writer, values, prec)
+}
+
+// writeSpreadRows 写入各延迟百分位在根节点之间的标准差和置信区间（没有根节点样本时不写入）
+func writeSpreadRows(writer *bufio.Writer, summaries map[string]*MetricSummary, lead int) {
+	if len(summaries) == 0 {
+		return
+	}
+	std := make([]float64, NumPercentiles)
+	low := make([]float64, NumPercentiles)
+	high := make([]float64, NumPercentiles)
+	for i := 0; i < NumPercentiles; i++ {
+		ms := summaries[fmt.Sprintf("Latency%.2f", PercentileLevel(i))]
+		std[i], low[i], high[i] = ms.Std, ms.CILow, ms.CIHigh
+		if ms.N < 2 {
+			std[i] = math.NaN()
+		}
+	}
+	writeMetricRow(writer, "latency std", lead, std, 2)
+	writeMetricRow(writer, fmt.Sprintf("latency %.0f%% ci low", ConfidenceLevel*100), lead, low, 2)
+	writeMetricRow(writer, fmt.Sprintf("latency %.0f%% ci high", ConfidenceLevel*100), lead, high, 2)
+}
+
+// writeOracleRows 在延迟百分位行之后写入延迟下界及比值行（未启用Oracle时不写入）
+// lead: 延迟百分位之前的列数
+func writeOracleRows(writer *bufio.Writer, result *TestResult, lead int) {
+	if result.OracleLatency == nil {
+		return
+	}
+	writeMetricRow(writer, "full mesh bound", lead, result.OracleLatency, 2)
+	writeMetricRow(writer, "latency / full mesh", lead, result.LatencyRatio, 2)
+	writeMetricRow(writer, "bounded degree bound", lead, result.BoundedOracleLatency, 2)
+	writeMetricRow(writer, "latency / bounded degree", lead, result.BoundedLatencyRatio, 2)
+}
+
 // WriteFigData 写入图表数据（简化版，用于绘图）
 func WriteFigData(filename string, result *TestResult, algoName string) error {
 	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -154,10 +523,11 @@ func WriteFigData(filename string, result *TestResult, algoName string) error {
 	fmt.Fprintf(writer, "%s, ", algoName)
 	//写入平均带宽
 	fmt.Fprintf(writer, "%.2f, ", result.AvgBandwidth)
-	for i := 0; i < len(result.Latency); i++ {
-		fmt.Fprintf(writer, "%.2f, ", result.Latency[i])
+	// 延迟百分位（未达到的百分位为空），最后一列为覆盖率
+	for _, v := range result.Latency {
+		fmt.Fprintf(writer, "%s, ", formatMetric(v, 2))
 	}
-	fmt.Fprintf(writer, "\n")
+	fmt.Fprintf(writer, "%.4f\n", result.Coverage)
 
 	return nil
 }
@@ -285,17 +655,16 @@ func WriteMercatorResults(filename string, result *TestResult, n int, malNode, f
 
 	// 写入表头
 	fmt.Fprintf(writer, "#node, mal node, fake coord, Bandwidth, ")
-	for p := 0.05; p <= 1.0; p += 0.05 {
-		fmt.Fprintf(writer, "%.2f, ", p)
-	}
-	fmt.Fprintf(writer, "\n")
+	writePercentileHeader(writer)
 
 	// 写入数据
 	fmt.Fprintf(writer, "%d, %.2f, %.2f, %.2f, ", n, malNode, fakeCoordRatio, result.AvgBandwidth)
-	for i := 0; i < len(result.Latency); i++ {
-		fmt.Fprintf(writer, "%.2f, ", result.Latency[i])
-	}
-	fmt.Fprintf(writer, "\n\n")
+	writeMetricValues(writer, result.Latency, 2)
+	writeMetricRow(writer, "latency reached", 4, result.LatencyReached, 4)
+	writeMetricRow(writer, "delivered latency", 4, result.DeliveredLatency, 2)
+	writeSpreadRows(writer, defaultSummaries(result), 4)
+	writeOracleRows(writer, result, 4)
+	fmt.Fprintf(writer, "coverage = %.4f\n\n", result.Coverage)
 
 	return nil
 }
@@ -316,12 +685,498 @@ func WriteMercatorFigData(filename string, result *TestResult, n int, malNode, f
 	fmt.Fprintf(writer, "mercator, %d, %.2f, %.2f, %.2f, GEO=%d, BKT=%d, K0T=%d, KARY=%d, ",
 		n, malNode, fakeCoordRatio, result.AvgBandwidth, geoPrecision, bucketSize, k0Threshold, karyFactor)
 
-	// 只输出前20个百分位（0.05-1.00）
-	for i := 0; i < Min(20, len(result.Latency)); i++ {
-		fmt.Fprintf(writer, "%.3f, ", result.Latency[i])
+	// 延迟百分位（0.05-1.00，未达到的百分位为空），最后一列为覆盖率
+	for _, v := range result.Latency {
+		fmt.Fprintf(writer, "%s, ", formatMetric(v, 3))
 	}
-	fmt.Fprintf(writer, "\n")
+	fmt.Fprintf(writer, "%.4f\n", result.Coverage)
+
+	return nil
+}
+
+// WriteStreamMessagesCSV 将连续消息流中每条消息的统计写入CSV文件
+// 参数:
+//   - filename: 输出文件名
+//   - sr: 连续消息流模拟结果
+//
+// 返回: 错误信息（如果有）
+func WriteStreamMessagesCSV(filename string, sr *StreamResult) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "ID,Kind,Root,Size,InjectTime,Coverage,Delivered,AvgLatency,P50Latency,P90Latency,MaxLatency,Sends,DupMsg,Dropped,AvgQueueDelay\n")
+	for _, m := range sr.Messages {
+		fmt.Fprintf(writer, "%d,%s,%d,%.0f,%.2f,%.4f,%t,%.2f,%.2f,%.2f,%.2f,%d,%d,%d,%.2f\n",
+			m.ID, m.Kind, m.Root, m.Size, m.InjectTime,
+			m.Coverage, m.Delivered, m.AvgLatency, m.P50Latency, m.P90Latency, m.MaxLatency,
+			m.Sends, m.DupMsg, m.Dropped, m.AvgQueueDelay)
+	}
+
+	fmt.Printf("✓ 连续消息流统计已保存到 %s，共 %d 条消息\n", filename, len(sr.Messages))
+	return nil
+}
+
+// WriteChurnSweepCSV 将churn率扫描结果写入CSV文件
+// 参数:
+//   - filename: 输出文件名
+//   - results: ChurnSweep的结果
+//
+// 返回: 错误信息（如果有）
+func WriteChurnSweepCSV(filename string, results []*ChurnSweepResult) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,ChurnRate,Coverage,AvgLatency,Latency50,Latency90,DeliveredLatency50,DeliveredLatency90,Bandwidth,Dropped,OfflineDropped\n")
+	for _, r := range results {
+		fmt.Fprintf(writer, "%s,%.3f,%.4f,%.2f,%s,%s,%.2f,%.2f,%.2f,%.4f,%.4f\n",
+			r.AlgoName, r.Rate, r.Result.Coverage, r.Result.AvgLatency,
+			formatMetric(r.Result.Latency[9], 2), formatMetric(r.Result.Latency[17], 2),
+			r.Result.DeliveredLatency[9], r.Result.DeliveredLatency[17], r.Result.AvgBandwidth, r.Result.AvgDropped, r.Result.AvgOfflineDropped)
+	}
+
+	fmt.Printf("✓ Churn扫描结果已保存到 %s，共 %d 条记录\n", filename, len(results))
+	return nil
+}
+
+// WriteRegionCoverageCSV 写入区域故障场景下各存活区域的覆盖率
+// 参数:
+//   - filename: 输出文件名
+//   - scenario: 场景名称
+//   - result: 模拟结果（需传入区域划分运行）
+//   - regions: 区域划分
+//   - names: 区域名称（可为nil，使用区域ID）
+func WriteRegionCoverageCSV(filename, scenario string, result *TestResult, regions *ClusterResult, names []string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Scenario,Region,Nodes,Surviving,Covered,Coverage\n")
+	count := 0
+	for c := 0; c < len(result.ClusterEligible); c++ {
+		if result.ClusterEligible[c] == 0 {
+			continue
+		}
+		name := fmt.Sprintf("%d", c)
+		if c < len(names) {
+			name = names[c]
+		}
+		fmt.Fprintf(writer, "%s,%s,%d,%.1f,%.1f,%.4f\n",
+			scenario, name, regions.ClusterCnt[c], result.ClusterEligible[c], result.ClusterCovered[c], result.RegionCoverage(c))
+		count++
+	}
+
+	fmt.Printf("✓ 区域覆盖率已保存到 %s，共 %d 个存活区域\n", filename, count)
+	return nil
+}
+
+// WriteSecurityReportCSV 写入Eclipse/Sybil攻击的安全报告
+// 参数:
+//   - filename: 输出文件名
+//   - results: 各实验结果
+func WriteSecurityReportCSV(filename string, results []*EclipseResult) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,Victim,Sybils,VictimPeers,OutboundControl,InboundControl,OutCoverage,OutCensored,InCensored\n")
+	for _, r := range results {
+		fmt.Fprintf(writer, "%s,%d,%d,%d,%.4f,%.4f,%.4f,%.4f,%.4f\n",
+			r.AlgoName, r.Victim, r.Sybils, r.VictimPeers, r.OutboundControl, r.InboundControl,
+			r.OutCoverage, r.OutCensored, r.InCensored)
+	}
+
+	fmt.Printf("✓ 安全报告已保存到 %s，共 %d 条记录\n", filename, len(results))
+	return nil
+}
+
+// WriteTargetedSweepCSV 写入针对性攻击扫描结果（每个策略一条覆盖率-移除比例曲线）
+// 参数:
+//   - filename: 输出文件名
+//   - results: 扫描结果
+func WriteTargetedSweepCSV(filename string, results []*TargetedSweepResult) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,Strategy,Fraction,Removed,Coverage,AvgLatency,Latency90,DeliveredLatency90,Bandwidth\n")
+	for _, r := range results {
+		fmt.Fprintf(writer, "%s,%s,%.3f,%d,%.4f,%.2f,%s,%.2f,%.2f\n",
+			r.AlgoName, r.Strategy, r.Fraction, r.Removed, r.Result.Coverage,
+			r.Result.AvgLatency, formatMetric(r.Result.Latency[17], 2), r.Result.DeliveredLatency[17], r.Result.AvgBandwidth)
+	}
+
+	fmt.Printf("✓ 针对性攻击扫描结果已保存到 %s，共 %d 条记录\n", filename, len(results))
+	return nil
+}
+
+// WriteFakeCoordSweepCSV 写入伪造坐标实验结果（诚实节点与谎报节点的延迟膨胀）
+// 参数:
+//   - filename: 输出文件名
+//   - results: 实验结果
+func WriteFakeCoordSweepCSV(filename string, results []*FakeCoordResult) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Variant,FakeRatio,Offset,Liars,Coverage,Bandwidth,"+
+		"HonestLatency,HonestBaseline,HonestInflation,HonestCoverage,"+
+		"LiarLatency,LiarBaseline,LiarInflation,LiarCoverage,"+
+		"DetectionRate,FalsePositiveRate,VerifiedHonestLatency,VerifiedLiarLatency,HonestRecovered\n")
+	for _, r := range results {
+		fmt.Fprintf(writer, "%s,%.3f,%s,%d,%.4f,%.2f,%.2f,%.2f,%.4f,%.4f,%.2f,%.2f,%.4f,%.4f,",
+			r.Variant, r.Ratio, r.OffsetName(), r.Liars, r.Result.Coverage, r.Result.AvgBandwidth,
+			r.HonestLatency, r.HonestBaseline, r.HonestInflation, r.HonestCoverage,
+			r.LiarLatency, r.LiarBaseline, r.LiarInflation, r.LiarCoverage)
+		if r.Verification != nil {
+			fmt.Fprintf(writer, "%.4f,%.4f,%.2f,%.2f,%.4f\n",
+				r.Verification.DetectionRate(), r.Verification.FalsePositiveRate(),
+				r.VerifiedHonestLatency, r.VerifiedLiarLatency, r.HonestRecovered)
+		} else {
+			fmt.Fprintf(writer, ",,,,\n")
+		}
+	}
+
+	fmt.Printf("✓ 伪造坐标实验结果已保存到 %s，共 %d 条记录\n", filename, len(results))
+	return nil
+}
+
+// WriteCoordVerificationCSV 写入每个节点的坐标验证结果
+// 参数:
+//   - filename: 输出文件名
+//   - claimed: 声明坐标
+//   - liarFlags: 谎报真值（可为nil）
+//   - cv: 验证结果
+func WriteCoordVerificationCSV(filename string, claimed []LatLonCoordinate, liarFlags []bool, cv *CoordVerification) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "NodeID,Liar,ClaimedLat,ClaimedLon,Violations,Flagged,VerifiedLat,VerifiedLon\n")
+	for i := range claimed {
+		liar, flagged := 0, 0
+		if liarFlags != nil && liarFlags[i] {
+			liar = 1
+		}
+		if cv.Flagged[i] {
+			flagged = 1
+		}
+		fmt.Fprintf(writer, "%d,%d,%.4f,%.4f,%d,%d,%.4f,%.4f\n",
+			i, liar, claimed[i].Lat, claimed[i].Lon, cv.Violations[i], flagged, cv.Coords[i].Lat, cv.Coords[i].Lon)
+	}
+
+	fmt.Printf("✓ 坐标验证结果已保存到 %s，共 %d 个节点\n", filename, len(claimed))
+	return nil
+}
+
+// WriteVivaldiAttackCSV 写入Vivaldi攻击与防御实验结果
+// 参数:
+//   - filename: 输出文件名
+//   - results: 实验结果
+func WriteVivaldiAttackCSV(filename string, results []*VivaldiAttackResult) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Variant,Attack,Defense,Ratio,ActualAvg,ActualMedian,ActualP95,ActualHighRate,"+
+		"ReportedAvg,ReportedMedian,ReportedP95,AttackRejectRate,FalseRejectRate\n")
+	for _, r := range results {
+		fmt.Fprintf(writer, "%s,%s,%s,%.3f,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f\n",
+			r.Variant, r.Attack, r.Defense, r.Ratio,
+			r.Actual.AvgError, r.Actual.MedianError, r.Actual.P95Error, r.Actual.HighErrorRate,
+			r.Reported.AvgError, r.Reported.MedianError, r.Reported.P95Error,
+			r.AttackRejectRate(), r.FalseRejectRate())
+	}
+
+	fmt.Printf("✓ Vivaldi攻击实验结果已保存到 %s，共 %d 条记录\n", filename, len(results))
+	return nil
+}
+
+// WriteBroadcastTreesCSV 写入每次广播的完整传播树（每个收到消息的节点一行，按(Run, Index)区分广播）
+// 参数:
+//   - filename: 输出文件名
+//   - algoName: 算法名称
+//   - trees: 传播树（TestResult.Trees）
+//   - metrics: 对应的树指标（AnalyzeTrees，可为nil，此时边伸展/子树/出度列为空）
+func WriteBroadcastTreesCSV(filename, algoName string, trees []*BroadcastTree, metrics []*TreeMetrics) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,Run,Index,Root,Node,Parent,Depth,RecvTime,HopLatency,EdgeStretch,SubtreeSize,Fanout\n")
+	rows := 0
+	for i, tree := range trees {
+		for u := range tree.Parent {
+			if !tree.Received(u) {
+				continue
+			}
+			fmt.Fprintf(writer, "%s,%d,%d,%d,%d,%d,%d,%.2f,%.2f,",
+				algoName, tree.Run, tree.Index, tree.Root, u, tree.Parent[u], tree.Depth[u], tree.RecvTime[u], tree.HopLatency[u])
+			if metrics != nil {
+				m := metrics[i]
+				fmt.Fprintf(writer, "%.4f,%d,%d\n", m.EdgeStretch[u], m.SubtreeSize[u], m.Fanout[u])
+			} else {
+				fmt.Fprintf(writer, ",,\n")
+			}
+			rows++
+		}
+	}
+
+	fmt.Printf("✓ 广播树已保存到 %s，共 %d 棵树 %d 条边\n", filename, len(trees), rows)
+	return nil
+}
+
+// WriteTreeMetricsCSV 写入每棵广播树的汇总指标
+// 参数:
+//   - filename: 输出文件名
+//   - algoName: 算法名称
+//   - metrics: 树指标（AnalyzeTrees）
+func WriteTreeMetricsCSV(filename, algoName string, metrics []*TreeMetrics) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,Run,Index,Root,Received,MaxDepth,AvgEdgeStretch,MedianEdgeStretch,P90EdgeStretch,"+
+		"AvgFanout,MaxSubtree,FanoutHist,LongestLatency,LongestPath\n")
+	for _, m := range metrics {
+		hist := make([]string, len(m.FanoutHist))
+		for k, c := range m.FanoutHist {
+			hist[k] = fmt.Sprintf("%d", c)
+		}
+		path := make([]string, len(m.LongestPath))
+		for i, u := range m.LongestPath {
+			path[i] = fmt.Sprintf("%d", u)
+		}
+		fmt.Fprintf(writer, "%s,%d,%d,%d,%d,%d,%.4f,%.4f,%.4f,%.2f,%d,%s,%.2f,%s\n",
+			algoName, m.Run, m.Index, m.Root, m.Received, m.MaxDepth, m.AvgEdgeStretch, m.MedianEdgeStretch, m.P90EdgeStretch,
+			m.AvgFanout, m.MaxSubtree, strings.Join(hist, "|"), m.LongestLatency, strings.Join(path, "|"))
+	}
+
+	fmt.Printf("✓ 广播树指标已保存到 %s，共 %d 棵树\n", filename, len(metrics))
+	return nil
+}
+
+// WriteMetricSummaryCSV 写入各指标在根节点之间的均值、标准差和自助法置信区间（追加写入）
+// 参数:
+//   - filename: 输出文件名
+//   - algoName: 算法名称
+//   - summaries: 指标统计（TestResult.Summaries）
+//
+// 返回: 错误信息（如果有）
+func WriteMetricSummaryCSV(filename, algoName string, summaries []*MetricSummary) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
 
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		fmt.Fprintf(writer, "Algo,Metric,N,Mean,Std,CILow,CIHigh\n")
+	}
+	for _, ms := range summaries {
+		fmt.Fprintf(writer, "%s,%s,%d,%s,%s,%s,%s\n", algoName, ms.Metric, ms.N,
+			formatMetric(ms.Mean, 4), formatMetric(ms.Std, 4), formatMetric(ms.CILow, 4), formatMetric(ms.CIHigh, 4))
+	}
+
+	fmt.Printf("✓ 指标统计已保存到 %s，共 %d 条记录\n", filename, len(summaries))
+	return nil
+}
+
+// WritePairedComparisonCSV 写入两个算法的配对比较结果（追加写入）
+// 参数:
+//   - filename: 输出文件名
+//   - comparisons: 配对比较结果（CompareAllPaired）
+//
+// 返回: 错误信息（如果有）
+func WritePairedComparisonCSV(filename string, comparisons []*PairedComparison) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		fmt.Fprintf(writer, "AlgoA,AlgoB,Metric,N,Unpaired,MeanA,MeanB,MeanDiff,StdDiff,CILow,CIHigh,Wins,Losses,Ties,PValue,Winner\n")
+	}
+	for _, pc := range comparisons {
+		winner := ""
+		if pc.ABetter {
+			winner = pc.AlgoA
+		} else if pc.BBetter {
+			winner = pc.AlgoB
+		}
+		fmt.Fprintf(writer, "%s,%s,%s,%d,%d,%s,%s,%s,%s,%s,%s,%d,%d,%d,%s,%s\n",
+			pc.AlgoA, pc.AlgoB, pc.Metric, pc.N, pc.Unpaired,
+			formatMetric(pc.MeanA, 4), formatMetric(pc.MeanB, 4), formatMetric(pc.MeanDiff, 4), formatMetric(pc.StdDiff, 4),
+			formatMetric(pc.CILow, 4), formatMetric(pc.CIHigh, 4), pc.Wins, pc.Losses, pc.Ties, formatMetric(pc.PValue, 4), winner)
+	}
+
+	fmt.Printf("✓ 配对比较结果已保存到 %s，共 %d 条记录\n", filename, len(comparisons))
+	return nil
+}
+
+// WriteNodeServiceCSV 写入每个节点在所有广播中的服务质量（带经纬度，用于绘制服务最差的区域）
+// 参数:
+//   - filename: 输出文件名
+//   - algoName: 算法名称
+//   - services: 节点服务质量（TestResult.Nodes.Services）
+//   - coords: 节点坐标
+//   - k0Sizes: 每个节点的K0桶大小（K0BucketSizes，可为nil）
+//
+// 返回: 错误信息（如果有）
+func WriteNodeServiceCSV(filename, algoName string, services []*NodeService, coords []LatLonCoordinate, k0Sizes []int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,Node,Lat,Lon,K0Size,Broadcasts,Received,MissRate,MeanLatency,P95Latency,AvgDepth,AvgDuplicates,"+
+		"AvgMsgsSent,AvgBytesSent,AvgMsgsRecv,AvgBytesRecv\n")
+	for _, s := range services {
+		k0 := ""
+		if k0Sizes != nil {
+			k0 = fmt.Sprintf("%d", k0Sizes[s.Node])
+		}
+		fmt.Fprintf(writer, "%s,%d,%.4f,%.4f,%s,%d,%d,%s,%s,%s,%s,%s,%.2f,%.0f,%.2f,%.0f\n",
+			algoName, s.Node, coords[s.Node].Lat, coords[s.Node].Lon, k0, s.Broadcasts, s.Received,
+			formatMetric(s.MissRate, 4), formatMetric(s.MeanLatency, 2), formatMetric(s.P95Latency, 2),
+			formatMetric(s.AvgDepth, 2), formatMetric(s.AvgDuplicates, 2),
+			s.AvgMsgsSent, s.AvgBytesSent, s.AvgMsgsRecv, s.AvgBytesRecv)
+	}
+
+	fmt.Printf("✓ 节点服务质量已保存到 %s，共 %d 个节点\n", filename, len(services))
+	return nil
+}
+
+// WriteLoadFairnessCSV 写入节点负载的分布（最大/平均负载、基尼系数、前1%节点占比，追加写入）
+// 参数:
+//   - filename: 输出文件名
+//   - algoName: 算法名称
+//   - fairness: 负载分布（TestResult.Nodes.LoadFairness）
+//
+// 返回: 错误信息（如果有）
+func WriteLoadFairnessCSV(filename, algoName string, fairness []*LoadFairness) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("无法打开文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		fmt.Fprintf(writer, "Algo,Metric,Max,MaxNode,Mean,MaxOverMean,Gini,Top1Share\n")
+	}
+	for _, lf := range fairness {
+		ratio := math.NaN()
+		if lf.Mean > 0 {
+			ratio = lf.Max / lf.Mean
+		}
+		fmt.Fprintf(writer, "%s,%s,%.2f,%d,%.2f,%s,%.4f,%.4f\n",
+			algoName, lf.Metric, lf.Max, lf.MaxNode, lf.Mean, formatMetric(ratio, 2), lf.Gini, lf.Top1Share)
+	}
+
+	fmt.Printf("✓ 负载分布已保存到 %s，共 %d 条记录\n", filename, len(fairness))
+	return nil
+}
+
+// WriteGroupBreakdownCSV 写入按节点分组分解的结果（每个组一行，覆盖已有文件，没有节点的组不写入）
+// 参数:
+//   - filename: 输出文件名
+//   - algoName: 算法名称
+//   - breakdowns: 分组统计（TestResult.Nodes.Breakdown）
+//
+// 返回: 错误信息（如果有）
+func WriteGroupBreakdownCSV(filename, algoName string, breakdowns []*GroupBreakdown) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("无法创建文件 %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Algo,Grouping,Group,Label,Nodes,Broadcasts,Received,Coverage,MeanLatency,P50Latency,P90Latency,P95Latency,"+
+		"MaxLatency,AvgDepth,AvgDuplicates,AvgMsgsSent,AvgBytesSent,AvgMsgsRecv,AvgBytesRecv,SentShare\n")
+	count := 0
+	for _, b := range breakdowns {
+		if b.Nodes == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "%s,%s,%d,%s,%d,%d,%d,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
+			algoName, b.Grouping, b.Group, b.Label, b.Nodes, b.Broadcasts, b.Received,
+			formatMetric(b.Coverage, 4), formatMetric(b.MeanLatency, 2), formatMetric(b.P50Latency, 2),
+			formatMetric(b.P90Latency, 2), formatMetric(b.P95Latency, 2), formatMetric(b.MaxLatency, 2),
+			formatMetric(b.AvgDepth, 2), formatMetric(b.AvgDuplicates, 2), formatMetric(b.AvgMsgsSent, 2),
+			formatMetric(b.AvgBytesSent, 0), formatMetric(b.AvgMsgsRecv, 2), formatMetric(b.AvgBytesRecv, 0),
+			formatMetric(b.SentShare, 4))
+		count++
+	}
+
+	fmt.Printf("✓ 分组统计已保存到 %s，共 %d 个组\n", filename, count)
 	return nil
 }
 
@@ -334,8 +1189,10 @@ func WriteSuccessChildrenCSV(path string, root int, success [][]int) error {
 	}
 	defer f.Close()
 
-	// 标题：Root,Src,NumChildren,Children(comma)
-	fmt.Fprintln(f, "Root,Src,NumChildren,Children")
+	// 标题：Root,Src,NumChildren,Children(comma)，只在新文件开头写一次
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		fmt.Fprintln(f, "Root,Src,NumChildren,Children")
+	}
 	for src := range success {
 		kids := success[src]
 		if len(kids) == 0 {
@@ -0,0 +1,299 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ==================== 延迟模型接口 ====================
+// 模拟器和各算法的预热（warmup）循环都通过 LatencyModel 计算链路传播延迟，
+// 保证同一次实验中所有延迟来自同一个模型
+
+// LatencyModel 链路传播延迟模型
+type LatencyModel interface {
+	// Delay 计算从节点u到节点v的传播延迟（ms）
+	// 注意：不包含数据传输延迟和节点处理延迟
+	Delay(u, v int) float64
+
+	// GetModelName 获取模型名称，用于日志和结果输出
+	GetModelName() string
+}
+
+// ==================== 地理距离模型（默认） ====================
+
+// GeoLatencyModel 基于地理距离的延迟模型
+// 延迟 = Distance(u, v) * Factor + Base
+type GeoLatencyModel struct {
+	Coords []LatLonCoordinate // 节点坐标
+	Factor float64            // 距离延迟系数（默认3，与C++ single_root_simulation对齐）
+	Base   float64            // 固定附加延迟（ms）
+}
+
+// NewGeoLatencyModel 创建默认的地理距离延迟模型
+func NewGeoLatencyModel(coords []LatLonCoordinate) *GeoLatencyModel {
+	return &GeoLatencyModel{
+		Coords: coords,
+		Factor: 3.0,
+		Base:   0.0,
+	}
+}
+
+// Delay 实现LatencyModel接口
+func (gm *GeoLatencyModel) Delay(u, v int) float64 {
+	return Distance(gm.Coords[u], gm.Coords[v])*gm.Factor + gm.Base
+}
+
+// GetModelName 实现LatencyModel接口
+func (gm *GeoLatencyModel) GetModelName() string {
+	return "geo"
+}
+
+// NewGeoRTTModel 创建Vivaldi坐标训练使用的地理RTT模型
+// RTT = Distance(u, v) + FixedDelay（与各GenerateVirtualCoordinate*的原始定义一致）
+func NewGeoRTTModel(coords []LatLonCoordinate) *GeoLatencyModel {
+	return &GeoLatencyModel{
+		Coords: coords,
+		Factor: 1.0,
+		Base:   FixedDelay,
+	}
+}
+
+// ==================== 实测延迟矩阵模型 ====================
+
+// RTTMatrix 实测RTT矩阵（King / WonderNetwork 等数据集）
+// 行列对应测量点（城市或主机），与模拟节点之间通过映射表关联
+type RTTMatrix struct {
+	Labels []string       // 测量点标签
+	Index  map[string]int // 标签 -> 行号
+	RTT    [][]float64    // RTT[i][j] 往返时延（ms），<0表示缺失
+}
+
+// NewRTTMatrix 创建size×size的空RTT矩阵（所有值标记为缺失）
+func NewRTTMatrix(labels []string) *RTTMatrix {
+	size := len(labels)
+	m := &RTTMatrix{
+		Labels: labels,
+		Index:  make(map[string]int, size),
+		RTT:    make([][]float64, size),
+	}
+	for i := 0; i < size; i++ {
+		m.Index[labels[i]] = i
+		m.RTT[i] = make([]float64, size)
+		for j := 0; j < size; j++ {
+			m.RTT[i][j] = -1
+		}
+	}
+	return m
+}
+
+// Size 获取测量点数量
+func (m *RTTMatrix) Size() int {
+	return len(m.Labels)
+}
+
+// Coverage 计算非对角线上已测量节点对的比例
+func (m *RTTMatrix) Coverage() float64 {
+	size := m.Size()
+	if size < 2 {
+		return 0
+	}
+	measured := 0
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			if i != j && m.RTT[i][j] >= 0 {
+				measured++
+			}
+		}
+	}
+	return float64(measured) / float64(size*(size-1))
+}
+
+// MatrixLatencyModel 基于实测延迟矩阵的模型
+// 节点先通过NodeMap映射到矩阵行号，再查表得到延迟；
+// 缺失的节点对（未映射、同一测量点、或矩阵值<0）交给Fallback模型估计
+type MatrixLatencyModel struct {
+	Delays   [][]float64  // 矩阵延迟（ms），<0表示缺失
+	NodeMap  []int        // 节点ID -> 矩阵行号（nil表示恒等映射，-1表示无测量）
+	Scale    float64      // 矩阵值换算系数（RTT换算为单向延迟时为0.5）
+	Fallback LatencyModel // 缺失时使用的模型（可为nil）
+}
+
+// NewMatrixLatencyModel 创建延迟矩阵模型（节点与矩阵行一一对应）
+// 参数:
+//   - delays: 节点间单向延迟矩阵（ms），<0表示缺失
+//   - fallback: 缺失节点对的估计模型（nil表示缺失时延迟为0）
+func NewMatrixLatencyModel(delays [][]float64, fallback LatencyModel) *MatrixLatencyModel {
+	return &MatrixLatencyModel{
+		Delays:   delays,
+		NodeMap:  nil,
+		Scale:    1.0,
+		Fallback: fallback,
+	}
+}
+
+// NewOneWayLatencyModel 基于实测RTT矩阵创建单向延迟模型（用于广播模拟）
+// 单向延迟 = RTT / 2，缺失部分通常使用 NewGeoLatencyModel 补齐
+func NewOneWayLatencyModel(m *RTTMatrix, nodeMap []int, fallback LatencyModel) *MatrixLatencyModel {
+	return &MatrixLatencyModel{
+		Delays:   m.RTT,
+		NodeMap:  nodeMap,
+		Scale:    0.5,
+		Fallback: fallback,
+	}
+}
+
+// NewRTTLatencyModel 基于实测RTT矩阵创建往返时延模型（用于Vivaldi坐标训练）
+// 缺失部分通常使用 NewGeoRTTModel 补齐
+func NewRTTLatencyModel(m *RTTMatrix, nodeMap []int, fallback LatencyModel) *MatrixLatencyModel {
+	return &MatrixLatencyModel{
+		Delays:   m.RTT,
+		NodeMap:  nodeMap,
+		Scale:    1.0,
+		Fallback: fallback,
+	}
+}
+
+// row 获取节点对应的矩阵行号，-1表示无测量
+func (mm *MatrixLatencyModel) row(u int) int {
+	if mm.NodeMap == nil {
+		if u < len(mm.Delays) {
+			return u
+		}
+		return -1
+	}
+	if u < len(mm.NodeMap) {
+		return mm.NodeMap[u]
+	}
+	return -1
+}
+
+// Delay 实现LatencyModel接口
+func (mm *MatrixLatencyModel) Delay(u, v int) float64 {
+	if u == v {
+		return 0
+	}
+	i, j := mm.row(u), mm.row(v)
+	if i >= 0 && j >= 0 && i != j && mm.Delays[i][j] >= 0 {
+		return mm.Delays[i][j] * mm.Scale
+	}
+	if mm.Fallback != nil {
+		return mm.Fallback.Delay(u, v)
+	}
+	return 0
+}
+
+// Measured 判断节点对(u, v)是否有实测数据
+func (mm *MatrixLatencyModel) Measured(u, v int) bool {
+	i, j := mm.row(u), mm.row(v)
+	return i >= 0 && j >= 0 && i != j && mm.Delays[i][j] >= 0
+}
+
+// GetModelName 实现LatencyModel接口
+func (mm *MatrixLatencyModel) GetModelName() string {
+	if mm.Fallback != nil {
+		return fmt.Sprintf("matrix+%s", mm.Fallback.GetModelName())
+	}
+	return "matrix"
+}
+
+// MapNodesToNearest 将每个节点映射到地理位置最近的测量点
+// 参数:
+//   - coords: 节点坐标
+//   - pointCoords: 测量点坐标（与矩阵行一一对应）
+//   - maxDist: 最大映射距离（Distance单位，<=0表示不限制），超出则映射为-1
+//
+// 返回: 节点ID -> 矩阵行号
+func MapNodesToNearest(coords, pointCoords []LatLonCoordinate, maxDist float64) []int {
+	nodeMap := make([]int, len(coords))
+	for i := range coords {
+		best := -1
+		bestDist := math.MaxFloat64
+		for j := range pointCoords {
+			d := Distance(coords[i], pointCoords[j])
+			if d < bestDist {
+				bestDist = d
+				best = j
+			}
+		}
+		if maxDist > 0 && bestDist > maxDist {
+			best = -1
+		}
+		nodeMap[i] = best
+	}
+	return nodeMap
+}
+
+// ==================== Vivaldi预测模型 ====================
+
+// VivaldiLatencyModel 基于Vivaldi虚拟坐标预测的延迟模型
+// Vivaldi坐标按 RTT = Distance + FixedDelay 训练，
+// 预测值先扣除Offset再乘以Factor，换算到与GeoLatencyModel相同的量纲
+type VivaldiLatencyModel struct {
+	Models []*VivaldiModel // 每个节点的Vivaldi模型
+	Offset float64         // 训练RTT中包含的固定延迟（默认FixedDelay）
+	Factor float64         // 换算系数（默认3）
+}
+
+// NewVivaldiLatencyModel 创建Vivaldi预测延迟模型
+func NewVivaldiLatencyModel(models []*VivaldiModel) *VivaldiLatencyModel {
+	return &VivaldiLatencyModel{
+		Models: models,
+		Offset: FixedDelay,
+		Factor: 3.0,
+	}
+}
+
+// Delay 实现LatencyModel接口
+func (vm *VivaldiLatencyModel) Delay(u, v int) float64 {
+	if u == v {
+		return 0
+	}
+	predicted := DistanceVivaldi(vm.Models[u].LocalCoord, vm.Models[v].LocalCoord)
+	return math.Max(0, predicted-vm.Offset) * vm.Factor
+}
+
+// GetModelName 实现LatencyModel接口
+func (vm *VivaldiLatencyModel) GetModelName() string {
+	return "vivaldi"
+}
+
+// ==================== 接入链路模型 ====================
+
+// AccessLinkLatencyModel 核心网延迟 + 每个节点的接入链路延迟
+// 延迟 = Core.Delay(u, v) + Access[u] + Access[v]
+type AccessLinkLatencyModel struct {
+	Core   LatencyModel // 核心网延迟模型（通常为GeoLatencyModel）
+	Access []float64    // 每个节点的接入链路延迟（ms）
+}
+
+// NewAccessLinkLatencyModel 创建接入链路延迟模型
+func NewAccessLinkLatencyModel(core LatencyModel, access []float64) *AccessLinkLatencyModel {
+	return &AccessLinkLatencyModel{
+		Core:   core,
+		Access: access,
+	}
+}
+
+// Delay 实现LatencyModel接口
+func (am *AccessLinkLatencyModel) Delay(u, v int) float64 {
+	if u == v {
+		return 0
+	}
+	return am.Core.Delay(u, v) + am.Access[u] + am.Access[v]
+}
+
+// GetModelName 实现LatencyModel接口
+func (am *AccessLinkLatencyModel) GetModelName() string {
+	return fmt.Sprintf("%s+access", am.Core.GetModelName())
+}
+
+// GenerateAccessDelays 为每个节点生成接入链路延迟（ms）
+// 延迟服从 Gaussian(mean, std)，截断到非负
+func GenerateAccessDelays(n int, mean, std float64, rng *rand.Rand) []float64 {
+	access := make([]float64, n)
+	for i := 0; i < n; i++ {
+		access[i] = math.Max(0, rng.NormFloat64()*std+mean)
+	}
+	return access
+}
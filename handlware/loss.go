@@ -0,0 +1,300 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// ==================== 链路丢包模型 ====================
+// 默认情况下所有发出的消息都会送达。LossModel 在链路层面决定每条消息是否丢失，
+// 与 LatencyModel 一样作为网络属性配置在 SimulatorConfig 中。
+// 突发丢包等模型需要保存链路状态，因此与 Algorithm/Broadcast 相同，
+// 模型只保存参数，每次广播（或一次连续负载模拟）通过 NewSession 创建独立的判定器。
+
+// LossModel 链路丢包模型
+type LossModel interface {
+	// NewSession 创建丢包判定器
+	// rng: 判定使用的随机流（由SimContext派生）
+	NewSession(rng *rand.Rand) LossSession
+
+	// GetModelName 获取模型名称，用于日志和结果输出
+	GetModelName() string
+}
+
+// LossSession 丢包判定器，保存一次模拟中的链路状态
+type LossSession interface {
+	// Drop 判断从u发往v的一条消息是否丢失
+	Drop(u, v int) bool
+}
+
+// probLossSession 按链路丢包概率独立判定
+type probLossSession struct {
+	prob func(u, v int) float64
+	rng  *rand.Rand
+}
+
+// Drop 实现LossSession接口
+func (ps *probLossSession) Drop(u, v int) bool {
+	p := ps.prob(u, v)
+	return p > 0 && ps.rng.Float64() < p
+}
+
+// ==================== 均匀丢包 ====================
+
+// UniformLoss 每条消息以相同概率独立丢失
+type UniformLoss struct {
+	Rate float64 // 丢包率 [0, 1]
+}
+
+// NewUniformLoss 创建均匀丢包模型
+func NewUniformLoss(rate float64) *UniformLoss {
+	return &UniformLoss{Rate: rate}
+}
+
+// NewSession 实现LossModel接口
+func (ul *UniformLoss) NewSession(rng *rand.Rand) LossSession {
+	return &probLossSession{
+		prob: func(u, v int) float64 { return ul.Rate },
+		rng:  rng,
+	}
+}
+
+// GetModelName 实现LossModel接口
+func (ul *UniformLoss) GetModelName() string {
+	return fmt.Sprintf("uniform%.3f", ul.Rate)
+}
+
+// ==================== 距离相关丢包 ====================
+
+// DistanceLoss 丢包率随地理距离增长
+// 丢包率 = min(Max, Base + PerDistance * Distance(u, v))
+type DistanceLoss struct {
+	Coords      []LatLonCoordinate // 节点坐标
+	Base        float64            // 基础丢包率
+	PerDistance float64            // 每单位Distance增加的丢包率（Distance单位约为ms）
+	Max         float64            // 丢包率上限
+}
+
+// NewDistanceLoss 创建距离相关丢包模型
+// 参数:
+//   - coords: 节点坐标
+//   - base: 基础丢包率
+//   - perDistance: 每单位Distance增加的丢包率
+//   - max: 丢包率上限
+func NewDistanceLoss(coords []LatLonCoordinate, base, perDistance, max float64) *DistanceLoss {
+	return &DistanceLoss{
+		Coords:      coords,
+		Base:        base,
+		PerDistance: perDistance,
+		Max:         max,
+	}
+}
+
+// Rate 计算链路(u, v)的丢包率
+func (dl *DistanceLoss) Rate(u, v int) float64 {
+	return math.Min(dl.Max, dl.Base+dl.PerDistance*Distance(dl.Coords[u], dl.Coords[v]))
+}
+
+// NewSession 实现LossModel接口
+func (dl *DistanceLoss) NewSession(rng *rand.Rand) LossSession {
+	return &probLossSession{
+		prob: dl.Rate,
+		rng:  rng,
+	}
+}
+
+// GetModelName 实现LossModel接口
+func (dl *DistanceLoss) GetModelName() string {
+	return "distance"
+}
+
+// ==================== Gilbert-Elliott 突发丢包 ====================
+
+// GilbertElliottLoss 两状态马尔可夫突发丢包模型
+// 每条有向链路独立维护好/坏状态，每发送一条消息先按转移概率更新状态，
+// 再按当前状态的丢包率判定是否丢失
+type GilbertElliottLoss struct {
+	PGoodToBad float64 // 好状态转移到坏状态的概率
+	PBadToGood float64 // 坏状态转移到好状态的概率
+	LossGood   float64 // 好状态丢包率
+	LossBad    float64 // 坏状态丢包率
+}
+
+// NewGilbertElliottLoss 创建Gilbert-Elliott突发丢包模型
+// 参数:
+//   - pGoodToBad: 好→坏转移概率
+//   - pBadToGood: 坏→好转移概率（平均突发长度为 1/pBadToGood 条消息）
+//   - lossGood: 好状态丢包率
+//   - lossBad: 坏状态丢包率
+func NewGilbertElliottLoss(pGoodToBad, pBadToGood, lossGood, lossBad float64) *GilbertElliottLoss {
+	return &GilbertElliottLoss{
+		PGoodToBad: pGoodToBad,
+		PBadToGood: pBadToGood,
+		LossGood:   lossGood,
+		LossBad:    lossBad,
+	}
+}
+
+// AverageRate 计算稳态平均丢包率
+func (ge *GilbertElliottLoss) AverageRate() float64 {
+	if ge.PGoodToBad+ge.PBadToGood == 0 {
+		return ge.LossGood
+	}
+	piBad := ge.PGoodToBad / (ge.PGoodToBad + ge.PBadToGood)
+	return (1-piBad)*ge.LossGood + piBad*ge.LossBad
+}
+
+// gilbertElliottSession 保存每条有向链路的状态
+type gilbertElliottSession struct {
+	*GilbertElliottLoss
+	bad map[[2]int]bool // 链路(u, v) -> 是否处于坏状态（未出现的链路处于好状态）
+	rng *rand.Rand
+}
+
+// NewSession 实现LossModel接口
+func (ge *GilbertElliottLoss) NewSession(rng *rand.Rand) LossSession {
+	return &gilbertElliottSession{
+		GilbertElliottLoss: ge,
+		bad:                make(map[[2]int]bool),
+		rng:                rng,
+	}
+}
+
+// Drop 实现LossSession接口
+func (gs *gilbertElliottSession) Drop(u, v int) bool {
+	link := [2]int{u, v}
+	bad := gs.bad[link]
+	if bad {
+		bad = gs.rng.Float64() >= gs.PBadToGood
+	} else {
+		bad = gs.rng.Float64() < gs.PGoodToBad
+	}
+	gs.bad[link] = bad
+
+	if bad {
+		return gs.rng.Float64() < gs.LossBad
+	}
+	return gs.rng.Float64() < gs.LossGood
+}
+
+// GetModelName 实现LossModel接口
+func (ge *GilbertElliottLoss) GetModelName() string {
+	return fmt.Sprintf("gilbert_elliott%.3f", ge.AverageRate())
+}
+
+// ==================== 永久失效链路 ====================
+
+// FailedLinkLoss 永久失效的链路集合，经过失效链路的消息全部丢失
+// 失效链路可以显式指定，也可以按比例随机产生（由种子和节点对哈希决定，不依赖拓扑）
+type FailedLinkLoss struct {
+	Links map[[2]int]bool // 显式指定的失效链路（无向，键为(min, max)）
+	Ratio float64         // 随机失效的节点对比例
+	Seed  int64           // 随机失效的种子
+}
+
+// NewFailedLinkLoss 创建失效链路模型
+// 参数:
+//   - links: 显式指定的失效链路（无向，可为nil）
+//   - ratio: 随机失效的节点对比例
+//   - seed: 随机失效的种子
+func NewFailedLinkLoss(links [][2]int, ratio float64, seed int64) *FailedLinkLoss {
+	fl := &FailedLinkLoss{
+		Links: make(map[[2]int]bool),
+		Ratio: ratio,
+		Seed:  seed,
+	}
+	for _, l := range links {
+		fl.Links[undirectedLink(l[0], l[1])] = true
+	}
+	return fl
+}
+
+// undirectedLink 无向链路的键
+func undirectedLink(u, v int) [2]int {
+	if u > v {
+		u, v = v, u
+	}
+	return [2]int{u, v}
+}
+
+// Failed 判断链路(u, v)是否失效
+func (fl *FailedLinkLoss) Failed(u, v int) bool {
+	link := undirectedLink(u, v)
+	if fl.Links[link] {
+		return true
+	}
+	if fl.Ratio <= 0 {
+		return false
+	}
+
+	// 种子与节点对哈希，同一种子下每个节点对的失效状态固定
+	h := splitMix64(uint64(fl.Seed) ^ (uint64(link[0])<<32 | uint64(uint32(link[1]))))
+	return float64(h>>11)/float64(1<<53) < fl.Ratio
+}
+
+// NewSession 实现LossModel接口
+func (fl *FailedLinkLoss) NewSession(rng *rand.Rand) LossSession {
+	return &probLossSession{
+		prob: func(u, v int) float64 {
+			if fl.Failed(u, v) {
+				return 1
+			}
+			return 0
+		},
+		rng: rng,
+	}
+}
+
+// GetModelName 实现LossModel接口
+func (fl *FailedLinkLoss) GetModelName() string {
+	return fmt.Sprintf("failed_links%.3f+%d", fl.Ratio, len(fl.Links))
+}
+
+// ==================== 组合模型 ====================
+
+// CombinedLoss 组合多个丢包模型，任一模型判定丢失即丢失
+// 例如失效链路 + 均匀丢包
+type CombinedLoss struct {
+	Models []LossModel
+}
+
+// NewCombinedLoss 创建组合丢包模型
+func NewCombinedLoss(models ...LossModel) *CombinedLoss {
+	return &CombinedLoss{Models: models}
+}
+
+// combinedLossSession 组合判定器
+type combinedLossSession struct {
+	sessions []LossSession
+}
+
+// NewSession 实现LossModel接口
+func (cl *CombinedLoss) NewSession(rng *rand.Rand) LossSession {
+	cs := &combinedLossSession{sessions: make([]LossSession, len(cl.Models))}
+	for i, m := range cl.Models {
+		cs.sessions[i] = m.NewSession(rng)
+	}
+	return cs
+}
+
+// Drop 实现LossSession接口（所有模型都会更新状态，不提前返回）
+func (cs *combinedLossSession) Drop(u, v int) bool {
+	drop := false
+	for _, s := range cs.sessions {
+		if s.Drop(u, v) {
+			drop = true
+		}
+	}
+	return drop
+}
+
+// GetModelName 实现LossModel接口
+func (cl *CombinedLoss) GetModelName() string {
+	names := make([]string, len(cl.Models))
+	for i, m := range cl.Models {
+		names[i] = m.GetModelName()
+	}
+	return strings.Join(names, "+")
+}
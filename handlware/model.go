@@ -28,6 +28,8 @@ const (
 	BandwidthDefault = 33000000.0 // 默认带宽（bps）
 	DataSizeSmall    = 300.0      // 小数据包（Bytes）
 	DataSizeLarge    = 1048576.0  // 大数据包（1MB）
+	DataSizeAnnounce = 64.0       // 公告消息大小（32字节哈希 + 消息头，Bytes）
+	DataSizeRequest  = 64.0       // 请求消息大小（Bytes）
 )
 
 // ==================== 坐标结构 ====================
@@ -56,14 +58,46 @@ func NewVivaldiCoordinate(dim int) *VivaldiCoordinate {
 
 // ==================== 消息结构 ====================
 
+// MessageType 消息类型（INV/GETDATA两阶段传播）
+type MessageType int
+
+const (
+	MsgPayload   MessageType = iota // 完整消息（默认）
+	MsgAnnounce                     // 公告：只包含消息哈希
+	MsgRequest                      // 请求：收到公告后向公告方索取完整消息
+	MsgTimer                        // 定时器事件：到期时回调会话的OnTimer
+	MsgScheduled                    // 延迟发送事件：到期时由Src向Dst发送Tag类型的消息
+)
+
+// String 获取消息类型名称
+func (t MessageType) String() string {
+	switch t {
+	case MsgAnnounce:
+		return "announce"
+	case MsgRequest:
+		return "request"
+	case MsgTimer:
+		return "timer"
+	case MsgScheduled:
+		return "scheduled"
+	default:
+		return "payload"
+	}
+}
+
 // Message 广播消息
 type Message struct {
-	Root     int     // 广播根节点ID
-	Src      int     // 消息源节点ID
-	Dst      int     // 目标节点ID
-	Step     int     // 当前传播步数
-	SendTime float64 // 发送时间（ms）
-	RecvTime float64 // 接收时间（ms）
+	Root     int         // 广播根节点ID
+	Src      int         // 消息源节点ID
+	Dst      int         // 目标节点ID
+	Step     int         // 当前传播步数
+	SendTime float64     // 发送时间（ms）
+	RecvTime float64     // 接收时间（ms）
+	ID       int         // 所属广播ID（连续负载中区分同时进行的多条广播，单次广播为0）
+	Type     MessageType // 消息类型
+	Size     float64     // 消息大小（Bytes）
+	Tag      int         // 定时器标签（延迟发送事件中为待发送的消息类型）
+	seq      uint64      // 入队序号（同一时刻的事件按入队顺序处理）
 }
 
 // NewMessage 创建新消息
@@ -169,16 +203,49 @@ func (g *Graph) InDegree(u int) int {
 
 // ==================== 测试结果结构 ====================
 
+const (
+	NumPercentiles = 20  // 延迟百分位个数 [5%, 10%, ..., 100%]
+	CoverageLevels = 100 // 覆盖时间曲线的点数 [1%, 2%, ..., 100%]
+)
+
+// PercentileLevel 获取第i个延迟百分位对应的比例（0.05, 0.10, ..., 1.00）
+func PercentileLevel(i int) float64 {
+	return float64(i+1) / NumPercentiles
+}
+
+// CoverageLevel 获取覆盖时间曲线第i个点对应的覆盖比例（0.01, 0.02, ..., 1.00）
+func CoverageLevel(i int) float64 {
+	return float64(i+1) / CoverageLevels
+}
+
 // TestResult 模拟测试结果
 type TestResult struct {
-	AvgBandwidth      float64   // 平均带宽消耗（重复消息率）
-	AvgLatency        float64   // 平均延迟（ms）
-	Latency           []float64 // 延迟百分位数组 [5%, 10%, ..., 100%]
-	DepthCDF          []float64 // 深度累积分布函数
-	AvgDist           []float64 // 每层平均距离延迟
-	ClusterAvgLatency []float64 // 每个簇的平均延迟
-	ClusterAvgDepth   []float64 // 每个簇的平均深度
-	SuccessChildren   [][]int   // 新增[u] => 成功（首次）把消息转发/传递到的子节点列表
+	AvgBandwidth         float64          // 平均带宽消耗（重复消息率）
+	AvgDropped           float64          // 平均丢失消息率（链路丢失的完整消息数 / 正常节点数，含网络分区切断的链路，不计入AvgBandwidth）
+	AvgOfflineDropped    float64          // 平均离线丢失率（接收方因churn离线而丢失的完整消息数 / 正常节点数）
+	Coverage             float64          // 覆盖率（收到消息的正常节点 / 正常节点）
+	AvgLatency           float64          // 平均延迟（ms）
+	Latency              []float64        // 全体节点（收到消息的节点 + 未覆盖的正常节点）的延迟百分位 [5%, 10%, ..., 100%]，所有广播都未达到的百分位为NaN
+	LatencyReached       []float64        // 每个百分位在广播中达到（对应节点收到消息）的比例
+	DeliveredLatency     []float64        // 只统计收到消息节点的延迟百分位 [5%, 10%, ..., 100%]
+	CoverageTime         []float64        // 覆盖X%正常节点的时刻 [1%, 2%, ..., 100%]（ms，所有广播都未达到时为NaN）
+	CoverageReached      []float64        // 达到X%覆盖的广播比例
+	OracleLatency        []float64        // 全连接延迟下界的百分位（与Latency对齐，只在对应百分位达到的广播中平均，未启用Oracle时为nil）
+	BoundedOracleLatency []float64        // 度受限延迟下界的百分位
+	LatencyRatio         []float64        // 各百分位延迟 / 全连接下界
+	BoundedLatencyRatio  []float64        // 各百分位延迟 / 度受限下界
+	DepthCDF             []float64        // 深度累积分布函数
+	AvgDist              []float64        // 每层平均距离延迟
+	ClusterAvgLatency    []float64        // 每个簇的平均延迟
+	ClusterAvgDepth      []float64        // 每个簇的平均深度
+	ClusterEligible      []float64        // 每个簇平均的正常（存活）节点数（按clusterResult.K分配，未传入聚类时为nil）
+	ClusterCovered       []float64        // 每个簇平均收到消息的正常节点数
+	SuccessChildren      [][]int          // 新增[u] => 成功（首次）把消息转发/传递到的子节点列表
+	Trees                []*BroadcastTree // 每次广播的完整传播树（按重复实验、根节点顺序）
+	Samples              []*RootSample    // 每个根节点的指标样本（按重复实验、根节点顺序，用于标准差和置信区间）
+	Nodes                *NodeStats       // 每个节点在所有广播中的接收情况（由SingleRootSimulation记录）
+	AvgBytes             float64          // 每次广播全网发送的字节数（完整消息 + 公告 + 请求）
+	AvgBytesSaved        float64          // 相比向所有目标推送完整消息节省的字节数
 
 }
 
@@ -187,7 +254,11 @@ func NewTestResult(n int) *TestResult {
 	return &TestResult{
 		AvgBandwidth:      0,
 		AvgLatency:        0,
-		Latency:           make([]float64, 21), // 0.05, 0.10, ..., 1.00
+		Latency:           make([]float64, NumPercentiles),
+		LatencyReached:    make([]float64, NumPercentiles),
+		DeliveredLatency:  make([]float64, NumPercentiles),
+		CoverageTime:      make([]float64, CoverageLevels),
+		CoverageReached:   make([]float64, CoverageLevels),
 		DepthCDF:          make([]float64, MaxDepth),
 		AvgDist:           make([]float64, MaxDepth),
 		ClusterAvgLatency: make([]float64, K),
@@ -196,6 +267,14 @@ func NewTestResult(n int) *TestResult {
 	}
 }
 
+// RegionCoverage 获取簇（区域）c的覆盖率，区域内没有存活节点时返回0
+func (r *TestResult) RegionCoverage(c int) float64 {
+	if c >= len(r.ClusterEligible) || r.ClusterEligible[c] == 0 {
+		return 0
+	}
+	return r.ClusterCovered[c] / r.ClusterEligible[c]
+}
+
 // ==================== 聚类结果结构 ====================
 
 // ClusterResult K-means聚类结果
@@ -223,6 +302,13 @@ type AttackConfig struct {
 	MaliciousRatio float64 // 恶意节点比例（拒绝转发）
 	NodeLeaveRatio float64 // 节点离开比例（接收但不转发）
 	FakeCoordRatio float64 // 谎报坐标节点比例（Mercator专用）
+	FailedNodes    []bool  // 区域故障节点（固定集合，不转发、不计入统计，nil表示无）
+
+	ByzantineRatio         float64 // 拜占庭节点比例（接收并转发，但延迟/选择性/合谋转发）
+	ByzantineDelay         float64 // 拜占庭节点每次转发的固定延迟（ms）
+	ByzantineDelayJitter   float64 // 拜占庭节点每次转发的随机延迟上限（ms，均匀分布）
+	ByzantineWithholdRatio float64 // 拜占庭节点对每个目标扣留不转发的概率（0表示全部转发）
+	ByzantineColluding     bool    // 拜占庭节点只向其他拜占庭（合谋）节点转发
 }
 
 // NewAttackConfig 创建默认攻击配置
@@ -231,6 +317,13 @@ func NewAttackConfig() *AttackConfig {
 		MaliciousRatio: 0.0,
 		NodeLeaveRatio: 0.0,
 		FakeCoordRatio: 0.0,
+		FailedNodes:    nil,
+
+		ByzantineRatio:         0.0,
+		ByzantineDelay:         0.0,
+		ByzantineDelayJitter:   0.0,
+		ByzantineWithholdRatio: 0.0,
+		ByzantineColluding:     false,
 	}
 }
 
@@ -1,9 +1,9 @@
 package handlware
 
 import (
-	"crypto/rand"
 	"math/big"
 	"math/bits"
+	"math/rand"
 )
 
 // ==================== NodeID128 和 XOR 距离计算 ====================
@@ -11,16 +11,12 @@ import (
 // NodeID128 代表 128-bit 节点 ID
 type NodeID128 [16]byte
 
-// GenerateRandomNodeID 生成随机 128-bit NodeID
-// 使用加密安全的随机数生成器
-func GenerateRandomNodeID() NodeID128 {
+// GenerateRandomNodeIDWithRng 使用给定的伪随机流生成 128-bit NodeID
+// 用于可重复的模拟（同一种子生成相同的节点ID）
+func GenerateRandomNodeIDWithRng(rng *rand.Rand) NodeID128 {
 	var id NodeID128
-	_, err := rand.Read(id[:])
-	if err != nil {
-		// 如果加密随机失败，回退到伪随机
-		for i := 0; i < 16; i++ {
-			id[i] = byte(RandomBetween01() * 256)
-		}
+	for i := 0; i < 16; i++ {
+		id[i] = byte(rng.Intn(256))
 	}
 	return id
 }
@@ -61,6 +57,20 @@ func BucketIndex(dist NodeID128) int {
 	return -1
 }
 
+// NodeIDInBucket 生成一个落在 base 第 bucket 号桶中的随机 NodeID
+// 即与 base 的 XOR 距离最高位恰为第 bucket 位（更高位相同、该位不同、更低位随机）
+// 用于攻击者构造 NodeID 填充目标节点的指定 k-bucket
+func NodeIDInBucket(base NodeID128, bucket int, rng *rand.Rand) NodeID128 {
+	id := GenerateRandomNodeIDWithRng(rng)
+	for bit := 127; bit > bucket; bit-- {
+		byteIdx, mask := 15-bit/8, byte(1)<<uint(bit%8)
+		id[byteIdx] = id[byteIdx]&^mask | base[byteIdx]&mask
+	}
+	byteIdx, mask := 15-bucket/8, byte(1)<<uint(bucket%8)
+	id[byteIdx] = id[byteIdx]&^mask | (base[byteIdx]&mask ^ mask)
+	return id
+}
+
 // CompareNodeID 比较两个 NodeID128 的大小（无符号）
 // 返回值：
 //   -1: a < b
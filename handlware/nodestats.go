@@ -0,0 +1,225 @@
+package handlware
+
+import (
+	"math"
+	"sort"
+)
+
+// ==================== 节点服务质量 ====================
+// 汇总指标按广播平均，掩盖了某些节点（如稀疏Geohash格子中的节点）总是很晚才收到消息。
+// NodeStats 按节点累积每次广播的接收时刻、跳数、重复消息数和未收到次数，
+// 由SingleRootSimulation逐次广播记录，Simulation在所有根节点之间合并（TestResult.Nodes），
+// Services 计算每个节点的均值/95分位延迟等服务质量指标，WriteNodeServiceCSV 带经纬度导出。
+// 同时记录每个节点收发的消息数和字节数（含重复消息、公告和请求），LoadFairness 汇总负载的集中程度。
+
+// NodeStats 每个节点在所有广播中的接收情况
+type NodeStats struct {
+	Broadcasts []int       // 计入统计的广播次数（正常节点且广播结束时在线）
+	Received   []int       // 收到消息的次数
+	Missed     []int       // 未收到消息的次数
+	RecvTimes  [][]float64 // 每次收到消息的接收时刻（ms）
+	DepthSum   []int       // 收到消息时的跳数之和
+	Duplicates []int       // 计入统计的广播中收到的重复完整消息数
+
+	Recorded  int       // 记录的广播次数（负载统计包含所有节点）
+	MsgsSent  []int     // 发送的消息数（含链路丢失的消息）
+	BytesSent []float64 // 上行字节数
+	MsgsRecv  []int     // 到达的消息数（含重复消息）
+	BytesRecv []float64 // 下行字节数
+}
+
+// NewNodeStats 创建节点统计
+func NewNodeStats(n int) *NodeStats {
+	return &NodeStats{
+		Broadcasts: make([]int, n),
+		Received:   make([]int, n),
+		Missed:     make([]int, n),
+		RecvTimes:  make([][]float64, n),
+		DepthSum:   make([]int, n),
+		Duplicates: make([]int, n),
+		MsgsSent:   make([]int, n),
+		BytesSent:  make([]float64, n),
+		MsgsRecv:   make([]int, n),
+		BytesRecv:  make([]float64, n),
+	}
+}
+
+// recordSend 记录节点u发出一条size字节的消息
+func (ns *NodeStats) recordSend(u int, size float64) {
+	ns.MsgsSent[u]++
+	ns.BytesSent[u] += size
+}
+
+// recordRecv 记录一条size字节的消息到达节点v
+func (ns *NodeStats) recordRecv(v int, size float64) {
+	ns.MsgsRecv[v]++
+	ns.BytesRecv[v] += size
+}
+
+// addBroadcast 记录一次广播（恶意节点和离开节点不计入，与覆盖率统计一致）
+// 参数:
+//   - recvFlag/recvTime/depth: 每个节点的接收标记、接收时间和跳数
+//   - dupCount: 每个节点收到的重复完整消息数
+//   - malFlags/leaveFlags: 不计入统计的节点
+func (ns *NodeStats) addBroadcast(recvFlag []bool, recvTime []float64, depth, dupCount []int, malFlags, leaveFlags []bool) {
+	for i := range recvFlag {
+		if malFlags[i] || leaveFlags[i] {
+			continue
+		}
+		ns.Broadcasts[i]++
+		ns.Duplicates[i] += dupCount[i]
+		if !recvFlag[i] {
+			ns.Missed[i]++
+			continue
+		}
+		ns.Received[i]++
+		ns.RecvTimes[i] = append(ns.RecvTimes[i], recvTime[i])
+		ns.DepthSum[i] += depth[i]
+	}
+}
+
+// Merge 合并另一组广播的节点统计
+func (ns *NodeStats) Merge(src *NodeStats) {
+	for i := range src.Broadcasts {
+		ns.Broadcasts[i] += src.Broadcasts[i]
+		ns.Received[i] += src.Received[i]
+		ns.Missed[i] += src.Missed[i]
+		ns.RecvTimes[i] = append(ns.RecvTimes[i], src.RecvTimes[i]...)
+		ns.DepthSum[i] += src.DepthSum[i]
+		ns.Duplicates[i] += src.Duplicates[i]
+		ns.MsgsSent[i] += src.MsgsSent[i]
+		ns.BytesSent[i] += src.BytesSent[i]
+		ns.MsgsRecv[i] += src.MsgsRecv[i]
+		ns.BytesRecv[i] += src.BytesRecv[i]
+	}
+	ns.Recorded += src.Recorded
+}
+
+// NodeService 一个节点的服务质量
+type NodeService struct {
+	Node          int     // 节点ID
+	Broadcasts    int     // 计入统计的广播次数
+	Received      int     // 收到消息的次数
+	MissRate      float64 // 未收到消息的比例
+	MeanLatency   float64 // 收到消息时的平均接收时刻（ms，从未收到为NaN）
+	P95Latency    float64 // 接收时刻的95分位（ms，从未收到为NaN）
+	AvgDepth      float64 // 收到消息时的平均跳数（从未收到为NaN）
+	AvgDuplicates float64 // 每次广播收到的平均重复消息数
+
+	AvgMsgsSent  float64 // 每次广播发送的平均消息数
+	AvgBytesSent float64 // 每次广播的平均上行字节数
+	AvgMsgsRecv  float64 // 每次广播到达的平均消息数
+	AvgBytesRecv float64 // 每次广播的平均下行字节数
+}
+
+// Services 计算每个节点的服务质量（从未计入统计的节点各比例为NaN）
+func (ns *NodeStats) Services() []*NodeService {
+	services := make([]*NodeService, len(ns.Broadcasts))
+	for i := range services {
+		s := &NodeService{
+			Node:          i,
+			Broadcasts:    ns.Broadcasts[i],
+			Received:      ns.Received[i],
+			MissRate:      math.NaN(),
+			MeanLatency:   math.NaN(),
+			P95Latency:    math.NaN(),
+			AvgDepth:      math.NaN(),
+			AvgDuplicates: math.NaN(),
+		}
+		if ns.Recorded > 0 {
+			s.AvgMsgsSent = float64(ns.MsgsSent[i]) / float64(ns.Recorded)
+			s.AvgBytesSent = ns.BytesSent[i] / float64(ns.Recorded)
+			s.AvgMsgsRecv = float64(ns.MsgsRecv[i]) / float64(ns.Recorded)
+			s.AvgBytesRecv = ns.BytesRecv[i] / float64(ns.Recorded)
+		}
+		if s.Broadcasts > 0 {
+			s.MissRate = float64(ns.Missed[i]) / float64(s.Broadcasts)
+			s.AvgDuplicates = float64(ns.Duplicates[i]) / float64(s.Broadcasts)
+		}
+		if s.Received > 0 {
+			s.MeanLatency = Mean(ns.RecvTimes[i])
+			s.P95Latency = Percentile(ns.RecvTimes[i], 0.95)
+			s.AvgDepth = float64(ns.DepthSum[i]) / float64(s.Received)
+		}
+		services[i] = s
+	}
+	return services
+}
+
+// WorstServed 返回服务最差的k个节点：先按未收到比例、再按平均延迟从高到低排列
+func WorstServed(services []*NodeService, k int) []*NodeService {
+	ranked := make([]*NodeService, 0, len(services))
+	for _, s := range services {
+		if s.Broadcasts > 0 {
+			ranked = append(ranked, s)
+		}
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		if ranked[a].MissRate != ranked[b].MissRate {
+			return ranked[a].MissRate > ranked[b].MissRate
+		}
+		// 未收到比例相同时，两者要么都从未收到（平均延迟均为NaN），要么都收到过
+		return ranked[a].MeanLatency > ranked[b].MeanLatency
+	})
+	if k < len(ranked) {
+		ranked = ranked[:k]
+	}
+	return ranked
+}
+
+// ==================== 负载公平性 ====================
+
+// LoadFairness 一种负载在节点之间的分布
+type LoadFairness struct {
+	Metric    string  // 负载名称（SentBytes / RecvBytes / SentMsgs / RecvMsgs）
+	Max       float64 // 单个节点每次广播的最大负载
+	MaxNode   int     // 负载最大的节点
+	Mean      float64 // 每个节点每次广播的平均负载
+	Gini      float64 // 基尼系数（0为完全均匀，接近1为集中在少数节点）
+	Top1Share float64 // 负载最高的1%节点承担的负载比例
+}
+
+// LoadFairness 汇总上行/下行字节数和消息数在节点之间的分布（未记录广播时返回nil）
+func (ns *NodeStats) LoadFairness() []*LoadFairness {
+	if ns.Recorded == 0 {
+		return nil
+	}
+	n := len(ns.MsgsSent)
+	loads := []struct {
+		name  string
+		value func(i int) float64
+	}{
+		{"SentBytes", func(i int) float64 { return ns.BytesSent[i] }},
+		{"RecvBytes", func(i int) float64 { return ns.BytesRecv[i] }},
+		{"SentMsgs", func(i int) float64 { return float64(ns.MsgsSent[i]) }},
+		{"RecvMsgs", func(i int) float64 { return float64(ns.MsgsRecv[i]) }},
+	}
+
+	fairness := make([]*LoadFairness, 0, len(loads))
+	for _, load := range loads {
+		values := make([]float64, n)
+		lf := &LoadFairness{Metric: load.name}
+		for i := 0; i < n; i++ {
+			values[i] = load.value(i) / float64(ns.Recorded)
+			if values[i] > lf.Max {
+				lf.Max, lf.MaxNode = values[i], i
+			}
+		}
+		lf.Mean = Mean(values)
+		lf.Gini = Gini(values)
+		lf.Top1Share = TopShare(values, 0.01)
+		fairness = append(fairness, lf)
+	}
+	return fairness
+}
+
+// K0BucketSizes 获取每个节点K0桶（同一Geohash格子内的邻居）的大小，用于关联节点服务质量与格子稀疏程度
+func K0BucketSizes(kBuckets [][][]int) []int {
+	sizes := make([]int, len(kBuckets))
+	for i, buckets := range kBuckets {
+		if len(buckets) > 0 {
+			sizes[i] = len(buckets[0])
+		}
+	}
+	return sizes
+}
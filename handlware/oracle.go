@@ -0,0 +1,327 @@
+package handlware
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// ==================== 延迟下界Oracle ====================
+// LatencyOracle 计算消息从根节点到每个节点的最早可能到达时刻，用于衡量各算法离最优还有多远。
+// 与模拟器使用相同的延迟模型，每一跳的代价为：
+//
+//	Processing + latency.Delay(u, v) + 传输延迟
+//
+// 提供两种下界：
+//   - 全连接（FullMesh）：任意节点可直接向任意节点转发，即完全图上的最短路
+//   - 度受限（BoundedDegree）：根节点最多转发给RootDegree个节点，其余节点最多Degree个，
+//     用贪心的度受限最短路树启发式构建（不保证最优，但总是一棵可行的广播树）
+//
+// 恶意节点和离开节点不转发；拜占庭、丢包和churn都只会让广播更慢，下界中不考虑。
+// 下界与算法无关，按(坐标, 延迟模型, 传输延迟, 根节点, 不转发节点)的内容指纹缓存，同一实验中的各算法共享。
+// 延迟模型的指纹由模型名称、根节点到所有节点以及每个节点到两个探测节点的延迟构成；
+// 原地修改延迟模型中未被探测的节点对后，应调用Reset清空缓存。
+
+// LatencyOracle 延迟下界计算器
+type LatencyOracle struct {
+	Processing float64 // 每一跳的节点处理延迟（ms，默认为模拟器可能抽到的最小处理延迟MinProcessingDelay）
+	Degree     int     // 度受限下界中普通节点的最大转发数
+	RootDegree int     // 度受限下界中根节点的最大转发数
+
+	mu    sync.Mutex
+	cache map[oracleKey]*OracleBound
+}
+
+// oracleKey 下界缓存键（内容指纹，与坐标切片地址和延迟模型实例无关）
+type oracleKey struct {
+	n            int
+	root         int
+	coords       uint64  // 坐标的指纹
+	latency      uint64  // 延迟模型的指纹
+	transmission float64 // 每一跳的传输延迟（ms）
+	relay        uint64  // 不转发节点集合的指纹
+	processing   float64 // 计算时的Processing/Degree/RootDegree
+	degree       int
+	rootDegree   int
+}
+
+// OracleBound 一个根节点的延迟下界
+type OracleBound struct {
+	Root     int       // 根节点
+	FullMesh []float64 // 全连接下每个节点的最早到达时刻（ms）
+	Bounded  []float64 // 度受限下每个节点的到达时刻（ms，无法到达为+Inf）
+
+	FullMeshPercentiles []float64 // 全连接下界的延迟百分位（与TestResult.Latency对齐，无法到达为NaN）
+	BoundedPercentiles  []float64 // 度受限下界的延迟百分位
+}
+
+// NewLatencyOracle 创建默认的延迟下界计算器
+func NewLatencyOracle() *LatencyOracle {
+	return &LatencyOracle{
+		Processing: MinProcessingDelay,
+		Degree:     Fanout,
+		RootDegree: RootFanout,
+		cache:      make(map[oracleKey]*OracleBound),
+	}
+}
+
+// Bound 获取根节点在一次广播场景下的延迟下界（带缓存，可并发调用）
+// 参数:
+//   - root: 根节点
+//   - coords: 节点坐标
+//   - malFlags: 恶意节点标记（不转发，不计入百分位）
+//   - leaveFlags: 离开节点标记（不转发）
+//   - config: 模拟器配置（延迟模型、数据大小和带宽）
+//
+// 返回: 延迟下界
+func (o *LatencyOracle) Bound(root int, coords []LatLonCoordinate, malFlags, leaveFlags []bool, config *SimulatorConfig) *OracleBound {
+	n := len(coords)
+	relay := make([]bool, n)
+	counted := make([]bool, n)
+	h := fnv.New64a()
+	buf := make([]byte, 4)
+	for i := 0; i < n; i++ {
+		relay[i] = !malFlags[i] && !leaveFlags[i]
+		counted[i] = !malFlags[i]
+		if !relay[i] {
+			buf[0], buf[1], buf[2], buf[3] = byte(i), byte(i>>8), byte(i>>16), byte(i>>24)
+			h.Write(buf)
+			if malFlags[i] {
+				h.Write([]byte{1})
+			}
+		}
+	}
+	latency := config.GetLatencyModel(coords)
+	// 启用上行带宽模型时串行化延迟由各节点带宽决定，下界只计传播延迟
+	transmission := 0.0
+	if config.Uplink == nil {
+		transmission = CalculateTransmissionDelay(config.DataSize, config.Bandwidth)
+	}
+	key := oracleKey{
+		n:            n,
+		root:         root,
+		coords:       coordsFingerprint(coords),
+		latency:      latencyFingerprint(latency, root, n),
+		transmission: transmission,
+		relay:        h.Sum64(),
+		processing:   o.Processing,
+		degree:       o.Degree,
+		rootDegree:   o.RootDegree,
+	}
+
+	o.mu.Lock()
+	if o.cache == nil {
+		o.cache = make(map[oracleKey]*OracleBound)
+	}
+	bound, ok := o.cache[key]
+	o.mu.Unlock()
+	if ok {
+		return bound
+	}
+
+	bound = &OracleBound{
+		Root:     root,
+		FullMesh: o.FullMesh(root, latency, transmission, relay),
+		Bounded:  o.BoundedDegree(root, latency, transmission, relay),
+	}
+	bound.FullMeshPercentiles = oraclePercentiles(bound.FullMesh, counted)
+	bound.BoundedPercentiles = oraclePercentiles(bound.Bounded, counted)
+
+	o.mu.Lock()
+	o.cache[key] = bound
+	o.mu.Unlock()
+	return bound
+}
+
+// Reset 清空下界缓存
+func (o *LatencyOracle) Reset() {
+	o.mu.Lock()
+	o.cache = make(map[oracleKey]*OracleBound)
+	o.mu.Unlock()
+}
+
+// coordsFingerprint 坐标内容的指纹
+func coordsFingerprint(coords []LatLonCoordinate) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 16)
+	for _, c := range coords {
+		putFloat64(buf[0:8], c.Lat)
+		putFloat64(buf[8:16], c.Lon)
+		h.Write(buf)
+	}
+	return h.Sum64()
+}
+
+// latencyFingerprint 延迟模型的指纹：模型名称、根节点到所有节点的延迟，
+// 以及每个节点到下一个节点和一个跨步节点的延迟（O(n)次Delay调用）
+func latencyFingerprint(latency LatencyModel, root, n int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(latency.GetModelName()))
+	buf := make([]byte, 8)
+	for v := 0; v < n; v++ {
+		putFloat64(buf, latency.Delay(root, v))
+		h.Write(buf)
+		putFloat64(buf, latency.Delay(v, (v+1)%n))
+		h.Write(buf)
+		putFloat64(buf, latency.Delay(v, (v*7+3)%n))
+		h.Write(buf)
+	}
+	return h.Sum64()
+}
+
+// putFloat64 按小端序写入浮点数的二进制表示
+func putFloat64(buf []byte, x float64) {
+	bits := math.Float64bits(x)
+	for i := 0; i < 8; i++ {
+		buf[i] = byte(bits >> (8 * i))
+	}
+}
+
+// FullMesh 全连接下界：完全图上从根节点出发的最短路（稠密Dijkstra，O(n^2)）
+// 参数:
+//   - root: 根节点
+//   - latency: 链路延迟模型
+//   - transmission: 每一跳的传输延迟（ms）
+//   - relay: 可以转发的节点（根节点总是转发）
+//
+// 返回: 每个节点的最早到达时刻（ms）
+func (o *LatencyOracle) FullMesh(root int, latency LatencyModel, transmission float64, relay []bool) []float64 {
+	n := len(relay)
+	arrival := make([]float64, n)
+	done := make([]bool, n)
+	for i := range arrival {
+		arrival[i] = math.Inf(1)
+	}
+	arrival[root] = 0
+
+	for step := 0; step < n; step++ {
+		u := -1
+		for v := 0; v < n; v++ {
+			if !done[v] && (u < 0 || arrival[v] < arrival[u]) {
+				u = v
+			}
+		}
+		if u < 0 || math.IsInf(arrival[u], 1) {
+			break
+		}
+		done[u] = true
+		if u != root && !relay[u] {
+			continue
+		}
+		sendTime := arrival[u] + o.Processing + transmission
+		for v := 0; v < n; v++ {
+			if !done[v] {
+				if t := sendTime + latency.Delay(u, v); t < arrival[v] {
+					arrival[v] = t
+				}
+			}
+		}
+	}
+	return arrival
+}
+
+// BoundedDegree 度受限下界：贪心构建度受限最短路树
+// 每一步在所有"树中仍有转发名额的节点 -> 树外节点"的边中选择到达时刻最早的一条加入树，
+// 节点的候选父节点名额用尽后再重新为它选择父节点（约O(n^2)，n=8000时单个根节点约需20秒）
+// 参数同 FullMesh
+//
+// 返回: 每个节点在树中的到达时刻（ms，无法到达为+Inf）
+func (o *LatencyOracle) BoundedDegree(root int, latency LatencyModel, transmission float64, relay []bool) []float64 {
+	n := len(relay)
+	arrival := make([]float64, n)
+	best := make([]float64, n) // 树外节点经候选父节点的到达时刻
+	from := make([]int, n)     // 候选父节点
+	inTree := make([]bool, n)
+	capacity := make([]int, n)
+	for i := 0; i < n; i++ {
+		arrival[i] = math.Inf(1)
+		best[i] = math.Inf(1)
+		from[i] = -1
+	}
+
+	tree := make([]int, 0, n)
+	// join 将u加入树，并用u更新树外节点的候选父节点
+	join := func(u int, t float64) {
+		inTree[u] = true
+		arrival[u] = t
+		tree = append(tree, u)
+		switch {
+		case u == root:
+			capacity[u] = o.RootDegree
+		case relay[u]:
+			capacity[u] = o.Degree
+		}
+		if capacity[u] <= 0 {
+			return
+		}
+		sendTime := t + o.Processing + transmission
+		for v := 0; v < n; v++ {
+			if !inTree[v] {
+				if d := sendTime + latency.Delay(u, v); d < best[v] {
+					best[v], from[v] = d, u
+				}
+			}
+		}
+	}
+	// reselect 候选父节点名额用尽时，在仍有名额的树节点中为v重新选择父节点
+	reselect := func(v int) {
+		best[v], from[v] = math.Inf(1), -1
+		for _, u := range tree {
+			if capacity[u] > 0 {
+				if d := arrival[u] + o.Processing + transmission + latency.Delay(u, v); d < best[v] {
+					best[v], from[v] = d, u
+				}
+			}
+		}
+	}
+
+	join(root, 0)
+	for len(tree) < n {
+		// 名额用尽的候选只会让best偏小，选中时再重新选择即可
+		v := -1
+		for w := 0; w < n; w++ {
+			if !inTree[w] && (v < 0 || best[w] < best[v]) {
+				v = w
+			}
+		}
+		if v < 0 || from[v] < 0 {
+			break
+		}
+		if capacity[from[v]] == 0 {
+			reselect(v)
+			continue
+		}
+		capacity[from[v]]--
+		join(v, best[v])
+	}
+	return arrival
+}
+
+// oraclePercentiles 计算计入统计的节点的延迟百分位（与TestResult.Latency一致，对应节点无法到达时为NaN）
+func oraclePercentiles(arrival []float64, counted []bool) []float64 {
+	times := make([]float64, 0, len(arrival))
+	for i, t := range arrival {
+		if counted[i] {
+			times = append(times, t)
+		}
+	}
+	percentiles := CalculatePercentiles(times)
+	for i, t := range percentiles {
+		if math.IsInf(t, 1) {
+			percentiles[i] = math.NaN()
+		}
+	}
+	return percentiles
+}
+
+// oracleRatios 计算各百分位的延迟与下界之比（下界为0、延迟或下界未达到时为NaN）
+func oracleRatios(latency, bound []float64) []float64 {
+	ratios := make([]float64, len(latency))
+	for i := range latency {
+		ratios[i] = math.NaN()
+		if i < len(bound) && bound[i] > 0 && !math.IsNaN(bound[i]) && !math.IsNaN(latency[i]) {
+			ratios[i] = latency[i] / bound[i]
+		}
+	}
+	return ratios
+}
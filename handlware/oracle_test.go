@@ -0,0 +1,63 @@
+package handlware_test
+
+import (
+	"math"
+	"testing"
+
+	hw "gomercator/handlware"
+)
+
+// TestOracleCacheContentKey 下界缓存按内容命中：内容相同的坐标副本共享缓存，修改坐标或数据大小后重新计算
+func TestOracleCacheContentKey(t *testing.T) {
+	coords := syntheticCoords(100)
+	n := len(coords)
+	malFlags, leaveFlags := make([]bool, n), make([]bool, n)
+	config := hw.NewSimulatorConfig()
+	oracle := hw.NewLatencyOracle()
+
+	bound := oracle.Bound(3, coords, malFlags, leaveFlags, config)
+	copied := append([]hw.LatLonCoordinate(nil), coords...)
+	if oracle.Bound(3, copied, malFlags, leaveFlags, config) != bound {
+		t.Error("内容相同的坐标副本没有命中缓存")
+	}
+
+	copied[10].Lat += 30
+	if oracle.Bound(3, copied, malFlags, leaveFlags, config) == bound {
+		t.Error("修改坐标后仍命中旧的缓存")
+	}
+
+	larger := *config
+	larger.DataSize = hw.DataSizeLarge
+	if oracle.Bound(3, coords, malFlags, leaveFlags, &larger) == bound {
+		t.Error("修改数据大小后仍命中旧的缓存")
+	}
+}
+
+// TestOracleAveragedOverReached 下界与Latency在同一组（百分位达到的）广播上平均
+func TestOracleAveragedOverReached(t *testing.T) {
+	reached := hw.NewTestResult(0)
+	missed := hw.NewTestResult(0)
+	reached.OracleLatency = make([]float64, hw.NumPercentiles)
+	reached.BoundedOracleLatency = make([]float64, hw.NumPercentiles)
+	missed.OracleLatency = make([]float64, hw.NumPercentiles)
+	missed.BoundedOracleLatency = make([]float64, hw.NumPercentiles)
+	for i := 0; i < hw.NumPercentiles; i++ {
+		reached.Latency[i], reached.LatencyReached[i] = 200, 1
+		reached.OracleLatency[i], reached.BoundedOracleLatency[i] = 100, 150
+		missed.Latency[i], missed.LatencyReached[i] = math.NaN(), 0
+		missed.OracleLatency[i], missed.BoundedOracleLatency[i] = 10, 20
+	}
+
+	total := hw.NewTestResult(0)
+	hw.AccumulateResults(total, reached)
+	hw.AccumulateResults(total, missed)
+	hw.AverageResults(total, 2)
+
+	last := hw.NumPercentiles - 1
+	if total.OracleLatency[last] != 100 || total.BoundedOracleLatency[last] != 150 {
+		t.Errorf("下界 %v / %v，期望只在达到的广播中平均得到 100 / 150", total.OracleLatency[last], total.BoundedOracleLatency[last])
+	}
+	if total.LatencyRatio[last] != 2 {
+		t.Errorf("延迟/下界 %v，期望2", total.LatencyRatio[last])
+	}
+}
@@ -0,0 +1,277 @@
+package handlware
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// ==================== 区域故障与网络分区 ====================
+// GenerateMaliciousNodes / GenerateLeaveNodes 均匀随机选择节点。
+// 本文件描述按地理区域发生的相关故障：
+//   - 区域故障：某个geohash格子、经纬度矩形或K-means簇内的节点全部下线
+//     （通过AttackConfig.FailedNodes注入，与恶意节点一样不转发、不计入统计）
+//   - 网络分区：跨越区域边界的链路全部中断（PartitionLoss，作为LossModel配置）
+// 区域划分可转换为ClusterResult传给Simulation，从而按区域统计覆盖率
+
+// BoundingBox 经纬度矩形区域
+// MinLon > MaxLon 表示区域跨越180°经线
+type BoundingBox struct {
+	Name   string  // 区域名称
+	MinLat float64 // 最小纬度
+	MaxLat float64 // 最大纬度
+	MinLon float64 // 最小经度
+	MaxLon float64 // 最大经度
+}
+
+// NewBoundingBox 创建经纬度矩形区域
+func NewBoundingBox(name string, minLat, maxLat, minLon, maxLon float64) *BoundingBox {
+	return &BoundingBox{
+		Name:   name,
+		MinLat: minLat,
+		MaxLat: maxLat,
+		MinLon: minLon,
+		MaxLon: maxLon,
+	}
+}
+
+// Contains 判断坐标是否在区域内
+func (b *BoundingBox) Contains(c LatLonCoordinate) bool {
+	if c.Lat < b.MinLat || c.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return c.Lon >= b.MinLon && c.Lon <= b.MaxLon
+	}
+	return c.Lon >= b.MinLon || c.Lon <= b.MaxLon
+}
+
+// 常用大洲区域（粗略划分，用于大洲级故障和跨洋分区）
+var (
+	BoxAmericas = NewBoundingBox("americas", -60, 85, -170, -30)
+	BoxEurope   = NewBoundingBox("europe", 35, 72, -25, 45)
+	BoxAsia     = NewBoundingBox("asia", -10, 80, 45, 180)
+	BoxOceania  = NewBoundingBox("oceania", -50, -10, 110, 180)
+)
+
+// ==================== 区域故障节点生成 ====================
+
+// GeohashOutageNodes 生成geohash前缀下的故障节点标记
+// 参数:
+//   - coords: 节点坐标数组
+//   - prefix: geohash前缀（如 "u" 覆盖欧洲大部，"dr5" 约为纽约附近）
+func GeohashOutageNodes(coords []LatLonCoordinate, prefix string) []bool {
+	flags := make([]bool, len(coords))
+	encoder := NewGeohashEncoder(len(prefix))
+	for i, c := range coords {
+		flags[i] = strings.HasPrefix(encoder.Encode(c.Lat, c.Lon), prefix)
+	}
+	return flags
+}
+
+// BoundingBoxOutageNodes 生成经纬度矩形区域内的故障节点标记
+// 参数:
+//   - coords: 节点坐标数组
+//   - box: 故障区域
+func BoundingBoxOutageNodes(coords []LatLonCoordinate, box *BoundingBox) []bool {
+	flags := make([]bool, len(coords))
+	for i, c := range coords {
+		flags[i] = box.Contains(c)
+	}
+	return flags
+}
+
+// ClusterOutageNodes 生成指定K-means簇内的故障节点标记
+// 参数:
+//   - clusterResult: 聚类结果
+//   - clusters: 故障的簇ID
+func ClusterOutageNodes(clusterResult *ClusterResult, clusters ...int) []bool {
+	flags := make([]bool, len(clusterResult.ClusterID))
+	for i, c := range clusterResult.ClusterID {
+		for _, fc := range clusters {
+			if c == fc {
+				flags[i] = true
+				break
+			}
+		}
+	}
+	return flags
+}
+
+// CountFlags 统计标记为true的节点数
+func CountFlags(flags []bool) int {
+	count := 0
+	for _, f := range flags {
+		if f {
+			count++
+		}
+	}
+	return count
+}
+
+// ==================== 区域划分 ====================
+
+// RegionsByBoxes 按矩形区域划分节点
+// 节点属于第一个包含它的区域，不在任何区域内的节点属于区域 len(boxes)
+func RegionsByBoxes(coords []LatLonCoordinate, boxes ...*BoundingBox) []int {
+	regions := make([]int, len(coords))
+	for i, c := range coords {
+		regions[i] = len(boxes)
+		for r, box := range boxes {
+			if box.Contains(c) {
+				regions[i] = r
+				break
+			}
+		}
+	}
+	return regions
+}
+
+// RegionsByGeohash 按geohash前缀划分节点
+// 参数:
+//   - coords: 节点坐标数组
+//   - precision: 前缀长度（字符数）
+//
+// 返回: 每个节点的区域ID，以及区域ID对应的geohash前缀
+func RegionsByGeohash(coords []LatLonCoordinate, precision int) ([]int, []string) {
+	encoder := NewGeohashEncoder(precision)
+	regions := make([]int, len(coords))
+	prefixes := make([]string, 0)
+	index := make(map[string]int)
+	for i, c := range coords {
+		h := encoder.Encode(c.Lat, c.Lon)
+		r, ok := index[h]
+		if !ok {
+			r = len(prefixes)
+			index[h] = r
+			prefixes = append(prefixes, h)
+		}
+		regions[i] = r
+	}
+	return regions, prefixes
+}
+
+// RegionClusters 将区域划分转换为ClusterResult，用于Simulation的分区域统计
+func RegionClusters(regions []int) *ClusterResult {
+	k := 0
+	for _, r := range regions {
+		if r+1 > k {
+			k = r + 1
+		}
+	}
+	result := NewClusterResult(k, len(regions))
+	for i, r := range regions {
+		result.ClusterID[i] = r
+		result.ClusterList[r] = append(result.ClusterList[r], i)
+		result.ClusterCnt[r]++
+	}
+	return result
+}
+
+// ==================== 网络分区 ====================
+
+// PartitionLoss 网络分区：两端属于不同区域的链路全部中断
+type PartitionLoss struct {
+	Regions []int // 每个节点所属的区域
+}
+
+// NewPartitionLoss 按区域划分创建网络分区
+func NewPartitionLoss(regions []int) *PartitionLoss {
+	return &PartitionLoss{Regions: regions}
+}
+
+// NewBoundingBoxPartition 按矩形区域创建网络分区
+// 例如 NewBoundingBoxPartition(coords, BoxAmericas) 切断所有跨大西洋/太平洋的链路
+func NewBoundingBoxPartition(coords []LatLonCoordinate, boxes ...*BoundingBox) *PartitionLoss {
+	return NewPartitionLoss(RegionsByBoxes(coords, boxes...))
+}
+
+// Cut 判断链路(u, v)是否被分区切断
+func (pl *PartitionLoss) Cut(u, v int) bool {
+	return pl.Regions[u] != pl.Regions[v]
+}
+
+// NewSession 实现LossModel接口
+func (pl *PartitionLoss) NewSession(rng *rand.Rand) LossSession {
+	return &probLossSession{
+		prob: func(u, v int) float64 {
+			if pl.Cut(u, v) {
+				return 1
+			}
+			return 0
+		},
+		rng: rng,
+	}
+}
+
+// GetModelName 实现LossModel接口
+func (pl *PartitionLoss) GetModelName() string {
+	k := 0
+	for _, r := range pl.Regions {
+		if r+1 > k {
+			k = r + 1
+		}
+	}
+	return fmt.Sprintf("partition%d", k)
+}
+
+// ==================== 区域故障场景 ====================
+
+// OutageScenario 一个区域故障/分区场景
+type OutageScenario struct {
+	Name      string         // 场景名称
+	Failed    []bool         // 故障节点（nil表示无）
+	Partition *PartitionLoss // 网络分区（nil表示无）
+}
+
+// OutageSimulation 运行区域故障场景，并按区域输出存活节点的覆盖率
+// 参数:
+//   - reptTime: 重复次数
+//   - coords: 节点坐标数组
+//   - attackConfig: 攻击配置（FailedNodes由场景覆盖）
+//   - algo: 广播算法
+//   - config: 模拟器配置（分区与已配置的丢包模型组合）
+//   - scenario: 故障场景
+//   - regions: 统计覆盖率的区域划分（如RegionClusters或K-means结果）
+//
+// 返回: 模拟结果（ClusterEligible/ClusterCovered为各区域的统计）
+func OutageSimulation(
+	reptTime int,
+	coords []LatLonCoordinate,
+	attackConfig *AttackConfig,
+	algo Algorithm,
+	config *SimulatorConfig,
+	scenario *OutageScenario,
+	regions *ClusterResult,
+) *TestResult {
+
+	fmt.Printf("区域故障场景: %s, 故障节点 %d/%d\n", scenario.Name, CountFlags(scenario.Failed), len(coords))
+
+	scenarioAttack := *attackConfig
+	scenarioAttack.FailedNodes = scenario.Failed
+
+	scenarioConfig := *config
+	if scenario.Partition != nil {
+		if config.Loss != nil {
+			scenarioConfig.Loss = NewCombinedLoss(config.Loss, scenario.Partition)
+		} else {
+			scenarioConfig.Loss = scenario.Partition
+		}
+	}
+
+	result := Simulation(reptTime, coords, &scenarioAttack, algo, &scenarioConfig, regions)
+	PrintRegionCoverage(result, regions)
+	return result
+}
+
+// PrintRegionCoverage 打印各存活区域的覆盖率（没有存活节点的区域不输出）
+func PrintRegionCoverage(result *TestResult, regions *ClusterResult) {
+	fmt.Printf("总覆盖率 %.2f%%\n", result.Coverage*100)
+	for c := 0; c < len(result.ClusterEligible); c++ {
+		if result.ClusterEligible[c] == 0 {
+			continue
+		}
+		fmt.Printf("  区域 %d: 节点 %d, 存活 %.1f, 覆盖率 %.2f%%\n",
+			c, regions.ClusterCnt[c], result.ClusterEligible[c], result.RegionCoverage(c)*100)
+	}
+}
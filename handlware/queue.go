@@ -6,6 +6,7 @@ import (
 
 // ==================== 消息优先队列 ====================
 // 用于模拟消息传播过程，按接收时间（RecvTime）排序
+// 除消息到达外，队列也承载定时器事件和延迟发送事件（触发时刻记在RecvTime）
 
 // MessageQueue 消息优先队列（最小堆）
 type MessageQueue []*Message
@@ -15,9 +16,13 @@ func (mq MessageQueue) Len() int {
 	return len(mq)
 }
 
-// Less 实现heap.Interface，按RecvTime升序排列
+// Less 实现heap.Interface，按RecvTime升序排列，同一时刻按入队顺序
+// 保证同时到达的事件处理顺序不受队列中其他事件（如定时器）的影响
 func (mq MessageQueue) Less(i, j int) bool {
-	return mq[i].RecvTime < mq[j].RecvTime
+	if mq[i].RecvTime != mq[j].RecvTime {
+		return mq[i].RecvTime < mq[j].RecvTime
+	}
+	return mq[i].seq < mq[j].seq
 }
 
 // Swap 实现heap.Interface
@@ -45,6 +50,7 @@ func (mq *MessageQueue) Pop() interface{} {
 // PriorityQueue 优先队列包装器，提供更友好的接口
 type PriorityQueue struct {
 	queue MessageQueue
+	seq   uint64 // 下一个入队序号
 }
 
 // NewPriorityQueue 创建新的优先队列
@@ -58,6 +64,8 @@ func NewPriorityQueue() *PriorityQueue {
 
 // Push 添加消息到队列
 func (pq *PriorityQueue) Push(msg *Message) {
+	msg.seq = pq.seq
+	pq.seq++
 	heap.Push(&pq.queue, msg)
 }
 
@@ -69,6 +77,14 @@ func (pq *PriorityQueue) Pop() *Message {
 	return heap.Pop(&pq.queue).(*Message)
 }
 
+// Peek 查看接收时间最早的消息（不取出）
+func (pq *PriorityQueue) Peek() *Message {
+	if pq.Empty() {
+		return nil
+	}
+	return pq.queue[0]
+}
+
 // Empty 检查队列是否为空
 func (pq *PriorityQueue) Empty() bool {
 	return pq.queue.Len() == 0
@@ -78,3 +94,23 @@ func (pq *PriorityQueue) Empty() bool {
 func (pq *PriorityQueue) Len() int {
 	return pq.queue.Len()
 }
+
+// ==================== 定时器与延迟发送 ====================
+
+// PushTimer 添加定时器事件：at时刻在node上触发，tag由算法自定义
+func (pq *PriorityQueue) PushTimer(root, node int, at float64, tag int) *Message {
+	msg := NewMessage(root, node, node, 0, at, at)
+	msg.Type = MsgTimer
+	msg.Tag = tag
+	pq.Push(msg)
+	return msg
+}
+
+// PushScheduledSend 添加延迟发送事件：at时刻由u向v发送msgType类型的消息
+func (pq *PriorityQueue) PushScheduledSend(root, u, v int, at float64, msgType MessageType) *Message {
+	msg := NewMessage(root, u, v, 0, at, at)
+	msg.Type = MsgScheduled
+	msg.Tag = int(msgType)
+	pq.Push(msg)
+	return msg
+}
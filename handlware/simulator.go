@@ -5,13 +5,25 @@ import (
 	"math"
 	"math/rand"
 	"sort"
+	"sync"
 )
 
 // SimulatorConfig 模拟器配置
 type SimulatorConfig struct {
-	Bandwidth float64 // 带宽（bps）
-	DataSize  float64 // 数据包大小（Bytes）
-	MaxNodes  int     // 最大节点数
+	Bandwidth float64         // 带宽（bps）
+	DataSize  float64         // 数据包大小（Bytes）
+	MaxNodes  int             // 最大节点数
+	Latency   LatencyModel    // 链路延迟模型（nil表示使用地理距离模型）
+	RTT       LatencyModel    // Vivaldi坐标训练使用的RTT模型（nil表示 Distance + FixedDelay）
+	Uplink    *BandwidthModel // 每节点带宽与上行串行化模型（nil表示每次转发使用相同的传输延迟）
+	Loss      LossModel       // 链路丢包模型（nil表示所有消息都会送达）
+	Churn     *ChurnConfig    // 广播过程中的节点动态（nil表示无churn）
+	Ctx       *SimContext     // 模拟上下文（随机种子，nil表示使用DefaultSeed）
+	Workers   int             // 多根节点模拟的并发数（<=1表示串行，结果与并发数无关）
+	Oracle    *LatencyOracle  // 延迟下界（nil表示不计算，见oracle.go）
+
+	AnnounceSize float64 // 公告消息大小（Bytes，算法选择只公告时使用）
+	RequestSize  float64 // 请求消息大小（Bytes）
 }
 
 // NewSimulatorConfig 创建默认配置
@@ -20,9 +32,44 @@ func NewSimulatorConfig() *SimulatorConfig {
 		Bandwidth: BandwidthDefault,
 		DataSize:  DataSizeSmall,
 		MaxNodes:  8000,
+		Latency:   nil,
+		RTT:       nil,
+		Uplink:    nil,
+		Loss:      nil,
+		Churn:     nil,
+		Ctx:       NewSimContext(DefaultSeed),
+		Workers:   1,
+		Oracle:    nil,
+
+		AnnounceSize: DataSizeAnnounce,
+		RequestSize:  DataSizeRequest,
 	}
 }
 
+// GetLatencyModel 获取链路延迟模型，未设置时使用默认的地理距离模型
+func (sc *SimulatorConfig) GetLatencyModel(coords []LatLonCoordinate) LatencyModel {
+	if sc.Latency == nil {
+		return NewGeoLatencyModel(coords)
+	}
+	return sc.Latency
+}
+
+// GetContext 获取模拟上下文，未设置时使用DefaultSeed
+func (sc *SimulatorConfig) GetContext() *SimContext {
+	if sc.Ctx == nil {
+		return NewSimContext(DefaultSeed)
+	}
+	return sc.Ctx
+}
+
+// GetRTTModel 获取Vivaldi坐标训练使用的RTT模型，未设置时使用地理RTT模型
+func (sc *SimulatorConfig) GetRTTModel(coords []LatLonCoordinate) LatencyModel {
+	if sc.RTT == nil {
+		return NewGeoRTTModel(coords)
+	}
+	return sc.RTT
+}
+
 // ==================== 单根节点模拟 ====================
 
 // SingleRootSimulation 单个根节点的广播模拟
@@ -32,6 +79,7 @@ func NewSimulatorConfig() *SimulatorConfig {
 //   - coords: 节点坐标数组
 //   - malFlags: 恶意节点标记（true表示恶意，拒绝转发）
 //   - leaveFlags: 节点离开标记（true表示离开，接收但不转发）
+//   - byzantine: 拜占庭节点（延迟/选择性/合谋转发，nil表示无）
 //   - algo: 广播算法实现
 //   - config: 模拟器配置
 //   - clusterResult: 聚类结果（可选，用于统计）
@@ -43,20 +91,23 @@ func SingleRootSimulation(
 	coords []LatLonCoordinate,
 	malFlags []bool,
 	leaveFlags []bool,
+	byzantine *ByzantineNodes,
 	algo Algorithm,
 	config *SimulatorConfig,
 	clusterResult *ClusterResult,
 ) *TestResult {
 
-	const inf = 1e8
 	n := len(coords)
 	result := NewTestResult(n)
+	result.Nodes = NewNodeStats(n)
 	// 用本地数组承接，结束时赋回结果
 	successChildren := make([][]int, n)
 
-	// 如果算法需要指定根节点，则重新初始化
-	// 注意：这在外部已经处理，此处不需要重建
-	algo.SetRoot(root)
+	latency := config.GetLatencyModel(coords)
+	delayRng := config.GetContext().Derive(fmt.Sprintf("processing/%d", root))
+	lossRng := config.GetContext().Derive(fmt.Sprintf("loss/%d", root))
+	churnRng := config.GetContext().Derive(fmt.Sprintf("churn/%d", root))
+	byzRng := config.GetContext().Derive(fmt.Sprintf("byzantine/%d", root))
 
 	for rept := 0; rept < reptTime; rept++ {
 		// 初始化状态
@@ -71,20 +122,193 @@ func SingleRootSimulation(
 			recvParent[i] = -1
 		}
 
+		// 每次重复开启一个新的广播会话（访问标记等状态不跨重复保留），会话随机流按根节点和重复次数派生
+		broadcast := algo.NewBroadcast(root, config.GetContext().Derive(fmt.Sprintf("algo/%d/%d", root, rept)))
+
 		dupMsg := 0
+		dupCount := make([]int, n)   // 每个节点收到的重复完整消息数
+		droppedMsg := 0              // 链路丢失的完整消息数（丢包模型，含网络分区切断的链路）
+		offlineDropped := 0          // 接收方已离线（churn）而丢失的完整消息数
+		requested := make([]bool, n) // 已向公告方请求过完整消息
+		pushCount, announceCount := 0, 0
+		bytesSent := 0.0
 
 		// 初始化消息队列
 		msgQueue := NewPriorityQueue()
 		msgQueue.Push(NewMessage(root, root, root, 0, 0, 0))
 
+		// 使用定时器的会话绑定调度器
+		timed, _ := broadcast.(TimedBroadcast)
+		sched := &queueScheduler{queue: msgQueue, root: root, recvFlag: recvFlag}
+		if timed != nil {
+			timed.SetScheduler(sched)
+		}
+
+		// 上行/下行链路调度器（启用带宽模型时）
+		var uplink *UplinkScheduler
+		var downlink *DownlinkScheduler
+		if config.Uplink != nil {
+			uplink = NewUplinkScheduler(config.Uplink)
+			downlink = NewDownlinkScheduler(config.Uplink)
+		}
+
+		// 丢包判定器（配置丢包模型时）
+		var loss LossSession
+		if config.Loss != nil {
+			loss = config.Loss.NewSession(lossRng)
+		}
+
+		// churn时间线（根节点、恶意节点、离开节点和拜占庭节点不参与）
+		var churn *churnState
+		if config.Churn != nil {
+			excluded := make([]bool, n)
+			for i := 0; i < n; i++ {
+				excluded[i] = i == root || malFlags[i] || leaveFlags[i] || (byzantine != nil && byzantine.Flags[i])
+			}
+			churn = newChurnState(n, GenerateChurnSchedule(n, config.Churn, excluded, churnRng))
+		}
+		// reachable 过滤邻居已知不在网络中的目标（主动离开或尚未加入）
+		reachable := func(targets []int) []int {
+			if churn == nil {
+				return targets
+			}
+			ret := make([]int, 0, len(targets))
+			for _, v := range targets {
+				if churn.known[v] {
+					ret = append(ret, v)
+				}
+			}
+			return ret
+		}
+
+		// send 节点u在 at+delay 时刻向v发送一条消息
+		send := func(u, v, step int, at, delay float64, msgType MessageType, size float64) {
+			if churn != nil && !churn.known[v] {
+				return
+			}
+			var newMsg *Message
+			if uplink != nil {
+				// 上行链路按转发列表顺序串行发送，到达时刻 = 发送完成 + 传播延迟
+				sendTime, finishTime := uplink.Schedule(u, v, at+delay, size)
+				newMsg = NewMessage(root, u, v, step, sendTime, finishTime+latency.Delay(u, v))
+			} else {
+				// 计算传播延迟（延迟模型 + 数据传输延迟）
+				dist := latency.Delay(u, v) + CalculateTransmissionDelay(size, config.Bandwidth)
+				newMsg = NewMessage(root, u, v, step, at+delay, at+dist+delay)
+			}
+			newMsg.Type = msgType
+			newMsg.Size = size
+			bytesSent += size
+			result.Nodes.recordSend(u, size)
+
+			// 丢失的消息照常占用上行链路，但不会到达
+			if loss != nil && loss.Drop(u, v) {
+				if msgType == MsgPayload {
+					droppedMsg++
+				}
+				return
+			}
+			if downlink != nil {
+				// 同时到达v的多条流共享v的下载带宽
+				newMsg.RecvTime = downlink.Schedule(v, newMsg.SendTime+latency.Delay(u, v), newMsg.RecvTime, size)
+			}
+			msgQueue.Push(newMsg)
+		}
+
 		// 事件驱动模拟
 		for !msgQueue.Empty() {
+			// 先应用时刻不晚于下一个事件的churn事件
+			if churn != nil && churn.due(msgQueue.Peek().RecvTime) {
+				ev := churn.apply()
+				if (ev.Kind == ChurnJoin || ev.Kind == ChurnRejoin) && !recvFlag[ev.Node] {
+					// 按算法的加入流程选择邻居，并向其请求正在传播的消息
+					joined := ev.Node
+					online := func(v int) bool { return v != joined && churn.online[v] && churn.known[v] }
+					for _, v := range JoinRequestPeers(algo, broadcast, joined, config.Churn.SyncPeers, online) {
+						send(joined, v, 0, ev.Time, 0, MsgRequest, config.RequestSize)
+					}
+				}
+				continue
+			}
+
 			msg := msgQueue.Pop()
 			u := msg.Dst // 当前接收节点
+			sched.now = msg.RecvTime
+
+			// 离线节点：到达的消息丢失，定时器和延迟发送不执行
+			if churn != nil {
+				actor := u
+				if msg.Type == MsgScheduled {
+					actor = msg.Src
+				}
+				if !churn.online[actor] {
+					if msg.Type == MsgPayload {
+						offlineDropped++
+					}
+					continue
+				}
+			}
+
+			// 节点负载：完整消息（含重复）、公告和请求到达接收方（忽略根节点的初始化自环）
+			if msg.Type != MsgTimer && msg.Type != MsgScheduled && msg.Src != u {
+				result.Nodes.recordRecv(u, msg.Size)
+			}
+
+			switch msg.Type {
+			case MsgAnnounce:
+				// 收到公告：尚未持有且未请求过时，向公告方请求完整消息
+				if !recvFlag[u] && !requested[u] {
+					requested[u] = true
+					send(u, msg.Src, msg.Step, msg.RecvTime, 0, MsgRequest, config.RequestSize)
+				}
+				continue
+			case MsgRequest:
+				// 收到请求：持有消息的正常节点向请求方发送完整消息
+				if recvFlag[u] && !malFlags[u] && !leaveFlags[u] {
+					targets, extra := byzantine.Withhold(u, []int{msg.Src}, byzRng)
+					for _, v := range targets {
+						send(u, v, depth[u]+1, msg.RecvTime, extra, MsgPayload, config.DataSize)
+					}
+				}
+				continue
+			case MsgTimer:
+				// 定时器到期：由会话决定从该节点立即推送的节点
+				if timed == nil || malFlags[u] || leaveFlags[u] {
+					continue
+				}
+				relayList := reachable(timed.OnTimer(u, msg.Tag))
+				if !recvFlag[u] {
+					continue
+				}
+				relayList, extra := byzantine.Withhold(u, relayList, byzRng)
+				for _, v := range relayList {
+					send(u, v, depth[u]+1, msg.RecvTime, extra, MsgPayload, config.DataSize)
+				}
+				pushCount += len(relayList)
+				continue
+			case MsgScheduled:
+				// 延迟发送：恶意节点和离开节点不执行，未收到消息的节点只能发送请求，不向已知离开的节点发送
+				src, msgType := msg.Src, MessageType(msg.Tag)
+				if malFlags[src] || leaveFlags[src] || (!recvFlag[src] && msgType != MsgRequest) || (churn != nil && !churn.known[u]) {
+					continue
+				}
+				targets, extra := byzantine.Withhold(src, []int{u}, byzRng)
+				if len(targets) == 0 {
+					continue
+				}
+				send(src, u, depth[src]+1, msg.RecvTime, extra, msgType, config.MessageSize(msgType))
+				if msgType == MsgPayload {
+					pushCount++
+				} else if msgType == MsgAnnounce {
+					announceCount++
+				}
+				continue
+			}
 
 			// 重复消息，忽略
 			if recvFlag[u] {
 				dupMsg++
+				dupCount[u]++
 				continue
 			}
 			// 首次成功到达：计入“成功转发边”
@@ -108,135 +332,308 @@ func SingleRootSimulation(
 				continue
 			}
 
-			// 调用算法的respond函数，获取转发节点列表
-			relayList := algo.Respond(msg)
+			// 调用广播会话的respond函数，获取推送和公告的节点列表
+			relayList, announceList := respondRelays(broadcast, msg)
+			relayList, announceList = reachable(relayList), reachable(announceList)
 
 			// 计算处理延迟
-			delayTime := CalculateProcessingDelay()
+			delayTime := CalculateProcessingDelayWithRng(delayRng)
+
+			// 拜占庭节点：扣留部分目标并延迟转发
+			if byzantine != nil && byzantine.Flags[u] {
+				var extra float64
+				relayList, extra = byzantine.Withhold(u, relayList, byzRng)
+				announceList, _ = byzantine.Withhold(u, announceList, byzRng)
+				delayTime += extra
+			}
 
-			// 向转发列表中的节点发送消息
+			// 向转发列表中的节点推送完整消息，再向公告列表发送公告
 			for _, v := range relayList {
-				// 计算传播延迟
-				// 注意：普通算法两种情况都使用系数3（与C++ single_root_simulation对齐）
-				dist := CalculatePropagationDelay(u, v, coords, config.Bandwidth, config.DataSize)
-
-				newMsg := NewMessage(root, u, v, msg.Step+1, recvTime[u]+delayTime, recvTime[u]+dist+delayTime)
-				msgQueue.Push(newMsg)
+				send(u, v, msg.Step+1, recvTime[u], delayTime, MsgPayload, config.DataSize)
+			}
+			for _, v := range announceList {
+				send(u, v, msg.Step+1, recvTime[u], delayTime, MsgAnnounce, config.AnnounceSize)
 			}
+			pushCount += len(relayList)
+			announceCount += len(announceList)
 		}
 
+		// 保存广播树（统计会改写未覆盖节点的深度）
+		tree := newBroadcastTree(root, recvFlag, recvParent, recvTime, recvDist, depth)
+		tree.Run = rept
+		result.Trees = append(result.Trees, tree)
+
 		// 统计结果
-		clusterRecvCount := make([]int, K)
-		recvCount := 0
-		avgLatency := 0.0
+		// 广播结束时离线的节点不计入覆盖率
+		statLeaveFlags := leaveFlags
+		if churn != nil {
+			statLeaveFlags = churn.offlineFlags(leaveFlags)
+		}
+		statMalFlags := byzantine.excludeFlags(malFlags)
+		result.Nodes.Recorded++
+		// 节点统计在collectBroadcastStats改写未覆盖节点的深度之前记录
+		result.Nodes.addBroadcast(recvFlag, recvTime, depth, dupCount, statMalFlags, statLeaveFlags)
+		recvCount := collectBroadcastStats(result, recvFlag, recvTime, recvDist, depth, recvList, dupMsg, droppedMsg, offlineDropped, statMalFlags, statLeaveFlags, clusterResult)
+		result.AvgBytes += bytesSent
+		result.AvgBytesSaved += float64(pushCount+announceCount)*config.DataSize - bytesSent
 
-		for i := 0; i < n; i++ {
-			if !recvFlag[i] && !malFlags[i] && !leaveFlags[i] {
-				// 未覆盖的节点
-				recvTime[i] = inf
-				recvList = append(recvList, i)
-				depth[i] = MaxDepth - 1
-			} else if recvFlag[i] {
-				recvCount++
-				avgLatency += recvTime[i]
-
-				// 簇统计
-				if clusterResult != nil {
-					c := clusterResult.ClusterID[i]
-					if c >= 0 && c < K {
-						clusterRecvCount[c]++
-						result.ClusterAvgDepth[c] += float64(depth[i])
-						result.ClusterAvgLatency[c] += recvTime[i]
+		// 打印收到消息的节点数
+		if rept == 0 {
+			fmt.Printf("收到消息的节点数: %d/%d (%.1f%%)\n", recvCount, n, float64(recvCount)*100.0/float64(n))
+		}
+		result.SuccessChildren = successChildren
+	}
+
+	// 延迟下界与算法和重复无关，每个根节点计算一次
+	if config.Oracle != nil {
+		bound := config.Oracle.Bound(root, coords, malFlags, leaveFlags, config)
+		result.OracleLatency = append([]float64(nil), bound.FullMeshPercentiles...)
+		result.BoundedOracleLatency = append([]float64(nil), bound.BoundedPercentiles...)
+	}
+
+	// 多次重复的平均值
+	finalizeResult(result, reptTime)
+	result.Samples = []*RootSample{newRootSample(root, result)}
+
+	return result
+}
+
+// collectBroadcastStats 将一次广播的接收情况累加到result（百分位、深度分布、带宽等）
+// 参数:
+//   - recvFlag/recvTime/recvDist/depth: 每个节点的接收标记、接收时间、最后一跳延迟、深度
+//   - recvList: 按接收顺序排列的已接收节点
+//   - dupMsg: 重复消息数
+//   - droppedMsg: 链路丢失的完整消息数（丢包模型，含网络分区切断的链路）
+//   - offlineDropped: 接收方已离线（churn）而丢失的完整消息数
+//
+// 注意：未覆盖的节点深度会被记为MaxDepth-1
+// 返回: 收到消息的节点数
+func collectBroadcastStats(
+	result *TestResult,
+	recvFlag []bool,
+	recvTime []float64,
+	recvDist []float64,
+	depth []int,
+	recvList []int,
+	dupMsg int,
+	droppedMsg int,
+	offlineDropped int,
+	malFlags []bool,
+	leaveFlags []bool,
+	clusterResult *ClusterResult,
+) int {
+
+	n := len(recvFlag)
+
+	clusterRecvCount := make([]int, K)
+	recvCount := 0
+	avgLatency := 0.0
+	eligible, covered := 0, 0
+	coveredTimes := make([]float64, 0, n) // 收到消息的正常节点的接收时间
+	if clusterResult != nil && result.ClusterEligible == nil {
+		result.ClusterEligible = make([]float64, clusterResult.K)
+		result.ClusterCovered = make([]float64, clusterResult.K)
+	}
+
+	for i := 0; i < n; i++ {
+		if !malFlags[i] && !leaveFlags[i] {
+			eligible++
+			if recvFlag[i] {
+				covered++
+				coveredTimes = append(coveredTimes, recvTime[i])
+			}
+			if clusterResult != nil {
+				// 未分配簇的节点（ID为-1）不计入
+				if c := clusterResult.ClusterID[i]; c >= 0 && c < len(result.ClusterEligible) {
+					result.ClusterEligible[c]++
+					if recvFlag[i] {
+						result.ClusterCovered[c]++
 					}
 				}
 			}
 		}
-
-		if recvCount > 0 {
-			avgLatency /= float64(recvCount)
-		}
-
-		// 簇统计平均值
-		if clusterResult != nil {
-			for c := 0; c < K; c++ {
-				if clusterRecvCount[c] > 0 {
-					result.ClusterAvgDepth[c] /= float64(clusterRecvCount[c])
-					result.ClusterAvgLatency[c] /= float64(clusterRecvCount[c])
+		if !recvFlag[i] && !malFlags[i] && !leaveFlags[i] {
+			// 未覆盖的节点
+			recvList = append(recvList, i)
+			depth[i] = MaxDepth - 1
+		} else if recvFlag[i] {
+			recvCount++
+			avgLatency += recvTime[i]
+
+			// 簇统计
+			if clusterResult != nil {
+				c := clusterResult.ClusterID[i]
+				if c >= 0 && c < K {
+					clusterRecvCount[c]++
+					result.ClusterAvgDepth[c] += float64(depth[i])
+					result.ClusterAvgLatency[c] += recvTime[i]
 				}
 			}
 		}
+	}
+
+	if recvCount > 0 {
+		avgLatency /= float64(recvCount)
+	}
 
-		// 计算带宽消耗
-		nonMalNode := len(recvList)
-		result.AvgBandwidth += float64(dupMsg+nonMalNode) / float64(nonMalNode)
-
-		// 深度统计
-		depthCnt := make([]int, MaxDepth)
-		for _, u := range recvList {
-			d := depth[u]
-			if d >= 0 && d < MaxDepth {
-				result.DepthCDF[d] += 1
-				result.AvgDist[d] += recvDist[u]
-				depthCnt[d]++
+	// 簇统计平均值
+	if clusterResult != nil {
+		for c := 0; c < K; c++ {
+			if clusterRecvCount[c] > 0 {
+				result.ClusterAvgDepth[c] /= float64(clusterRecvCount[c])
+				result.ClusterAvgLatency[c] /= float64(clusterRecvCount[c])
 			}
 		}
+	}
 
-		result.AvgLatency = avgLatency
+	// 计算带宽消耗
+	nonMalNode := len(recvList)
+	result.AvgBandwidth += float64(dupMsg+nonMalNode) / float64(nonMalNode)
+	result.AvgDropped += float64(droppedMsg) / float64(nonMalNode)
+	result.AvgOfflineDropped += float64(offlineDropped) / float64(nonMalNode)
+	if eligible > 0 {
+		result.Coverage += float64(covered) / float64(eligible)
+	}
 
-		// 归一化
-		for i := 0; i < MaxDepth; i++ {
-			result.DepthCDF[i] /= float64(nonMalNode)
-			if depthCnt[i] > 0 {
-				result.AvgDist[i] /= float64(depthCnt[i])
-			}
+	// 深度统计
+	depthCnt := make([]int, MaxDepth)
+	for _, u := range recvList {
+		d := depth[u]
+		if d >= 0 && d < MaxDepth {
+			result.DepthCDF[d] += 1
+			result.AvgDist[d] += recvDist[u]
+			depthCnt[d]++
 		}
+	}
 
-		// 计算延迟百分位
-		// 按接收时间排序
-		sort.Slice(recvList, func(i, j int) bool {
-			return recvTime[recvList[i]] < recvTime[recvList[j]]
-		})
-
-		cnt := 0
-		for pct := 0.05; pct <= 1.0; pct += 0.05 {
-			idx := int(float64(nonMalNode) * pct)
-			if idx >= nonMalNode {
-				idx = nonMalNode - 1
-			}
-			result.Latency[cnt] += recvTime[recvList[idx]]
-			cnt++
+	result.AvgLatency = avgLatency
+
+	// 归一化
+	for i := 0; i < MaxDepth; i++ {
+		result.DepthCDF[i] /= float64(nonMalNode)
+		if depthCnt[i] > 0 {
+			result.AvgDist[i] /= float64(depthCnt[i])
 		}
+	}
 
-		// 打印收到消息的节点数
-		if rept == 0 {
-			fmt.Printf("收到消息的节点数: %d/%d (%.1f%%)\n", recvCount, n, float64(recvCount)*100.0/float64(n))
+	// 计算延迟百分位：按接收时间排序，未覆盖的节点排在最后
+	sort.SliceStable(recvList, func(i, j int) bool {
+		a, b := recvList[i], recvList[j]
+		if recvFlag[a] != recvFlag[b] {
+			return recvFlag[a]
+		}
+		return recvFlag[a] && recvTime[a] < recvTime[b]
+	})
+	for i := 0; i < NumPercentiles; i++ {
+		// 全体节点：对应节点未收到时该百分位未达到
+		if u := recvList[percentileIndex(nonMalNode, i)]; recvFlag[u] {
+			result.Latency[i] += recvTime[u]
+			result.LatencyReached[i]++
+		}
+		// 只统计收到消息的节点（排序后位于前recvCount个）
+		if recvCount > 0 {
+			result.DeliveredLatency[i] += recvTime[recvList[percentileIndex(recvCount, i)]]
 		}
-		result.SuccessChildren = successChildren
 	}
 
-	// 多次重复的平均值
+	// 覆盖时间曲线：第k个正常节点收到消息的时刻即覆盖k/eligible的时刻
+	sort.Float64s(coveredTimes)
+	for i := 0; i < CoverageLevels && eligible > 0; i++ {
+		k := ((i+1)*eligible + CoverageLevels - 1) / CoverageLevels
+		if k >= 1 && k <= covered {
+			result.CoverageTime[i] += coveredTimes[k-1]
+			result.CoverageReached[i]++
+		}
+	}
+
+	return recvCount
+}
+
+// finalizeResult 对reptTime次广播累加的结果求平均（未达到的百分位不计入平均）
+func finalizeResult(result *TestResult, reptTime int) {
 	result.AvgBandwidth /= float64(reptTime)
+	result.AvgDropped /= float64(reptTime)
+	result.AvgOfflineDropped /= float64(reptTime)
+	result.Coverage /= float64(reptTime)
+	result.AvgBytes /= float64(reptTime)
+	result.AvgBytesSaved /= float64(reptTime)
+	for c := range result.ClusterEligible {
+		result.ClusterEligible[c] /= float64(reptTime)
+		result.ClusterCovered[c] /= float64(reptTime)
+	}
 	for i := 0; i < MaxDepth; i++ {
 		result.DepthCDF[i] /= float64(reptTime)
 	}
 
-	for i := 0; i < len(result.Latency); i++ {
-		tmp := int(result.Latency[i] / inf)
-		result.Latency[i] -= float64(tmp) * inf
-		validCount := reptTime - tmp
+	averageReached(result.Latency, result.LatencyReached, reptTime)
+	averageReached(result.CoverageTime, result.CoverageReached, reptTime)
+	for i := range result.DeliveredLatency {
+		result.DeliveredLatency[i] /= float64(reptTime)
+	}
 
-		if validCount == 0 {
-			result.Latency[i] = 0
-		} else {
-			result.Latency[i] /= float64(validCount)
-		}
+	if result.OracleLatency != nil {
+		result.LatencyRatio = oracleRatios(result.Latency, result.OracleLatency)
+		result.BoundedLatencyRatio = oracleRatios(result.Latency, result.BoundedOracleLatency)
+	}
+}
 
-		if result.Latency[i] < 0.1 {
-			result.Latency[i] = inf
-		}
+// MessageSize 获取各类型消息的大小（Bytes）
+func (sc *SimulatorConfig) MessageSize(msgType MessageType) float64 {
+	switch msgType {
+	case MsgAnnounce:
+		return sc.AnnounceSize
+	case MsgRequest:
+		return sc.RequestSize
+	default:
+		return sc.DataSize
 	}
+}
 
-	return result
+// ==================== 会话调度 ====================
+
+// queueScheduler 基于模拟器事件队列的Scheduler实现
+type queueScheduler struct {
+	queue    *PriorityQueue
+	root     int
+	id       int     // 所属广播ID
+	now      float64 // 当前事件时刻
+	recvFlag []bool
+	onPush   func() // 每添加一个事件时调用（可为nil）
+}
+
+// Now 实现Scheduler接口
+func (qs *queueScheduler) Now() float64 {
+	return qs.now
+}
+
+// Has 实现Scheduler接口
+func (qs *queueScheduler) Has(node int) bool {
+	return qs.recvFlag[node]
+}
+
+// SetTimer 实现Scheduler接口
+func (qs *queueScheduler) SetTimer(node int, delay float64, tag int) {
+	qs.queue.PushTimer(qs.root, node, qs.now+delay, tag).ID = qs.id
+	if qs.onPush != nil {
+		qs.onPush()
+	}
+}
+
+// SendAfter 实现Scheduler接口
+func (qs *queueScheduler) SendAfter(u, v int, delay float64, msgType MessageType) {
+	qs.queue.PushScheduledSend(qs.root, u, v, qs.now+delay, msgType).ID = qs.id
+	if qs.onPush != nil {
+		qs.onPush()
+	}
+}
+
+// respondRelays 获取转发决策
+// 支持公告的会话（AnnouncingBroadcast）逐目标选择推送或公告，其余会话全部推送完整消息
+func respondRelays(broadcast Broadcast, msg *Message) ([]int, []int) {
+	if ab, ok := broadcast.(AnnouncingBroadcast); ok {
+		return ab.RespondAnnounce(msg)
+	}
+	return broadcast.Respond(msg), nil
 }
 
 // ==================== 多根节点模拟 ====================
@@ -260,47 +657,61 @@ func Simulation(
 	clusterResult *ClusterResult,
 ) *TestResult {
 
-	rand.Seed(100) // 固定种子，确保可重复性
+	// 所有随机性来自模拟上下文，同一种子逐位可重复
+	ctx := config.GetContext()
 	n := len(coords)
 	result := NewTestResult(n)
 	testTime := 0
 
 	for rept := 0; rept < reptTime; rept++ {
 		fmt.Printf("重复测试 %d/%d\n", rept+1, reptTime)
+		reptCtx := ctx.Sub(fmt.Sprintf("rept/%d", rept))
+		rootRng := reptCtx.Derive("roots")
 
 		// 1) 生成恶意节点列表
-		malFlags := GenerateMaliciousNodes(n, attackConfig.MaliciousRatio)
+		malFlags := GenerateMaliciousNodes(n, attackConfig.MaliciousRatio, reptCtx.Derive("malicious"))
+		// 区域故障节点与恶意节点一样不转发、不计入统计
+		for i, failed := range attackConfig.FailedNodes {
+			if failed {
+				malFlags[i] = true
+			}
+		}
 
 		// 2) 生成节点离开列表
-		leaveFlags := GenerateLeaveNodes(n, attackConfig.NodeLeaveRatio)
+		leaveFlags := GenerateLeaveNodes(n, attackConfig.NodeLeaveRatio, reptCtx.Derive("leave"))
 
-		// 3) 如果算法需要为每个根重建，则在外部重新创建algo实例
-		// 这里假设algo已经在外部正确初始化
+		// 生成拜占庭节点（延迟/选择性/合谋转发，从非恶意、未离开的节点中选出，未配置时为nil）
+		byzExcluded := make([]bool, n)
+		for i := 0; i < n; i++ {
+			byzExcluded[i] = malFlags[i] || leaveFlags[i]
+		}
+		byzantine := NewByzantineNodes(n, attackConfig, byzExcluded, reptCtx.Derive("byzantine"))
 
-		// 4) 测试多个随机根节点
+		// 3) 按顺序选出所有根节点（根节点序列与并发数无关）
 		testNodes := 20
+		roots := make([]int, testNodes)
 		for t := 0; t < testNodes; t++ {
-			fmt.Printf("  测试节点 %d/%d\n", t+1, testNodes)
-
-			// 随机选择一个非恶意、未离开的根节点
-			root := rand.Intn(n)
-			for malFlags[root] || leaveFlags[root] {
-				root = rand.Intn(n)
+			// 随机选择一个非恶意、未离开、非拜占庭的根节点
+			root := rootRng.Intn(n)
+			for malFlags[root] || leaveFlags[root] || (byzantine != nil && byzantine.Flags[root]) {
+				root = rootRng.Intn(n)
 			}
+			roots[t] = root
+		}
 
+		// 4) 运行各根节点的单根模拟，并按根节点顺序写出和累积结果
+		results := runRootSimulations(roots, reptCtx, coords, malFlags, leaveFlags, byzantine, algo, config, clusterResult)
+		for t, res := range results {
 			testTime++
-
-			// 如果算法需要指定根节点重建
-			if algo.NeedSpecifiedRoot() {
-				// 这里需要外部重新创建算法实例
-				// 暂时使用SetRoot
-				algo.SetRoot(root)
+			for _, tree := range res.Trees {
+				tree.Run = rept
+				tree.Index = t
 			}
-
-			// 单根模拟
-			res := SingleRootSimulation(root, 1, coords, malFlags, leaveFlags, algo, config, clusterResult)
-			
-			_ = WriteSuccessChildrenCSV("success_edges.csv", root, res.SuccessChildren)
+			for _, sample := range res.Samples {
+				sample.Run = rept
+				sample.Index = t
+			}
+			_ = WriteSuccessChildrenCSV("success_edges.csv", roots[t], res.SuccessChildren)
 			// 累积结果
 			AccumulateResults(result, res)
 		}
@@ -310,20 +721,98 @@ func Simulation(
 	AverageResults(result, testTime)
 
 	fmt.Printf("模拟完成，共测试 %d 次\n", testTime)
+	if result.LatencyRatio != nil {
+		fmt.Printf("延迟/下界 (50%%, 90%%): 全连接 %.2fx, %.2fx; 度受限 %.2fx, %.2fx\n",
+			result.LatencyRatio[9], result.LatencyRatio[17], result.BoundedLatencyRatio[9], result.BoundedLatencyRatio[17])
+	}
+	if result.Nodes != nil {
+		if fairness := result.Nodes.LoadFairness(); fairness != nil && fairness[0].Mean > 0 {
+			up := fairness[0]
+			fmt.Printf("上行负载: 最大/平均 %.1fx, Gini %.3f, 前1%%节点占 %.1f%%\n", up.Max/up.Mean, up.Gini, up.Top1Share*100)
+		}
+	}
 	return result
 }
 
+// runRootSimulations 运行一组根节点的单根模拟，结果按roots顺序返回
+// 参数:
+//   - roots: 根节点列表
+//   - reptCtx: 本次重复实验的上下文（第t个根节点使用子上下文 root/t）
+//   - 其余参数同 Simulation
+//
+// 每个根节点在同一拓扑上开启独立的广播会话，会话的随机流由根节点上下文派生，
+// 因此串行与并行运行得到完全相同的结果；
+// 在广播之间持续学习的算法（AdaptiveAlgorithm）会话会修改共享状态，只能串行运行。
+func runRootSimulations(
+	roots []int,
+	reptCtx *SimContext,
+	coords []LatLonCoordinate,
+	malFlags []bool,
+	leaveFlags []bool,
+	byzantine *ByzantineNodes,
+	algo Algorithm,
+	config *SimulatorConfig,
+	clusterResult *ClusterResult,
+) []*TestResult {
+
+	results := make([]*TestResult, len(roots))
+
+	runOne := func(t int) {
+		// 单根模拟（每个根节点使用独立的子上下文）
+		rootConfig := *config
+		rootConfig.Ctx = reptCtx.Sub(fmt.Sprintf("root/%d", t))
+		results[t] = SingleRootSimulation(roots[t], 1, coords, malFlags, leaveFlags, byzantine, algo, &rootConfig, clusterResult)
+	}
+
+	workers := Min(config.Workers, len(roots))
+	adaptive := false
+	if aa, ok := algo.(AdaptiveAlgorithm); ok {
+		adaptive = aa.AdaptsAcrossBroadcasts()
+	}
+	if workers > 1 && adaptive {
+		fmt.Printf("算法 %s 在广播之间共享学习状态，%d 个根节点改为串行模拟\n", algo.GetAlgoName(), len(roots))
+	}
+	if workers <= 1 || adaptive {
+		for t := range roots {
+			fmt.Printf("  测试节点 %d/%d\n", t+1, len(roots))
+			runOne(t)
+		}
+		return results
+	}
+
+	// 工作池：每个协程领取根节点下标，结果写入各自的位置（并行时只输出一行进度，避免各协程的输出交错）
+	fmt.Printf("  测试节点 1-%d/%d（%d 个协程并行）\n", len(roots), len(roots), workers)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				runOne(t)
+			}
+		}()
+	}
+	for t := range roots {
+		jobs <- t
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
 // ==================== 攻击场景生成 ====================
 
 // GenerateMaliciousNodes 生成恶意节点标记（拒绝转发）
-func GenerateMaliciousNodes(n int, ratio float64) []bool {
+func GenerateMaliciousNodes(n int, ratio float64, rng *rand.Rand) []bool {
 	flags := make([]bool, n)
 	count := int(float64(n) * ratio)
 
 	for i := 0; i < count; i++ {
-		node := rand.Intn(n)
+		node := rng.Intn(n)
 		for flags[node] {
-			node = rand.Intn(n)
+			node = rng.Intn(n)
 		}
 		flags[node] = true
 	}
@@ -339,14 +828,14 @@ func GenerateMaliciousNodes(n int, ratio float64) []bool {
 // 注意：节点离开与恶意节点的区别：
 //   - 恶意节点：完全不响应
 //   - 离开节点：接收消息但不转发，统计时不计入
-func GenerateLeaveNodes(n int, ratio float64) []bool {
+func GenerateLeaveNodes(n int, ratio float64, rng *rand.Rand) []bool {
 	flags := make([]bool, n)
 	count := int(float64(n) * ratio)
 
 	for i := 0; i < count; i++ {
-		node := rand.Intn(n)
+		node := rng.Intn(n)
 		for flags[node] {
-			node = rand.Intn(n)
+			node = rng.Intn(n)
 		}
 		flags[node] = true
 	}
@@ -363,9 +852,10 @@ func GenerateLeaveNodes(n int, ratio float64) []bool {
 //   - coords: 真实坐标数组
 //   - ratio: 谎报坐标节点比例
 //   - offsetDegree: 偏移度数（如10, 20, 30，或-1表示完全随机）
+//   - rng: 随机流（由SimContext派生）
 //
 // 返回: (伪造坐标数组, 谎报标记数组)
-func GenerateFakeCoordinates(coords []LatLonCoordinate, ratio float64, offsetDegree float64) ([]LatLonCoordinate, []bool) {
+func GenerateFakeCoordinates(coords []LatLonCoordinate, ratio float64, offsetDegree float64, rng *rand.Rand) ([]LatLonCoordinate, []bool) {
 	n := len(coords)
 	fakeCoords := make([]LatLonCoordinate, n)
 	flags := make([]bool, n)
@@ -377,21 +867,21 @@ func GenerateFakeCoordinates(coords []LatLonCoordinate, ratio float64, offsetDeg
 	fmt.Printf("设置 %d 个节点伪造坐标 (%.1f%%)\n", count, ratio*100)
 
 	for i := 0; i < count; i++ {
-		node := rand.Intn(n)
+		node := rng.Intn(n)
 		for flags[node] {
-			node = rand.Intn(n)
+			node = rng.Intn(n)
 		}
 		flags[node] = true
 
 		if offsetDegree > 0 {
 			// 基于真实坐标偏移
 			offset := offsetDegree
-			fakeCoords[node].Lat = coords[node].Lat + (rand.Float64()*2-1)*offset
-			fakeCoords[node].Lon = coords[node].Lon + (rand.Float64()*2-1)*offset
+			fakeCoords[node].Lat = coords[node].Lat + (rng.Float64()*2-1)*offset
+			fakeCoords[node].Lon = coords[node].Lon + (rng.Float64()*2-1)*offset
 		} else {
 			// 完全随机
-			fakeCoords[node].Lat = rand.Float64()*180 - 90
-			fakeCoords[node].Lon = rand.Float64()*360 - 180
+			fakeCoords[node].Lat = rng.Float64()*180 - 90
+			fakeCoords[node].Lon = rng.Float64()*360 - 180
 		}
 
 		// 确保坐标在有效范围内
@@ -0,0 +1,170 @@
+package handlware_test
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	hw "gomercator/handlware"
+	"gomercator/handlware/algorithms"
+)
+
+// syntheticCoords 生成固定的随机坐标（不依赖Geo.txt）
+func syntheticCoords(n int) []hw.LatLonCoordinate {
+	rng := rand.New(rand.NewSource(1))
+	coords := make([]hw.LatLonCoordinate, n)
+	for i := range coords {
+		coords[i] = hw.LatLonCoordinate{Lat: rng.Float64()*140 - 70, Lon: rng.Float64()*360 - 180}
+	}
+	return coords
+}
+
+// inTempDir 在临时目录中运行测试（Simulation会在当前目录写出success_edges.csv）
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// equalValues 递归比较两个值，NaN与NaN视为相等（未达到的百分位记为NaN）
+func equalValues(a, b reflect.Value) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		return x == y || (math.IsNaN(x) && math.IsNaN(y))
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValues(a.Elem(), b.Elem())
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && a.IsNil() != b.IsNil() {
+			return false
+		}
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValues(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equalValues(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+		for _, k := range a.MapKeys() {
+			if !equalValues(a.MapIndex(k), b.MapIndex(k)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+}
+
+// assertSameResult 逐字段比较两次模拟的结果
+func assertSameResult(t *testing.T, want, got *hw.TestResult) {
+	t.Helper()
+	w, g := reflect.ValueOf(want).Elem(), reflect.ValueOf(got).Elem()
+	for i := 0; i < w.NumField(); i++ {
+		if !equalValues(w.Field(i), g.Field(i)) {
+			t.Errorf("字段 %s 不一致", w.Type().Field(i).Name)
+		}
+	}
+}
+
+// TestSimulationSameSeed 同一种子运行两次Simulation得到完全相同的结果
+func TestSimulationSameSeed(t *testing.T) {
+	inTempDir(t)
+	coords := syntheticCoords(200)
+	n := len(coords)
+	attackConfig := hw.NewAttackConfig()
+	attackConfig.MaliciousRatio = 0.1
+
+	builders := map[string]func(ctx *hw.SimContext) hw.Algorithm{
+		"random": func(ctx *hw.SimContext) hw.Algorithm {
+			return algorithms.NewRandomFlood(n, coords, 0, 8, 8, ctx.Derive("random"))
+		},
+		"kadcast": func(ctx *hw.SimContext) hw.Algorithm {
+			return algorithms.NewKadcast(n, coords, hw.KBucketConfig{K: 8, Fanout: 6, NumBits: 128}, ctx.Derive("kadcast"))
+		},
+		"mercator": func(ctx *hw.SimContext) hw.Algorithm {
+			return algorithms.NewMercator(n, coords, coords, 0, 2, 6, 20, 3)
+		},
+	}
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			run := func() *hw.TestResult {
+				config := hw.NewSimulatorConfig()
+				config.Ctx = hw.NewSimContext(7)
+				return hw.Simulation(2, coords, attackConfig, build(config.Ctx), config, nil)
+			}
+			assertSameResult(t, run(), run())
+		})
+	}
+}
+
+// TestSimulationWorkers 多根节点并行模拟与串行模拟结果一致（用 go test -race 检查共享状态）
+// 覆盖上行/下行带宽、丢包、churn、拜占庭节点和共享的延迟下界缓存
+func TestSimulationWorkers(t *testing.T) {
+	inTempDir(t)
+	coords := syntheticCoords(200)
+	n := len(coords)
+	attackConfig := hw.NewAttackConfig()
+	attackConfig.MaliciousRatio = 0.05
+	attackConfig.ByzantineRatio = 0.05
+	attackConfig.ByzantineDelay = 20
+	attackConfig.ByzantineWithholdRatio = 0.3
+
+	builders := map[string]func(ctx *hw.SimContext) hw.Algorithm{
+		"random": func(ctx *hw.SimContext) hw.Algorithm {
+			return algorithms.NewRandomFlood(n, coords, 0, 8, 8, ctx.Derive("random"))
+		},
+		"kadcast": func(ctx *hw.SimContext) hw.Algorithm {
+			return algorithms.NewKadcast(n, coords, hw.KBucketConfig{K: 8, Fanout: 6, NumBits: 128}, ctx.Derive("kadcast"))
+		},
+		"mercator": func(ctx *hw.SimContext) hw.Algorithm {
+			return algorithms.NewMercator(n, coords, coords, 0, 2, 6, 20, 3)
+		},
+	}
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			oracle := hw.NewLatencyOracle()
+			run := func(workers int) *hw.TestResult {
+				config := hw.NewSimulatorConfig()
+				config.Ctx = hw.NewSimContext(11)
+				config.Workers = workers
+				config.DataSize = hw.DataSizeLarge
+				config.Uplink = hw.NewBandwidthModel(n, 100e6, 50e6, 2)
+				config.Loss = hw.NewUniformLoss(0.02)
+				config.Churn = hw.NewChurnConfig(0.1)
+				config.Oracle = oracle
+				return hw.Simulation(2, coords, attackConfig, build(config.Ctx), config, nil)
+			}
+			serial := run(1)
+			assertSameResult(t, serial, run(4))
+			assertSameResult(t, serial, run(4))
+		})
+	}
+}
@@ -8,27 +8,115 @@ import (
 // ==================== 延迟百分位计算 ====================
 
 // CalculatePercentiles 计算延迟的百分位数
-// recvTimes: 所有节点的接收时间（未覆盖的节点使用inf）
-// 返回: 21个百分位的延迟值 [5%, 10%, ..., 100%]
+// recvTimes: 所有节点的接收时间（未覆盖的节点使用math.Inf(1)）
+// 返回: NumPercentiles个百分位的延迟值 [5%, 10%, ..., 100%]
 func CalculatePercentiles(recvTimes []float64) []float64 {
 	// 复制一份用于排序
 	times := make([]float64, len(recvTimes))
 	copy(times, recvTimes)
 	sort.Float64s(times)
 
-	percentiles := make([]float64, 21)
-	cnt := 0
+	percentiles := make([]float64, NumPercentiles)
+	if len(times) == 0 {
+		return percentiles
+	}
+	for i := range percentiles {
+		percentiles[i] = times[percentileIndex(len(times), i)]
+	}
+	return percentiles
+}
 
-	for pct := 0.05; pct <= 1.0; pct += 0.05 {
-		idx := int(float64(len(times)) * pct)
-		if idx >= len(times) {
-			idx = len(times) - 1
-		}
-		percentiles[cnt] = times[idx]
-		cnt++
+// percentileIndex 获取count个已排序样本中第i个延迟百分位的下标
+func percentileIndex(count, i int) int {
+	idx := count * (i + 1) / NumPercentiles
+	if idx >= count {
+		idx = count - 1
 	}
+	return idx
+}
 
-	return percentiles
+// Percentile 计算样本的p分位数（p取值[0, 1]，最近秩法）
+// 空样本返回0
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// Mean 计算样本均值，空样本返回0
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Gini 计算非负数组的基尼系数（0为完全均匀，全部集中在一个元素时为1-1/n；空数组或总和为0时返回0）
+func Gini(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	// G = sum((2i - n - 1) * x_i) / (n * sum(x))，i从1开始
+	weighted, total := 0.0, 0.0
+	for i, v := range sorted {
+		weighted += float64(2*(i+1)-n-1) * v
+		total += v
+	}
+	if total <= 0 {
+		return 0
+	}
+	return weighted / (float64(n) * total)
+}
+
+// TopShare 计算最大的fraction比例元素（至少一个）占总和的比例（总和为0时返回0）
+func TopShare(values []float64, fraction float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	k := int(math.Ceil(fraction * float64(n)))
+	if k < 1 {
+		k = 1
+	}
+	if k > n {
+		k = n
+	}
+	top, total := 0.0, 0.0
+	for i, v := range sorted {
+		if i < k {
+			top += v
+		}
+		total += v
+	}
+	if total <= 0 {
+		return 0
+	}
+	return top / total
 }
 
 // ==================== 深度分布统计 ====================
@@ -138,10 +226,30 @@ func CalculateClusterStatistics(clusterResult *ClusterResult, depths []int, late
 // AccumulateResults 累加两个测试结果
 func AccumulateResults(dst, src *TestResult) {
 	dst.AvgBandwidth += src.AvgBandwidth
+	dst.AvgDropped += src.AvgDropped
+	dst.AvgOfflineDropped += src.AvgOfflineDropped
+	dst.Coverage += src.Coverage
 	dst.AvgLatency += src.AvgLatency
+	dst.AvgBytes += src.AvgBytes
+	dst.AvgBytesSaved += src.AvgBytesSaved
 
-	for i := 0; i < len(src.Latency); i++ {
-		dst.Latency[i] += src.Latency[i]
+	accumulateReached(dst.Latency, dst.LatencyReached, src.Latency, src.LatencyReached)
+	accumulateReached(dst.CoverageTime, dst.CoverageReached, src.CoverageTime, src.CoverageReached)
+	for i := range src.DeliveredLatency {
+		dst.DeliveredLatency[i] += src.DeliveredLatency[i]
+	}
+	if src.OracleLatency != nil {
+		if dst.OracleLatency == nil {
+			dst.OracleLatency = make([]float64, len(src.OracleLatency))
+			dst.BoundedOracleLatency = make([]float64, len(src.BoundedOracleLatency))
+		}
+		// 下界只在对应延迟百分位达到的广播中累加，与Latency使用相同的权重
+		for i := range src.OracleLatency {
+			if src.LatencyReached[i] > 0 {
+				dst.OracleLatency[i] += src.OracleLatency[i] * src.LatencyReached[i]
+				dst.BoundedOracleLatency[i] += src.BoundedOracleLatency[i] * src.LatencyReached[i]
+			}
+		}
 	}
 
 	for i := 0; i < MaxDepth; i++ {
@@ -153,35 +261,65 @@ func AccumulateResults(dst, src *TestResult) {
 		dst.ClusterAvgDepth[i] += src.ClusterAvgDepth[i]
 		dst.ClusterAvgLatency[i] += src.ClusterAvgLatency[i]
 	}
+
+	if src.ClusterEligible != nil && dst.ClusterEligible == nil {
+		dst.ClusterEligible = make([]float64, len(src.ClusterEligible))
+		dst.ClusterCovered = make([]float64, len(src.ClusterCovered))
+	}
+	for i := range src.ClusterEligible {
+		dst.ClusterEligible[i] += src.ClusterEligible[i]
+		dst.ClusterCovered[i] += src.ClusterCovered[i]
+	}
+
+	dst.Trees = append(dst.Trees, src.Trees...)
+	dst.Samples = append(dst.Samples, src.Samples...)
+	if src.Nodes != nil {
+		if dst.Nodes == nil {
+			dst.Nodes = NewNodeStats(len(src.Nodes.Broadcasts))
+		}
+		dst.Nodes.Merge(src.Nodes)
+	}
 }
 
 // AverageResults 对测试结果求平均
 func AverageResults(result *TestResult, count int) {
-	const inf = 1e8
-
 	if count == 0 {
 		return
 	}
 
 	fcount := float64(count)
 	result.AvgBandwidth /= fcount
+	result.AvgDropped /= fcount
+	result.AvgOfflineDropped /= fcount
+	result.Coverage /= fcount
 	result.AvgLatency /= fcount
-
-	// 延迟百分位需要特殊处理（剔除inf值）
-	for i := 0; i < len(result.Latency); i++ {
-		tmp := int(result.Latency[i] / inf)
-		result.Latency[i] -= float64(tmp) * inf
-		validCount := count - tmp
-
-		if validCount == 0 {
-			result.Latency[i] = 0
-		} else {
-			result.Latency[i] /= float64(validCount)
+	result.AvgBytes /= fcount
+	result.AvgBytesSaved /= fcount
+
+	// 延迟下界与Latency在同一组广播（百分位达到的广播）上平均，需在LatencyReached归一化之前计算
+	if result.OracleLatency != nil {
+		for i := range result.OracleLatency {
+			if result.LatencyReached[i] > 0 {
+				result.OracleLatency[i] /= result.LatencyReached[i]
+				result.BoundedOracleLatency[i] /= result.LatencyReached[i]
+			} else {
+				result.OracleLatency[i] = math.NaN()
+				result.BoundedOracleLatency[i] = math.NaN()
+			}
 		}
+	}
 
-		if result.Latency[i] < 0.1 {
-			result.Latency[i] = inf
-		}
+	// 延迟百分位和覆盖时间只在达到的广播中平均
+	averageReached(result.Latency, result.LatencyReached, count)
+	averageReached(result.CoverageTime, result.CoverageReached, count)
+	for i := range result.DeliveredLatency {
+		result.DeliveredLatency[i] /= fcount
+	}
+
+	// 延迟下界之比（按平均后的百分位计算）
+	if result.OracleLatency != nil {
+		result.LatencyRatio = oracleRatios(result.Latency, result.OracleLatency)
+		result.BoundedLatencyRatio = oracleRatios(result.Latency, result.BoundedOracleLatency)
 	}
 
 	// 深度CDF和平均距离
@@ -195,6 +333,33 @@ func AverageResults(result *TestResult, count int) {
 		result.ClusterAvgDepth[i] /= fcount
 		result.ClusterAvgLatency[i] /= fcount
 	}
+	for i := range result.ClusterEligible {
+		result.ClusterEligible[i] /= fcount
+		result.ClusterCovered[i] /= fcount
+	}
+}
+
+// accumulateReached 累加按达到比例加权的指标（延迟百分位、覆盖时间）
+// dst保存加权和与达到次数，src为已平均的结果（未达到的项不参与累加）
+func accumulateReached(dst, dstReached, src, srcReached []float64) {
+	for i := range src {
+		if srcReached[i] > 0 {
+			dst[i] += src[i] * srcReached[i]
+			dstReached[i] += srcReached[i]
+		}
+	}
+}
+
+// averageReached 将加权和除以达到次数得到平均值（从未达到时为NaN），达到次数除以count得到达到比例
+func averageReached(values, reached []float64, count int) {
+	for i := range values {
+		if reached[i] > 0 {
+			values[i] /= reached[i]
+		} else {
+			values[i] = math.NaN()
+		}
+		reached[i] /= float64(count)
+	}
 }
 
 // ==================== Perigee专用统计 ====================
@@ -0,0 +1,235 @@
+package handlware
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ==================== Sybil / Eclipse 攻击 ====================
+// Mercator、Kadcast、ETH 都按确定性的桶结构选择邻居：Mercator按声明坐标的Geohash分桶，
+// Kadcast/ETH按NodeID的XOR距离分桶。能够自由选择坐标或NodeID的攻击者可以批量创建
+// Sybil节点，使其落入受害者的各个桶中，从而占据受害者的邻居（Eclipse攻击）。
+//
+// SybilNetwork 在诚实节点之后追加Sybil节点（下标 n..n+Count-1），给出扩展后的
+// 真实坐标、声明坐标和NodeID，由调用者据此构建算法：
+//   - Mercator: NewMercator(net.Size(), net.RealCoords, net.DisplayCoords, ...)
+//   - Kadcast:  NewKadcastWithNodeIDs(net.Size(), net.RealCoords, net.NodeIDs, ...)
+//   - ETH:      NewETHWithNodeIDs(net.Size(), net.RealCoords, net.NodeIDs, ...)
+//
+// EclipseExperiment 度量受害者路由表被攻击者控制的比例，以及受害者收发的广播被审查的频率。
+// Sybil节点在模拟中作为恶意节点（不转发）。
+
+// SybilConfig Sybil攻击配置
+type SybilConfig struct {
+	Victim          int     // 受害者节点
+	Count           int     // Sybil节点数
+	GeoPrec         int     // 目标Mercator的Geohash精度（按坐标放置时使用）
+	NumBits         int     // 目标路由表的NodeID位数（按NodeID放置时使用）
+	Spread          float64 // Sybil真实坐标相对受害者的最大偏移（度），攻击者把主机部署在受害者附近
+	CensorThreshold float64 // 受害者发起的广播覆盖率低于该值视为被审查
+	Trials          int     // 每个方向（受害者发出 / 发往受害者）的广播次数
+}
+
+// NewSybilConfig 创建默认Sybil攻击配置
+// 参数:
+//   - victim: 受害者节点
+//   - count: Sybil节点数
+func NewSybilConfig(victim, count int) *SybilConfig {
+	return &SybilConfig{
+		Victim:          victim,
+		Count:           count,
+		GeoPrec:         2,
+		NumBits:         128,
+		Spread:          0.5,
+		CensorThreshold: 0.5,
+		Trials:          20,
+	}
+}
+
+// SybilNetwork 追加了Sybil节点的网络
+type SybilNetwork struct {
+	Config        *SybilConfig
+	HonestCount   int                // 诚实节点数（下标 0..HonestCount-1）
+	RealCoords    []LatLonCoordinate // 真实坐标（用于延迟计算）
+	DisplayCoords []LatLonCoordinate // 声明坐标（Sybil节点声明在受害者各桶对应的Geohash区域）
+	NodeIDs       []NodeID128        // NodeID（Sybil节点的ID落在受害者的各个k-bucket中）
+	SybilFlags    []bool             // 是否为Sybil节点
+}
+
+// NewSybilNetwork 在诚实节点之后追加Sybil节点
+// Sybil节点按轮转方式依次瞄准受害者的各个桶（从最远、诚实节点最多的桶开始）：
+//   - 声明坐标：与受害者的Geohash在该桶对应的位上首次不同（K0桶为相同Geohash）
+//   - NodeID：与受害者的XOR距离最高位为该桶号
+//
+// 参数:
+//   - coords: 诚实节点坐标数组
+//   - sc: Sybil攻击配置
+//   - rng: 随机流（诚实节点ID、Sybil的ID和坐标抖动）
+func NewSybilNetwork(coords []LatLonCoordinate, sc *SybilConfig, rng *rand.Rand) *SybilNetwork {
+	n := len(coords)
+	total := n + sc.Count
+	net := &SybilNetwork{
+		Config:        sc,
+		HonestCount:   n,
+		RealCoords:    make([]LatLonCoordinate, total),
+		DisplayCoords: make([]LatLonCoordinate, total),
+		NodeIDs:       make([]NodeID128, total),
+		SybilFlags:    make([]bool, total),
+	}
+	copy(net.RealCoords, coords)
+	copy(net.DisplayCoords, coords)
+	for i := 0; i < n; i++ {
+		net.NodeIDs[i] = GenerateRandomNodeIDWithRng(rng)
+	}
+
+	victim := coords[sc.Victim]
+	encoder := NewGeohashEncoder(sc.GeoPrec)
+	totalBits := sc.GeoPrec * GeoBitsPerChar
+	victimBin := ToBinary(encoder.Encode(victim.Lat, victim.Lon))
+
+	for s := 0; s < sc.Count; s++ {
+		u := n + s
+		net.SybilFlags[u] = true
+
+		// 真实坐标：受害者附近
+		net.RealCoords[u] = LatLonCoordinate{
+			Lat: Clamp(victim.Lat+(rng.Float64()*2-1)*sc.Spread, -90, 90),
+			Lon: FitInRing(victim.Lon + (rng.Float64()*2-1)*sc.Spread),
+		}
+
+		// 声明坐标：桶 totalBits..1 与K0桶轮转
+		bucket := totalBits - s%(totalBits+1)
+		if bucket == 0 {
+			net.DisplayCoords[u] = victim
+		} else {
+			diffPos := totalBits - bucket
+			bin := []byte(victimBin)
+			bin[diffPos] ^= 1 // '0' <-> '1'
+			for b := diffPos + 1; b < totalBits; b++ {
+				bin[b] = byte('0' + rng.Intn(2))
+			}
+			lat, lon := encoder.Decode(BinaryToGeohash(string(bin)))
+			net.DisplayCoords[u] = LatLonCoordinate{Lat: lat, Lon: lon}
+		}
+
+		// NodeID：桶 NumBits-1..0 轮转
+		net.NodeIDs[u] = NodeIDInBucket(net.NodeIDs[sc.Victim], sc.NumBits-1-s%sc.NumBits, rng)
+	}
+
+	fmt.Printf("Sybil网络: 诚实节点 %d, Sybil节点 %d, 受害者 %d\n", n, sc.Count, sc.Victim)
+	return net
+}
+
+// Size 获取扩展后的节点总数
+func (net *SybilNetwork) Size() int {
+	return len(net.RealCoords)
+}
+
+// ==================== Eclipse 度量 ====================
+
+// EclipseResult 一次Eclipse攻击实验的结果
+type EclipseResult struct {
+	AlgoName        string  // 算法名称
+	Victim          int     // 受害者节点
+	Sybils          int     // Sybil节点数
+	VictimPeers     int     // 受害者路由表中的邻居数
+	OutboundControl float64 // 受害者路由表中Sybil节点的比例
+	InboundControl  float64 // 路由表包含受害者的节点中Sybil节点的比例
+	OutCoverage     float64 // 受害者发起的广播的平均覆盖率（诚实节点）
+	OutCensored     float64 // 受害者发起的广播被审查的比例（覆盖率低于CensorThreshold）
+	InCensored      float64 // 其他诚实节点发起的广播未到达受害者的比例
+}
+
+// EclipseExperiment 在Sybil网络上度量Eclipse攻击的效果
+// 路由表邻居通过JoiningAlgorithm.JoinPeers获取（未实现时控制比例记为0）
+// 参数:
+//   - net: Sybil网络
+//   - algo: 在net上构建的广播算法
+//   - config: 模拟器配置（延迟模型需覆盖扩展后的全部节点，nil Latency使用地理模型）
+//
+// 返回: 实验结果
+func EclipseExperiment(net *SybilNetwork, algo Algorithm, config *SimulatorConfig) *EclipseResult {
+	sc := net.Config
+	victim := sc.Victim
+	total := net.Size()
+	result := &EclipseResult{
+		AlgoName: algo.GetAlgoName(),
+		Victim:   victim,
+		Sybils:   sc.Count,
+	}
+
+	fmt.Printf("Eclipse实验: %s, 受害者 %d, Sybil节点 %d\n", result.AlgoName, victim, sc.Count)
+
+	// 1) 路由表控制比例
+	if ja, ok := algo.(JoiningAlgorithm); ok {
+		peers := DedupIntsStable(append([]int(nil), ja.JoinPeers(victim)...))
+		result.VictimPeers = len(peers)
+		result.OutboundControl = sybilShare(peers, net.SybilFlags)
+
+		inbound := make([]int, 0)
+		for u := 0; u < total; u++ {
+			if u != victim && Contains(ja.JoinPeers(u), victim) {
+				inbound = append(inbound, u)
+			}
+		}
+		result.InboundControl = sybilShare(inbound, net.SybilFlags)
+	} else {
+		fmt.Printf("警告: 算法 %s 未提供路由表邻居，控制比例记为0\n", result.AlgoName)
+	}
+
+	// 2) 广播审查：受害者单独作为区域1，统计其是否收到消息
+	labels := make([]int, total)
+	labels[victim] = 1
+	regions := RegionClusters(labels)
+	leaveFlags := make([]bool, total)
+
+	ctx := config.GetContext()
+	rootRng := ctx.Derive("eclipse/roots")
+	outCensored, inCensored := 0, 0
+	for t := 0; t < sc.Trials; t++ {
+		// 受害者发出
+		outConfig := *config
+		outConfig.Ctx = ctx.Sub(fmt.Sprintf("eclipse/out/%d", t))
+		res := SingleRootSimulation(victim, 1, net.RealCoords, net.SybilFlags, leaveFlags, nil, algo, &outConfig, nil)
+		result.OutCoverage += res.Coverage
+		if res.Coverage < sc.CensorThreshold {
+			outCensored++
+		}
+
+		// 发往受害者：随机选择其他诚实节点作为根节点
+		root := rootRng.Intn(net.HonestCount)
+		for root == victim {
+			root = rootRng.Intn(net.HonestCount)
+		}
+		inConfig := *config
+		inConfig.Ctx = ctx.Sub(fmt.Sprintf("eclipse/in/%d", t))
+		res = SingleRootSimulation(root, 1, net.RealCoords, net.SybilFlags, leaveFlags, nil, algo, &inConfig, regions)
+		if res.ClusterCovered[1] == 0 {
+			inCensored++
+		}
+	}
+
+	if sc.Trials > 0 {
+		result.OutCoverage /= float64(sc.Trials)
+		result.OutCensored = float64(outCensored) / float64(sc.Trials)
+		result.InCensored = float64(inCensored) / float64(sc.Trials)
+	}
+
+	fmt.Printf("  路由表控制: 出 %.2f%% / 入 %.2f%%, 审查: 发出 %.2f%% / 收到 %.2f%%\n",
+		result.OutboundControl*100, result.InboundControl*100, result.OutCensored*100, result.InCensored*100)
+	return result
+}
+
+// sybilShare 计算节点列表中Sybil节点的比例
+func sybilShare(nodes []int, sybilFlags []bool) float64 {
+	if len(nodes) == 0 {
+		return 0
+	}
+	count := 0
+	for _, u := range nodes {
+		if sybilFlags[u] {
+			count++
+		}
+	}
+	return float64(count) / float64(len(nodes))
+}
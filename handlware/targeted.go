@@ -0,0 +1,266 @@
+package handlware
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// ==================== 针对性攻击 ====================
+// GenerateMaliciousNodes 均匀随机选择节点，低估了依赖中心节点的设计
+// （如MercatorMercury的Hub、BlockP2P的簇入口点）面临的风险。
+// 针对性攻击按策略对节点排序，移除排名靠前的节点（通过AttackConfig.FailedNodes注入）：
+//   - 入度 / 出度：基于算法的路由表（JoiningAlgorithm.JoinPeers）
+//   - 介数中心性：路由表有向图上的（采样近似）介数
+//   - Hub：算法给出的中心节点（HubAlgorithm）
+//   - K0代表：算法给出的组内代表节点（RepresentativeAlgorithm）
+// 策略给出的节点不足时，剩余名额按随机顺序补齐。
+
+// TargetStrategy 攻击者选择目标的策略
+type TargetStrategy int
+
+const (
+	TargetRandom           TargetStrategy = iota // 均匀随机
+	TargetInDegree                               // 入度从高到低
+	TargetOutDegree                              // 出度从高到低
+	TargetBetweenness                            // 介数中心性从高到低
+	TargetHub                                    // Hub节点优先
+	TargetK0Representative                       // K0代表节点优先
+)
+
+// BetweennessSamples 近似介数中心性时采样的源节点数
+const BetweennessSamples = 256
+
+// String 获取策略名称
+func (s TargetStrategy) String() string {
+	switch s {
+	case TargetRandom:
+		return "random"
+	case TargetInDegree:
+		return "in_degree"
+	case TargetOutDegree:
+		return "out_degree"
+	case TargetBetweenness:
+		return "betweenness"
+	case TargetHub:
+		return "hub"
+	default:
+		return "k0_representative"
+	}
+}
+
+// RankTargets 按策略对节点排序（越靠前越优先被移除）
+// 参数:
+//   - algo: 广播算法（提供路由表、Hub或代表节点）
+//   - n: 节点数
+//   - strategy: 目标选择策略
+//   - rng: 随机流（随机策略、补齐和介数采样）
+//
+// 返回: 全部n个节点的移除顺序
+func RankTargets(algo Algorithm, n int, strategy TargetStrategy, rng *rand.Rand) []int {
+	var ranked []int
+
+	switch strategy {
+	case TargetInDegree, TargetOutDegree, TargetBetweenness:
+		ja, ok := algo.(JoiningAlgorithm)
+		if !ok {
+			fmt.Printf("警告: 算法 %s 未提供路由表，策略 %s 退化为随机\n", algo.GetAlgoName(), strategy)
+			break
+		}
+		adj := make([][]int, n)
+		for u := 0; u < n; u++ {
+			adj[u] = ja.JoinPeers(u)
+		}
+
+		score := make([]float64, n)
+		switch strategy {
+		case TargetInDegree:
+			for u := 0; u < n; u++ {
+				for _, v := range adj[u] {
+					score[v]++
+				}
+			}
+		case TargetOutDegree:
+			for u := 0; u < n; u++ {
+				score[u] = float64(len(adj[u]))
+			}
+		default:
+			score = BetweennessCentrality(adj, BetweennessSamples, rng)
+		}
+
+		ranked = make([]int, n)
+		for i := range ranked {
+			ranked[i] = i
+		}
+		sort.SliceStable(ranked, func(a, b int) bool {
+			return score[ranked[a]] > score[ranked[b]]
+		})
+	case TargetHub:
+		if ha, ok := algo.(HubAlgorithm); ok {
+			ranked = ha.HubNodes()
+		} else {
+			fmt.Printf("警告: 算法 %s 没有Hub节点，策略 %s 退化为随机\n", algo.GetAlgoName(), strategy)
+		}
+	case TargetK0Representative:
+		if ra, ok := algo.(RepresentativeAlgorithm); ok {
+			ranked = ra.K0Representatives()
+		} else {
+			fmt.Printf("警告: 算法 %s 没有K0代表节点，策略 %s 退化为随机\n", algo.GetAlgoName(), strategy)
+		}
+	}
+
+	// 剩余节点按随机顺序补齐
+	inRank := make([]bool, n)
+	for _, u := range ranked {
+		inRank[u] = true
+	}
+	for _, u := range rng.Perm(n) {
+		if !inRank[u] {
+			ranked = append(ranked, u)
+		}
+	}
+	return ranked
+}
+
+// TargetedAttackNodes 移除排名前fraction的节点
+// 参数:
+//   - ranked: RankTargets给出的移除顺序
+//   - fraction: 移除比例
+//
+// 返回: 被移除节点标记（用于AttackConfig.FailedNodes）
+func TargetedAttackNodes(ranked []int, fraction float64) []bool {
+	flags := make([]bool, len(ranked))
+	count := int(float64(len(ranked)) * fraction)
+	for i := 0; i < count && i < len(ranked); i++ {
+		flags[ranked[i]] = true
+	}
+	return flags
+}
+
+// BetweennessCentrality 计算有向无权图的介数中心性（Brandes算法）
+// 参数:
+//   - adj: 邻接表（出边）
+//   - samples: 采样的源节点数（<=0或不小于节点数时精确计算）
+//   - rng: 随机流（选择采样源节点）
+//
+// 返回: 每个节点的介数（采样时按 n/samples 放缩）
+func BetweennessCentrality(adj [][]int, samples int, rng *rand.Rand) []float64 {
+	n := len(adj)
+	bc := make([]float64, n)
+
+	sources := rng.Perm(n)
+	if samples > 0 && samples < n {
+		sources = sources[:samples]
+	}
+
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	stack := make([]int, 0, n)
+	queue := make([]int, 0, n)
+
+	for _, s := range sources {
+		for i := 0; i < n; i++ {
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+			preds[i] = preds[i][:0]
+		}
+		stack = stack[:0]
+		queue = append(queue[:0], s)
+		sigma[s] = 1
+		dist[s] = 0
+
+		// BFS计算最短路径数
+		for head := 0; head < len(queue); head++ {
+			u := queue[head]
+			stack = append(stack, u)
+			for _, v := range adj[u] {
+				if dist[v] < 0 {
+					dist[v] = dist[u] + 1
+					queue = append(queue, v)
+				}
+				if dist[v] == dist[u]+1 {
+					sigma[v] += sigma[u]
+					preds[v] = append(preds[v], u)
+				}
+			}
+		}
+
+		// 逆序累积依赖
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, u := range preds[w] {
+				delta[u] += sigma[u] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				bc[w] += delta[w]
+			}
+		}
+	}
+
+	scale := float64(n) / float64(len(sources))
+	for i := range bc {
+		bc[i] *= scale
+	}
+	return bc
+}
+
+// ==================== 针对性攻击扫描 ====================
+
+// TargetedSweepResult 一个策略在一个移除比例下的结果
+type TargetedSweepResult struct {
+	AlgoName string         // 算法名称
+	Strategy TargetStrategy // 目标选择策略
+	Fraction float64        // 移除比例
+	Removed  int            // 移除节点数
+	Result   *TestResult    // 模拟结果
+}
+
+// TargetedAttackSweep 在不同移除比例下比较各攻击策略，得到覆盖率随移除比例变化的曲线
+// 参数:
+//   - reptTime: 每个比例的重复次数
+//   - coords: 节点坐标数组
+//   - attackConfig: 攻击配置（FailedNodes由扫描覆盖）
+//   - algo: 广播算法
+//   - config: 模拟器配置
+//   - strategies: 参与比较的策略
+//   - fractions: 移除比例列表
+//
+// 返回: 每个(策略, 比例)的结果
+func TargetedAttackSweep(
+	reptTime int,
+	coords []LatLonCoordinate,
+	attackConfig *AttackConfig,
+	algo Algorithm,
+	config *SimulatorConfig,
+	strategies []TargetStrategy,
+	fractions []float64,
+) []*TargetedSweepResult {
+
+	n := len(coords)
+	results := make([]*TargetedSweepResult, 0, len(strategies)*len(fractions))
+	for _, strategy := range strategies {
+		// 排序只依赖拓扑，每个策略计算一次
+		ranked := RankTargets(algo, n, strategy, config.GetContext().Derive("targeted/"+strategy.String()))
+
+		for _, fraction := range fractions {
+			fmt.Printf("针对性攻击扫描: %s, 策略 %s, 移除比例 %.2f\n", algo.GetAlgoName(), strategy, fraction)
+			failed := TargetedAttackNodes(ranked, fraction)
+			fractionAttack := *attackConfig
+			fractionAttack.FailedNodes = failed
+
+			result := Simulation(reptTime, coords, &fractionAttack, algo, config, nil)
+			results = append(results, &TargetedSweepResult{
+				AlgoName: algo.GetAlgoName(),
+				Strategy: strategy,
+				Fraction: fraction,
+				Removed:  CountFlags(failed),
+				Result:   result,
+			})
+			fmt.Printf("  覆盖率 %.2f%%, 平均延迟 %.2f ms\n", result.Coverage*100, result.AvgLatency)
+		}
+	}
+	return results
+}
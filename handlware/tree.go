@@ -0,0 +1,195 @@
+package handlware
+
+import (
+	"sort"
+)
+
+// ==================== 广播树 ====================
+// SingleRootSimulation 在每次广播中记录每个节点首次收到完整消息的来源（父节点），
+// 这些边构成以根节点为根的广播树。BroadcastTree 保存一次广播的完整树，
+// 由TestResult.Trees按(重复, 根节点)收集，AnalyzeTree 计算树的结构指标。
+
+// BroadcastTree 一次广播的传播树
+type BroadcastTree struct {
+	Run        int       // 所属重复实验编号（Simulation的rept）
+	Index      int       // 根节点在本次重复中的序号
+	Root       int       // 根节点
+	Parent     []int     // 父节点（根节点和未收到的节点为-1）
+	RecvTime   []float64 // 接收时刻（ms，未收到为-1）
+	HopLatency []float64 // 最后一跳延迟（父节点发出到本节点收到，ms）
+	Depth      []int     // 跳数（未收到为-1）
+}
+
+// newBroadcastTree 由一次广播的接收状态构建传播树（复制数组，不受后续统计修改影响）
+func newBroadcastTree(root int, recvFlag []bool, recvParent []int, recvTime, recvDist []float64, depth []int) *BroadcastTree {
+	n := len(recvFlag)
+	tree := &BroadcastTree{
+		Root:       root,
+		Parent:     make([]int, n),
+		RecvTime:   make([]float64, n),
+		HopLatency: make([]float64, n),
+		Depth:      make([]int, n),
+	}
+	for i := 0; i < n; i++ {
+		if !recvFlag[i] {
+			tree.Parent[i] = -1
+			tree.RecvTime[i] = -1
+			tree.Depth[i] = -1
+			continue
+		}
+		tree.Parent[i] = recvParent[i]
+		if i == root {
+			tree.Parent[i] = -1
+		}
+		tree.RecvTime[i] = recvTime[i]
+		tree.HopLatency[i] = recvDist[i]
+		tree.Depth[i] = depth[i]
+	}
+	return tree
+}
+
+// Received 判断节点是否收到消息
+func (t *BroadcastTree) Received(u int) bool {
+	return t.Depth[u] >= 0
+}
+
+// Children 获取每个节点在树中的子节点
+func (t *BroadcastTree) Children() [][]int {
+	children := make([][]int, len(t.Parent))
+	for v, p := range t.Parent {
+		if p >= 0 {
+			children[p] = append(children[p], v)
+		}
+	}
+	return children
+}
+
+// PathTo 获取根节点到u的树路径（u未收到时返回nil）
+func (t *BroadcastTree) PathTo(u int) []int {
+	if !t.Received(u) {
+		return nil
+	}
+	path := make([]int, 0, t.Depth[u]+1)
+	for v := u; v >= 0; v = t.Parent[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// ==================== 树指标 ====================
+
+// TreeMetrics 一棵广播树的结构指标
+type TreeMetrics struct {
+	Run      int // 所属重复实验编号
+	Index    int // 根节点序号
+	Root     int // 根节点
+	Received int // 收到消息的节点数（含根节点）
+	MaxDepth int // 最大跳数
+
+	EdgeStretch []float64 // 每个节点最后一跳的伸展（HopLatency / 父节点到本节点的链路延迟，根节点、未收到或链路延迟为0时为0）
+	SubtreeSize []int     // 每个节点的子树大小（含自身，未收到为0）
+	Fanout      []int     // 每个节点在树中的子节点数
+
+	AvgEdgeStretch    float64 // 平均边伸展
+	MedianEdgeStretch float64 // 边伸展中位数
+	P90EdgeStretch    float64 // 边伸展90分位
+	FanoutHist        []int   // 出度分布：FanoutHist[k] = 树中恰有k个子节点的已接收节点数
+	AvgFanout         float64 // 非叶节点的平均子节点数
+	MaxSubtree        int     // 根节点之外最大的子树
+
+	LongestPath    []int   // 接收时刻最晚节点的树路径（根节点 -> 该节点）
+	LongestLatency float64 // 该路径的总延迟（即最晚的接收时刻，ms）
+}
+
+// AnalyzeTree 计算广播树的结构指标
+// 边伸展只比较每条树边的最后一跳延迟与该边的链路延迟，大于1的部分来自传输延迟和上行链路排队；
+// 接收时刻还包含每一跳的处理延迟，因此不与根节点直连延迟相比，整条路径与最优的差距见LatencyOracle的下界。
+// 参数:
+//   - tree: 广播树
+//   - latency: 延迟模型（计算边伸展时的链路延迟，应与模拟使用的模型一致）
+//
+// 返回: 树指标
+func AnalyzeTree(tree *BroadcastTree, latency LatencyModel) *TreeMetrics {
+	n := len(tree.Parent)
+	m := &TreeMetrics{
+		Run:         tree.Run,
+		Index:       tree.Index,
+		Root:        tree.Root,
+		EdgeStretch: make([]float64, n),
+		SubtreeSize: make([]int, n),
+		Fanout:      make([]int, n),
+	}
+
+	received := make([]int, 0, n)
+	for u := 0; u < n; u++ {
+		if tree.Received(u) {
+			received = append(received, u)
+		}
+	}
+	m.Received = len(received)
+
+	// 按深度从深到浅累加子树大小
+	sort.Slice(received, func(a, b int) bool {
+		return tree.Depth[received[a]] > tree.Depth[received[b]]
+	})
+	stretches := make([]float64, 0, len(received))
+	latest := tree.Root
+	for _, u := range received {
+		m.SubtreeSize[u]++
+		if p := tree.Parent[u]; p >= 0 {
+			m.SubtreeSize[p] += m.SubtreeSize[u]
+			m.Fanout[p]++
+		}
+		if tree.Depth[u] > m.MaxDepth {
+			m.MaxDepth = tree.Depth[u]
+		}
+		if tree.RecvTime[u] > tree.RecvTime[latest] {
+			latest = u
+		}
+		if p := tree.Parent[u]; p >= 0 {
+			if link := latency.Delay(p, u); link > 1e-6 {
+				m.EdgeStretch[u] = tree.HopLatency[u] / link
+				stretches = append(stretches, m.EdgeStretch[u])
+			}
+		}
+	}
+
+	m.AvgEdgeStretch = Mean(stretches)
+	m.MedianEdgeStretch = Percentile(stretches, 0.5)
+	m.P90EdgeStretch = Percentile(stretches, 0.9)
+
+	internal, children := 0, 0
+	for _, u := range received {
+		k := m.Fanout[u]
+		for len(m.FanoutHist) <= k {
+			m.FanoutHist = append(m.FanoutHist, 0)
+		}
+		m.FanoutHist[k]++
+		if k > 0 {
+			internal++
+			children += k
+		}
+		if u != tree.Root && m.SubtreeSize[u] > m.MaxSubtree {
+			m.MaxSubtree = m.SubtreeSize[u]
+		}
+	}
+	if internal > 0 {
+		m.AvgFanout = float64(children) / float64(internal)
+	}
+
+	m.LongestPath = tree.PathTo(latest)
+	m.LongestLatency = tree.RecvTime[latest]
+	return m
+}
+
+// AnalyzeTrees 计算一组广播树的结构指标
+func AnalyzeTrees(trees []*BroadcastTree, latency LatencyModel) []*TreeMetrics {
+	metrics := make([]*TreeMetrics, len(trees))
+	for i, tree := range trees {
+		metrics[i] = AnalyzeTree(tree, latency)
+	}
+	return metrics
+}
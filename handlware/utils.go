@@ -92,26 +92,6 @@ func AngleCheck(r, u, v LatLonCoordinate) bool {
 	return crossProduct > -1e-3
 }
 
-// ==================== 随机数相关 ====================
-
-// RandomNum 生成[0, n)范围内的随机整数
-func RandomNum(n int) int {
-	if n <= 0 {
-		return 0
-	}
-	return rand.Intn(n)
-}
-
-// RandomBetween01 生成[0, 1)范围内的随机浮点数
-func RandomBetween01() float64 {
-	return rand.Float64()
-}
-
-// RandomNormal 生成正态分布的随机数（均值mean，标准差std）
-func RandomNormal(mean, std float64) float64 {
-	return rand.NormFloat64()*std + mean
-}
-
 // ==================== 数组和切片工具 ====================
 
 // Contains 检查切片中是否包含目标元素
@@ -195,15 +175,16 @@ func CalculatePropagationDelay(u, v int, coords []LatLonCoordinate, bandwidth, d
 	return distDelay + dataDelay
 }
 
-// CalculateProcessingDelay 计算节点处理延迟（固定延迟 + 随机高斯噪声）
-func CalculateProcessingDelay() float64 {
-	// 固定延迟250ms，模拟时拆分为: 200ms + Gaussian(50, 10)
-	base := FixedDelay - 50.0
-	noise := RandomNormal(50.0, 10.0)
+// MinProcessingDelay 节点处理延迟的最小值（ms），即噪声取下限0时的处理延迟
+const MinProcessingDelay = FixedDelay - 50.0
 
-	// 限制噪声在[0, 100]范围内
+// CalculateProcessingDelayWithRng 使用给定随机流计算节点处理延迟（固定延迟 + 随机高斯噪声）
+// 固定延迟250ms，模拟时拆分为: 200ms + Gaussian(50, 10)，噪声限制在[0, 100]范围内
+// 所有随机性来自调用方的随机流，同一种子逐位可重复
+func CalculateProcessingDelayWithRng(rng *rand.Rand) float64 {
+	base := MinProcessingDelay
+	noise := rng.NormFloat64()*10.0 + 50.0
 	noise = Clamp(noise, 0.0, 100.0)
-
 	return base + noise
 }
 
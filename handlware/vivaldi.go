@@ -95,6 +95,17 @@ func Observe(vm *VivaldiModel, peerID int, peerCoord *VivaldiCoordinate, rtt flo
 //
 // 返回: Vivaldi模型数组
 func GenerateVirtualCoordinate(coords []LatLonCoordinate, rounds int, dim int) []*VivaldiModel {
+	return GenerateVirtualCoordinateWithRTT(coords, rounds, dim, NewGeoRTTModel(coords), nil)
+}
+
+// GenerateVirtualCoordinateWithRTT 使用指定RTT模型生成Vivaldi虚拟坐标
+// rttModel 返回节点对之间的往返时延（如实测RTT矩阵，nil表示地理RTT模型）
+// rng 为坐标初始化和邻居选择使用的随机流（nil表示以DefaultSeed初始化）
+func GenerateVirtualCoordinateWithRTT(coords []LatLonCoordinate, rounds int, dim int, rttModel LatencyModel, rng *rand.Rand) []*VivaldiModel {
+	if rttModel == nil {
+		rttModel = NewGeoRTTModel(coords)
+	}
+	rng = NewRngOrDefault(rng, DefaultSeed)
 	n := len(coords)
 	models := make([]*VivaldiModel, n)
 
@@ -105,9 +116,9 @@ func GenerateVirtualCoordinate(coords []LatLonCoordinate, rounds int, dim int) [
 
 		// 初始化随机坐标
 		for d := 0; d < dim; d++ {
-			models[i].LocalCoord.Vector[d] = RandomBetween01() * 1000
+			models[i].LocalCoord.Vector[d] = rng.Float64() * 1000
 		}
-		models[i].LocalCoord.Height = RandomBetween01() * 100
+		models[i].LocalCoord.Height = rng.Float64() * 100
 	}
 
 	fmt.Printf("开始生成虚拟坐标（%d轮，%d维）...\n", rounds, dim)
@@ -127,9 +138,9 @@ func GenerateVirtualCoordinate(coords []LatLonCoordinate, rounds int, dim int) [
 			} else {
 				selectedNeighbors = make([]int, 0, VivaldiPeerSetSize)
 				for j := 0; j < VivaldiPeerSetSize; j++ {
-					y := rand.Intn(n)
+					y := rng.Intn(n)
 					for y == x {
-						y = rand.Intn(n)
+						y = rng.Intn(n)
 					}
 					selectedNeighbors = append(selectedNeighbors, y)
 				}
@@ -137,8 +148,8 @@ func GenerateVirtualCoordinate(coords []LatLonCoordinate, rounds int, dim int) [
 
 			// 对每个邻居进行观测和更新
 			for _, y := range selectedNeighbors {
-				// 计算真实RTT（基于地理距离）
-				rtt := Distance(coords[x], coords[y]) + FixedDelay
+				// 计算真实RTT（由RTT模型给出）
+				rtt := rttModel.Delay(x, y)
 
 				// 观测并更新坐标
 				Observe(models[x], y, models[y].LocalCoord, rtt)
@@ -176,8 +187,8 @@ func GenerateVirtualCoordinate(coords []LatLonCoordinate, rounds int, dim int) [
 // ==================== 坐标质量评估 ====================
 
 // EvaluateCoordinateQuality 评估虚拟坐标的质量
-// 通过比较预测距离和真实距离的相关性
-func EvaluateCoordinateQuality(models []*VivaldiModel, coords []LatLonCoordinate, sampleSize int) {
+// 通过比较预测距离和真实距离的相关性，rng用于采样节点对
+func EvaluateCoordinateQuality(models []*VivaldiModel, coords []LatLonCoordinate, sampleSize int, rng *rand.Rand) {
 	n := len(models)
 	if sampleSize > n*n {
 		sampleSize = n * n
@@ -190,8 +201,8 @@ func EvaluateCoordinateQuality(models []*VivaldiModel, coords []LatLonCoordinate
 	errorDistribution := make([]int, 10) // 0-10%, 10-20%, ..., 90-100%
 
 	for sample := 0; sample < sampleSize; sample++ {
-		i := rand.Intn(n)
-		j := rand.Intn(n)
+		i := rng.Intn(n)
+		j := rng.Intn(n)
 		if i == j {
 			continue
 		}
@@ -233,16 +244,16 @@ func EvaluateCoordinateQuality(models []*VivaldiModel, coords []LatLonCoordinate
 // ==================== 辅助函数 ====================
 
 // BuildPeerSet 为每个节点构建邻居集合
-// 用于加速Vivaldi收敛
-func BuildPeerSet(models []*VivaldiModel, peerSetSize int) {
+// 用于加速Vivaldi收敛，rng用于随机选择邻居
+func BuildPeerSet(models []*VivaldiModel, peerSetSize int, rng *rand.Rand) {
 	n := len(models)
 
 	for i := 0; i < n; i++ {
 		models[i].RandomPeerSet = make([]int, peerSetSize)
 		for j := 0; j < peerSetSize; j++ {
-			peer := rand.Intn(n)
+			peer := rng.Intn(n)
 			for peer == i {
-				peer = rand.Intn(n)
+				peer = rng.Intn(n)
 			}
 			models[i].RandomPeerSet[j] = peer
 		}
@@ -0,0 +1,579 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ==================== Vivaldi 坐标攻击 ====================
+// Observe / ObserveImproved / ObservePlusPlus 都直接采信邻居上报的坐标和误差。
+// 攻击节点照常训练自己的坐标，但对外上报篡改后的坐标，并声称很小的误差（ClaimedError），
+// 使诚实节点以最大权重向其靠拢：
+//   - 坐标膨胀（Inflation）：在真实坐标上叠加固定的大偏移
+//   - 坐标震荡（Oscillation）：偏移方向逐轮翻转
+//   - 温水煮青蛙（FrogBoiling）：所有攻击节点沿同一方向缓慢漂移，单次观测的残差始终很小
+//
+// 防御以VivaldiDefense的形式插入训练循环，在观测被采信前判定是否丢弃：
+//   - OutlierDefense: 基于观测历史的离群检测（残差的中位数+MAD，以及邻居坐标的单次位移）
+//   - KalmanDefense:  对本节点相对误差序列运行卡尔曼滤波，丢弃新息超出置信区间的观测
+
+// VivaldiAttackType Vivaldi攻击类型
+type VivaldiAttackType int
+
+const (
+	VivaldiAttackNone        VivaldiAttackType = iota // 无攻击
+	VivaldiAttackInflation                            // 坐标膨胀
+	VivaldiAttackOscillation                          // 坐标震荡
+	VivaldiAttackFrogBoiling                          // 温水煮青蛙（缓慢漂移）
+)
+
+// String 获取攻击类型名称
+func (t VivaldiAttackType) String() string {
+	switch t {
+	case VivaldiAttackInflation:
+		return "inflation"
+	case VivaldiAttackOscillation:
+		return "oscillation"
+	case VivaldiAttackFrogBoiling:
+		return "frog_boiling"
+	default:
+		return "none"
+	}
+}
+
+// VivaldiAdversary Vivaldi攻击节点及其上报策略
+type VivaldiAdversary struct {
+	Type         VivaldiAttackType // 攻击类型
+	Flags        []bool            // 是否为攻击节点
+	ClaimedError float64           // 上报的误差
+	Magnitude    float64           // 膨胀/震荡的偏移量（ms）
+	DriftRate    float64           // 温水煮青蛙每轮的漂移量（ms）
+	directions   [][]float64       // 每个攻击节点的偏移方向（单位向量，温水煮青蛙共用一个方向）
+}
+
+// NewVivaldiAdversary 创建Vivaldi攻击节点
+// 参数:
+//   - n: 节点数
+//   - dim: 坐标维度
+//   - attack: 攻击类型
+//   - ratio: 攻击节点比例
+//   - rng: 随机流（选择攻击节点和偏移方向）
+func NewVivaldiAdversary(n, dim int, attack VivaldiAttackType, ratio float64, rng *rand.Rand) *VivaldiAdversary {
+	adv := &VivaldiAdversary{
+		Type:         attack,
+		Flags:        make([]bool, n),
+		ClaimedError: VivaldiMinError,
+		Magnitude:    1000.0,
+		DriftRate:    20.0,
+		directions:   make([][]float64, n),
+	}
+	if attack == VivaldiAttackNone {
+		return adv
+	}
+
+	adv.Flags = GenerateMaliciousNodes(n, ratio, rng)
+	shared := randomUnitVector(dim, rng)
+	for u := 0; u < n; u++ {
+		if !adv.Flags[u] {
+			continue
+		}
+		if attack == VivaldiAttackFrogBoiling {
+			adv.directions[u] = shared
+		} else {
+			adv.directions[u] = randomUnitVector(dim, rng)
+		}
+	}
+	return adv
+}
+
+// Report 节点u在第round轮对外上报的坐标（诚实节点返回当前坐标的副本，不随本轮后续的更新变化）
+func (adv *VivaldiAdversary) Report(u, round int, coord *VivaldiCoordinate) *VivaldiCoordinate {
+	if adv == nil || adv.Type == VivaldiAttackNone || !adv.Flags[u] {
+		return copyCoordinate(coord)
+	}
+
+	offset := 0.0
+	switch adv.Type {
+	case VivaldiAttackInflation:
+		offset = adv.Magnitude
+	case VivaldiAttackOscillation:
+		offset = adv.Magnitude
+		if round%2 == 1 {
+			offset = -offset
+		}
+	case VivaldiAttackFrogBoiling:
+		offset = adv.DriftRate * float64(round)
+	}
+
+	reported := &VivaldiCoordinate{
+		Vector: make([]float64, len(coord.Vector)),
+		Height: coord.Height,
+		Error:  adv.ClaimedError,
+	}
+	for d := range coord.Vector {
+		reported.Vector[d] = coord.Vector[d] + offset*adv.directions[u][d]
+	}
+	return reported
+}
+
+// randomUnitVector 生成随机单位向量
+func randomUnitVector(dim int, rng *rand.Rand) []float64 {
+	v := make([]float64, dim)
+	norm := 0.0
+	for d := range v {
+		v[d] = rng.NormFloat64()
+		norm += v[d] * v[d]
+	}
+	norm = math.Sqrt(norm)
+	for d := range v {
+		v[d] /= norm
+	}
+	return v
+}
+
+// ==================== 防御 ====================
+
+// VivaldiDefense 观测过滤器：在节点采信邻居坐标之前判定是否丢弃该观测
+type VivaldiDefense interface {
+	// Accept 节点u是否采信邻居peer上报的坐标（rtt为实测往返时延）
+	Accept(u, peer int, local, peerCoord *VivaldiCoordinate, rtt float64) bool
+
+	// GetDefenseName 获取防御名称
+	GetDefenseName() string
+}
+
+// VivaldiDefenseFactory 为n个节点创建防御实例（防御维护每个节点的状态）
+type VivaldiDefenseFactory func(n int) VivaldiDefense
+
+// relativeResidual 观测的相对残差 |预测RTT - 实测RTT| / 实测RTT
+func relativeResidual(local, peerCoord *VivaldiCoordinate, rtt float64) float64 {
+	if rtt < 1e-6 {
+		return 0
+	}
+	return math.Abs(DistanceVivaldi(local, peerCoord)-rtt) / rtt
+}
+
+// OutlierDefense 基于观测历史的离群检测
+//   - 残差检测：相对残差超过本节点最近Window次采信观测的 中位数 + K*MAD 时丢弃
+//   - 位移检测：邻居上报坐标相对本节点上次看到的坐标位移超过MaxPeerMove（ms）时丢弃
+type OutlierDefense struct {
+	Window      int     // 残差历史窗口
+	K           float64 // MAD倍数
+	MinResidual float64 // 残差低于该值时总是采信
+	MaxPeerMove float64 // 邻居坐标单次最大位移（ms，<=0表示不检测）
+
+	residuals [][]float64                  // 每个节点最近采信的残差
+	lastSeen  []map[int]*VivaldiCoordinate // 每个节点看到的邻居上次坐标
+}
+
+// NewOutlierDefense 创建离群检测防御
+func NewOutlierDefense(n int) *OutlierDefense {
+	od := &OutlierDefense{
+		Window:      32,
+		K:           3.0,
+		MinResidual: 0.1,
+		MaxPeerMove: 300.0,
+		residuals:   make([][]float64, n),
+		lastSeen:    make([]map[int]*VivaldiCoordinate, n),
+	}
+	for i := 0; i < n; i++ {
+		od.lastSeen[i] = make(map[int]*VivaldiCoordinate)
+	}
+	return od
+}
+
+// Accept 实现VivaldiDefense接口
+func (od *OutlierDefense) Accept(u, peer int, local, peerCoord *VivaldiCoordinate, rtt float64) bool {
+	// 位移检测（无论是否采信都记录邻居的最新坐标）
+	moved := false
+	if prev, ok := od.lastSeen[u][peer]; ok && od.MaxPeerMove > 0 {
+		moved = DistanceVivaldi(prev, peerCoord) > od.MaxPeerMove
+	}
+	od.lastSeen[u][peer] = copyCoordinate(peerCoord)
+	if moved {
+		return false
+	}
+
+	// 残差检测
+	r := relativeResidual(local, peerCoord, rtt)
+	history := od.residuals[u]
+	if r > od.MinResidual && len(history) >= od.Window/2 {
+		med := median(history)
+		deviations := make([]float64, len(history))
+		for i, h := range history {
+			deviations[i] = math.Abs(h - med)
+		}
+		mad := 1.4826 * median(deviations)
+		if r > med+od.K*mad {
+			return false
+		}
+	}
+
+	history = append(history, r)
+	if len(history) > od.Window {
+		history = history[1:]
+	}
+	od.residuals[u] = history
+	return true
+}
+
+// GetDefenseName 实现VivaldiDefense接口
+func (od *OutlierDefense) GetDefenseName() string {
+	return "outlier"
+}
+
+// KalmanDefense 卡尔曼滤波残差检测
+// 每个节点把自己的相对残差建模为随机游走 x_k = x_{k-1} + w（方差Q），观测 z = x + v（方差R）。
+// 新息 |z - x| 超过 K*sqrt(P + R) 的观测被丢弃且不更新滤波器；前Warmup次观测总是采信，用于训练滤波器。
+type KalmanDefense struct {
+	Q      float64 // 过程噪声方差
+	R      float64 // 观测噪声方差
+	K      float64 // 新息置信倍数
+	Warmup int     // 预热观测数
+
+	state []float64 // 每个节点的残差估计
+	cov   []float64 // 每个节点的估计方差
+	count []int     // 每个节点已处理的观测数
+}
+
+// NewKalmanDefense 创建卡尔曼滤波防御
+func NewKalmanDefense(n int) *KalmanDefense {
+	kd := &KalmanDefense{
+		Q:      1e-4,
+		R:      0.04,
+		K:      3.0,
+		Warmup: 32,
+		state:  make([]float64, n),
+		cov:    make([]float64, n),
+		count:  make([]int, n),
+	}
+	for i := 0; i < n; i++ {
+		kd.state[i] = VivaldiInitError
+		kd.cov[i] = 1.0
+	}
+	return kd
+}
+
+// Accept 实现VivaldiDefense接口
+func (kd *KalmanDefense) Accept(u, peer int, local, peerCoord *VivaldiCoordinate, rtt float64) bool {
+	z := relativeResidual(local, peerCoord, rtt)
+
+	// 预测
+	p := kd.cov[u] + kd.Q
+	innovation := z - kd.state[u]
+	s := p + kd.R
+
+	kd.count[u]++
+	if kd.count[u] > kd.Warmup && math.Abs(innovation) > kd.K*math.Sqrt(s) {
+		kd.cov[u] = p
+		return false
+	}
+
+	// 更新
+	gain := p / s
+	kd.state[u] += gain * innovation
+	kd.cov[u] = (1 - gain) * p
+	return true
+}
+
+// GetDefenseName 实现VivaldiDefense接口
+func (kd *KalmanDefense) GetDefenseName() string {
+	return "kalman"
+}
+
+// ==================== 攻击下的坐标训练 ====================
+
+// VivaldiVariant 参与攻击实验的Vivaldi更新规则
+type VivaldiVariant int
+
+const (
+	VivaldiVariantBasic    VivaldiVariant = iota // Observe
+	VivaldiVariantImproved                       // ObserveImproved
+	VivaldiVariantPlusPlus                       // ObservePlusPlus
+)
+
+// String 获取更新规则名称
+func (v VivaldiVariant) String() string {
+	switch v {
+	case VivaldiVariantImproved:
+		return "improved"
+	case VivaldiVariantPlusPlus:
+		return "plusplus"
+	default:
+		return "basic"
+	}
+}
+
+// VivaldiTrainingStats 攻击下坐标训练的统计
+type VivaldiTrainingStats struct {
+	Observations int // 诚实节点发起的观测数
+	FromAttacker int // 其中来自攻击节点的观测数
+	Rejected     int // 被防御丢弃的观测数
+	RejectedBad  int // 被丢弃的来自攻击节点的观测数
+}
+
+// GenerateVirtualCoordinateUnderAttack 在攻击节点存在时训练Vivaldi坐标
+// 三种更新规则使用统一的训练循环：每个节点有VivaldiPeerSetSize个固定邻居
+// （Vivaldi++为FixedNeighborSetSize个，每轮采样NeighborSampleSizePerRound个），
+// 邻居坐标经adversary.Report篡改后交给defense过滤，再调用对应的Observe函数。
+//
+// 参数:
+//   - coords: 真实地理坐标数组
+//   - rounds: 更新轮数
+//   - variant: 更新规则
+//   - adversary: 攻击节点（nil表示无攻击）
+//   - defense: 防御（nil表示不防御）
+//   - rttModel: RTT模型（nil表示地理RTT模型）
+//   - rng: 随机流
+//
+// 返回: Vivaldi模型数组（攻击节点的模型为其真实训练结果）和训练统计
+func GenerateVirtualCoordinateUnderAttack(
+	coords []LatLonCoordinate,
+	rounds int,
+	variant VivaldiVariant,
+	adversary *VivaldiAdversary,
+	defense VivaldiDefense,
+	rttModel LatencyModel,
+	rng *rand.Rand,
+) ([]*VivaldiModel, *VivaldiTrainingStats) {
+
+	if rttModel == nil {
+		rttModel = NewGeoRTTModel(coords)
+	}
+	n := len(coords)
+	dim := VivaldiDim
+	stats := &VivaldiTrainingStats{}
+
+	config := NewVivaldiPlusPlusConfig()
+	config.RTTModel = rttModel
+
+	// 初始化坐标与固定邻居
+	models := make([]*VivaldiModel, n)
+	states := make([]*VivaldiPlusPlusState, n)
+	peerSetSize := VivaldiPeerSetSize
+	if variant == VivaldiVariantPlusPlus {
+		peerSetSize = FixedNeighborSetSize
+	}
+	peerSetSize = Min(peerSetSize, n-1)
+	for i := 0; i < n; i++ {
+		models[i] = NewVivaldiModel(i, dim)
+		if variant == VivaldiVariantPlusPlus {
+			states[i] = NewVivaldiPlusPlusState(i, dim, config, rng)
+			models[i].LocalCoord = states[i].Coord
+		} else {
+			models[i].LocalCoord.Error = VivaldiInitError
+			for d := 0; d < dim; d++ {
+				models[i].LocalCoord.Vector[d] = rng.Float64() * 1000
+			}
+			models[i].LocalCoord.Height = rng.Float64() * 100
+		}
+		models[i].RandomPeerSet = samplePeers(n, i, peerSetSize, rng)
+		models[i].HaveEnoughPeer = true
+		if states[i] != nil {
+			states[i].FixedNeighbors = models[i].RandomPeerSet
+		}
+	}
+
+	defenseName := "none"
+	if defense != nil {
+		defenseName = defense.GetDefenseName()
+	}
+	attackName := VivaldiAttackNone.String()
+	if adversary != nil {
+		attackName = adversary.Type.String()
+	}
+	fmt.Printf("攻击下生成虚拟坐标（%s, 攻击 %s, 防御 %s, %d轮）...\n", variant, attackName, defenseName, rounds)
+
+	for round := 0; round < rounds; round++ {
+		// 同一轮内所有节点看到的上报坐标一致（每轮开始时的快照）
+		reported := make([]*VivaldiCoordinate, n)
+		for u := 0; u < n; u++ {
+			reported[u] = adversary.Report(u, round, models[u].LocalCoord)
+		}
+
+		for x := 0; x < n; x++ {
+			neighbors := models[x].RandomPeerSet
+			if variant == VivaldiVariantPlusPlus {
+				ShouldSwitchToLate(states[x], round, config)
+				if states[x].Phase == "LATE" && round%3 == 0 {
+					states[x].StableSetManager.RefreshStableSet(states[x].NeighborHistory)
+				}
+				neighbors = append([]int(nil), neighbors...)
+				rng.Shuffle(len(neighbors), func(a, b int) {
+					neighbors[a], neighbors[b] = neighbors[b], neighbors[a]
+				})
+				neighbors = neighbors[:Min(NeighborSampleSizePerRound, len(neighbors))]
+			}
+
+			honest := adversary == nil || !adversary.Flags[x]
+			for _, y := range neighbors {
+				rtt := rttModel.Delay(x, y)
+				peerCoord := reported[y]
+				bad := adversary != nil && adversary.Flags[y]
+				if honest {
+					stats.Observations++
+					if bad {
+						stats.FromAttacker++
+					}
+				}
+
+				if defense != nil && !defense.Accept(x, y, models[x].LocalCoord, peerCoord, rtt) {
+					if honest {
+						stats.Rejected++
+						if bad {
+							stats.RejectedBad++
+						}
+					}
+					continue
+				}
+
+				switch variant {
+				case VivaldiVariantImproved:
+					ObserveImproved(models[x], y, peerCoord, rtt, round, rounds)
+				case VivaldiVariantPlusPlus:
+					ObservePlusPlus(states[x], y, peerCoord, rtt, round, config, coords)
+				default:
+					Observe(models[x], y, peerCoord, rtt)
+				}
+			}
+
+			if variant == VivaldiVariantPlusPlus {
+				ApplyAnnealing(states[x], round, config)
+			}
+		}
+	}
+
+	return models, stats
+}
+
+// ==================== 攻击实验 ====================
+
+// VivaldiAttackResult 一组(更新规则, 攻击, 防御)的结果
+type VivaldiAttackResult struct {
+	Variant  VivaldiVariant        // 更新规则
+	Attack   VivaldiAttackType     // 攻击类型
+	Defense  string                // 防御名称（"none"表示不防御）
+	Ratio    float64               // 攻击节点比例
+	Stats    *VivaldiTrainingStats // 训练统计
+	Reported *ErrorDistribution    // 诚实节点自报误差的分布（EvaluateErrorDistribution）
+	Actual   *ErrorDistribution    // 诚实节点实际预测误差的分布（与诚实邻居的相对误差中位数）
+}
+
+// FalseRejectRate 被丢弃的诚实观测占诚实观测的比例
+func (r *VivaldiAttackResult) FalseRejectRate() float64 {
+	honest := r.Stats.Observations - r.Stats.FromAttacker
+	if honest == 0 {
+		return 0
+	}
+	return float64(r.Stats.Rejected-r.Stats.RejectedBad) / float64(honest)
+}
+
+// AttackRejectRate 被丢弃的攻击观测占攻击观测的比例
+func (r *VivaldiAttackResult) AttackRejectRate() float64 {
+	if r.Stats.FromAttacker == 0 {
+		return 0
+	}
+	return float64(r.Stats.RejectedBad) / float64(r.Stats.FromAttacker)
+}
+
+// HonestPredictionErrors 计算每个诚实节点的实际预测误差
+// 节点误差为其与sampleSize个随机诚实节点之间 |坐标距离 - RTT| / RTT 的中位数
+// 参数:
+//   - models: Vivaldi模型数组
+//   - rttModel: RTT模型
+//   - flags: 攻击节点标记（nil表示全部诚实）
+//   - sampleSize: 每个节点采样的节点数
+//   - rng: 随机流
+func HonestPredictionErrors(models []*VivaldiModel, rttModel LatencyModel, flags []bool, sampleSize int, rng *rand.Rand) []float64 {
+	n := len(models)
+	errors := make([]float64, 0, n)
+	for u := 0; u < n; u++ {
+		if flags != nil && flags[u] {
+			continue
+		}
+		samples := make([]float64, 0, sampleSize)
+		for _, v := range samplePeers(n, u, sampleSize, rng) {
+			if flags != nil && flags[v] {
+				continue
+			}
+			samples = append(samples, relativeResidual(models[u].LocalCoord, models[v].LocalCoord, rttModel.Delay(u, v)))
+		}
+		if len(samples) > 0 {
+			sort.Float64s(samples)
+			errors = append(errors, samples[len(samples)/2])
+		}
+	}
+	return errors
+}
+
+// VivaldiAttackSweep 比较各更新规则在各攻击和防御下的诚实节点误差分布
+// 参数:
+//   - coords: 真实地理坐标数组
+//   - rounds: 更新轮数
+//   - ratio: 攻击节点比例
+//   - variants: 更新规则
+//   - attacks: 攻击类型（应包含VivaldiAttackNone作为基线）
+//   - defenses: 防御工厂（nil元素表示不防御）
+//   - rttModel: RTT模型（nil表示地理RTT模型）
+//   - ctx: 随机上下文（同一攻击在各更新规则和防御下使用相同的攻击节点和初始坐标）
+//
+// 返回: 全部结果
+func VivaldiAttackSweep(
+	coords []LatLonCoordinate,
+	rounds int,
+	ratio float64,
+	variants []VivaldiVariant,
+	attacks []VivaldiAttackType,
+	defenses []VivaldiDefenseFactory,
+	rttModel LatencyModel,
+	ctx *SimContext,
+) []*VivaldiAttackResult {
+
+	if rttModel == nil {
+		rttModel = NewGeoRTTModel(coords)
+	}
+	n := len(coords)
+	results := make([]*VivaldiAttackResult, 0, len(variants)*len(attacks)*len(defenses))
+
+	for _, variant := range variants {
+		for _, attack := range attacks {
+			for _, factory := range defenses {
+				adversary := NewVivaldiAdversary(n, VivaldiDim, attack, ratio, ctx.Derive("vivaldi_attack/"+attack.String()))
+				var defense VivaldiDefense
+				defenseName := "none"
+				if factory != nil {
+					defense = factory(n)
+					defenseName = defense.GetDefenseName()
+				}
+
+				trainRng := ctx.Derive(fmt.Sprintf("vivaldi_attack/train/%s", variant))
+				models, stats := GenerateVirtualCoordinateUnderAttack(coords, rounds, variant, adversary, defense, rttModel, trainRng)
+
+				honestModels := make([]*VivaldiModel, 0, n)
+				for u := 0; u < n; u++ {
+					if !adversary.Flags[u] {
+						honestModels = append(honestModels, models[u])
+					}
+				}
+				actual := HonestPredictionErrors(models, rttModel, adversary.Flags, 32, ctx.Derive("vivaldi_attack/eval"))
+
+				r := &VivaldiAttackResult{
+					Variant:  variant,
+					Attack:   attack,
+					Defense:  defenseName,
+					Ratio:    ratio,
+					Stats:    stats,
+					Reported: EvaluateErrorDistribution(honestModels),
+					Actual:   ErrorDistributionOf(actual),
+				}
+				results = append(results, r)
+				fmt.Printf("  %s/%s/%s: 实际误差 中位数 %.3f, 95分位 %.3f; 自报误差 中位数 %.3f; 丢弃 攻击 %.1f%% / 诚实 %.1f%%\n",
+					variant, attack, defenseName, r.Actual.MedianError, r.Actual.P95Error, r.Reported.MedianError,
+					r.AttackRejectRate()*100, r.FalseRejectRate()*100)
+			}
+		}
+	}
+	return results
+}
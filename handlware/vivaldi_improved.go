@@ -140,7 +140,7 @@ func computeAdaptiveCc(currentError float64, currentRound int, totalRounds int)
 
 // selectStratifiedNeighbors 分层邻居选择
 // 改进3：近邻（局部精度）+ 中距离 + 远距离（全局精度）
-func selectStratifiedNeighbors(nodeID int, n int, geohashes []string, peerSetSize int) []int {
+func selectStratifiedNeighbors(nodeID int, n int, geohashes []string, peerSetSize int, rng *rand.Rand) []int {
 	nearCount := peerSetSize / 3
 	midCount := peerSetSize / 3
 	farCount := peerSetSize - nearCount - midCount
@@ -188,14 +188,14 @@ func selectStratifiedNeighbors(nodeID int, n int, geohashes []string, peerSetSiz
 	allSelected = append(allSelected, far...)
 
 	for len(allSelected) < peerSetSize {
-		candidate := rand.Intn(n)
+		candidate := rng.Intn(n)
 		if candidate != nodeID && !contains(allSelected, candidate) {
 			allSelected = append(allSelected, candidate)
 		}
 	}
 
 	// 打乱顺序
-	rand.Shuffle(len(allSelected), func(i, j int) {
+	rng.Shuffle(len(allSelected), func(i, j int) {
 		allSelected[i], allSelected[j] = allSelected[j], allSelected[i]
 	})
 
@@ -203,7 +203,7 @@ func selectStratifiedNeighbors(nodeID int, n int, geohashes []string, peerSetSiz
 }
 
 // selectNeighborsPreferAnchors 选择邻居（优先选择锚点）
-func selectNeighborsPreferAnchors(nodeID int, n int, anchors []int, geohashes []string, peerSetSize int) []int {
+func selectNeighborsPreferAnchors(nodeID int, n int, anchors []int, geohashes []string, peerSetSize int, rng *rand.Rand) []int {
 	anchorCount := peerSetSize / 2 // 一半选锚点
 	regularCount := peerSetSize - anchorCount
 
@@ -212,7 +212,7 @@ func selectNeighborsPreferAnchors(nodeID int, n int, anchors []int, geohashes []
 	// 先选锚点
 	shuffledAnchors := make([]int, len(anchors))
 	copy(shuffledAnchors, anchors)
-	rand.Shuffle(len(shuffledAnchors), func(i, j int) {
+	rng.Shuffle(len(shuffledAnchors), func(i, j int) {
 		shuffledAnchors[i], shuffledAnchors[j] = shuffledAnchors[j], shuffledAnchors[i]
 	})
 
@@ -223,7 +223,7 @@ func selectNeighborsPreferAnchors(nodeID int, n int, anchors []int, geohashes []
 	}
 
 	// 再选常规节点（分层）
-	regularNodes := selectStratifiedNeighbors(nodeID, n, geohashes, regularCount+len(anchors))
+	regularNodes := selectStratifiedNeighbors(nodeID, n, geohashes, regularCount+len(anchors), rng)
 	for _, node := range regularNodes {
 		if node != nodeID && !contains(selected, node) && !contains(anchors, node) {
 			selected = append(selected, node)
@@ -275,6 +275,15 @@ func contains(slice []int, val int) bool {
 // GenerateVirtualCoordinateImproved 改进版Vivaldi坐标生成
 // 集成所有改进：分层邻居选择、自适应步长、信任度加权、全局锚点、异常值过滤
 func GenerateVirtualCoordinateImproved(coords []LatLonCoordinate, rounds int, dim int) []*VivaldiModel {
+	return GenerateVirtualCoordinateImprovedWithRTT(coords, rounds, dim, NewGeoRTTModel(coords), nil)
+}
+
+// GenerateVirtualCoordinateImprovedWithRTT 使用指定RTT模型和随机流的改进版Vivaldi坐标生成
+func GenerateVirtualCoordinateImprovedWithRTT(coords []LatLonCoordinate, rounds int, dim int, rttModel LatencyModel, rng *rand.Rand) []*VivaldiModel {
+	if rttModel == nil {
+		rttModel = NewGeoRTTModel(coords)
+	}
+	rng = NewRngOrDefault(rng, DefaultSeed)
 	n := len(coords)
 	models := make([]*VivaldiModel, n)
 	observationBuffers := make([]*ObservationBuffer, n)
@@ -290,9 +299,9 @@ func GenerateVirtualCoordinateImproved(coords []LatLonCoordinate, rounds int, di
 
 		// 随机初始化坐标
 		for d := 0; d < dim; d++ {
-			models[i].LocalCoord.Vector[d] = RandomBetween01() * 1000
+			models[i].LocalCoord.Vector[d] = rng.Float64() * 1000
 		}
-		models[i].LocalCoord.Height = RandomBetween01() * 100
+		models[i].LocalCoord.Height = rng.Float64() * 100
 
 		// 创建观测缓冲区（用于异常值过滤）
 		observationBuffers[i] = NewObservationBuffer(5)
@@ -334,16 +343,16 @@ func GenerateVirtualCoordinateImproved(coords []LatLonCoordinate, rounds int, di
 			// 选择邻居策略
 			if round < anchorThreshold {
 				// 前期：分层邻居选择
-				selectedNeighbors = selectStratifiedNeighbors(x, n, geohashes, VivaldiPeerSetSize)
+				selectedNeighbors = selectStratifiedNeighbors(x, n, geohashes, VivaldiPeerSetSize, rng)
 			} else {
 				// 后期：优先选择锚点
-				selectedNeighbors = selectNeighborsPreferAnchors(x, n, anchors, geohashes, VivaldiPeerSetSize)
+				selectedNeighbors = selectNeighborsPreferAnchors(x, n, anchors, geohashes, VivaldiPeerSetSize, rng)
 			}
 
 			// 对每个邻居进行观测和更新
 			for _, y := range selectedNeighbors {
 				// 计算真实RTT
-				rtt := Distance(coords[x], coords[y]) + FixedDelay
+				rtt := rttModel.Delay(x, y)
 
 				// 异常值过滤：使用缓冲区中位数
 				filteredRTT := observationBuffers[x].AddObservation(y, rtt)
@@ -71,6 +71,9 @@ type VivaldiPlusPlusConfig struct {
 
 	// 冻结参数
 	Fc float64 // 最大位移上限
+
+	// RTT来源
+	RTTModel LatencyModel // 节点对往返时延模型（nil表示 Distance + FixedDelay）
 }
 
 // NewVivaldiPlusPlusConfig 创建默认配置
@@ -95,9 +98,19 @@ func NewVivaldiPlusPlusConfig() *VivaldiPlusPlusConfig {
 		AnnealRate:   DefaultAnnealRate,
 		AnnealPeriod: DefaultAnnealPeriod,
 		Fc:           DefaultFc,
+		RTTModel:     nil,
 	}
 }
 
+// MeasureRTT 获取节点i与j之间的真实RTT
+// 配置了RTTModel（如实测RTT矩阵）时使用该模型，否则使用地理距离 + FixedDelay
+func (config *VivaldiPlusPlusConfig) MeasureRTT(coords []LatLonCoordinate, i, j int) float64 {
+	if config.RTTModel != nil {
+		return config.RTTModel.Delay(i, j)
+	}
+	return Distance(coords[i], coords[j]) + FixedDelay
+}
+
 // ==================== RTTTracker: RTT历史跟踪器 ====================
 
 // RTTTracker 维护每个邻居的RTT历史，用于计算中位数RTT
@@ -282,12 +295,14 @@ func (nh *NeighborHistory) GetStableNodes() []int {
 // StableSetManager 管理稳定节点集合，选择参考点
 type StableSetManager struct {
 	stableSet []int
+	rng       *rand.Rand // 回退时随机选择参考点使用的随机流
 }
 
 // NewStableSetManager 创建新的稳定集合管理器
-func NewStableSetManager() *StableSetManager {
+func NewStableSetManager(rng *rand.Rand) *StableSetManager {
 	return &StableSetManager{
 		stableSet: make([]int, 0),
+		rng:       rng,
 	}
 }
 
@@ -316,7 +331,7 @@ func (ssm *StableSetManager) SelectReferencePoint(history *NeighborHistory) int
 
 	// 如果所有节点震荡都很大，随机选一个
 	if bestPeer == -1 && len(ssm.stableSet) > 0 {
-		bestPeer = ssm.stableSet[rand.Intn(len(ssm.stableSet))]
+		bestPeer = ssm.stableSet[ssm.rng.Intn(len(ssm.stableSet))]
 	}
 
 	return bestPeer
@@ -372,17 +387,17 @@ type VivaldiPlusPlusState struct {
 	FixedNeighbors     []int              // 固定邻居集合（128个）
 }
 
-// NewVivaldiPlusPlusState 创建新的节点状态
-func NewVivaldiPlusPlusState(nodeID int, dim int, config *VivaldiPlusPlusConfig) *VivaldiPlusPlusState {
+// NewVivaldiPlusPlusState 创建新的节点状态（rng用于坐标初始化和参考点回退选择）
+func NewVivaldiPlusPlusState(nodeID int, dim int, config *VivaldiPlusPlusConfig, rng *rand.Rand) *VivaldiPlusPlusState {
 	// 初始化坐标
 	coord := NewVivaldiCoordinate(dim)
 	coord.Error = VivaldiInitError
 
 	// 随机初始化坐标
 	for d := 0; d < dim; d++ {
-		coord.Vector[d] = RandomBetween01() * 1000
+		coord.Vector[d] = rng.Float64() * 1000
 	}
-	coord.Height = RandomBetween01() * 100
+	coord.Height = rng.Float64() * 100
 
 	return &VivaldiPlusPlusState{
 		NodeID:             nodeID,
@@ -390,7 +405,7 @@ func NewVivaldiPlusPlusState(nodeID int, dim int, config *VivaldiPlusPlusConfig)
 		Coord:              coord,
 		RTTTracker:         NewRTTTracker(config.RTTWindow),
 		NeighborHistory:    NewNeighborHistory(config.CoordWindow),
-		StableSetManager:   NewStableSetManager(),
+		StableSetManager:   NewStableSetManager(rng),
 		PhaseStableCounter: 0,
 		CurrentCc:          config.Cc,
 		CurrentCe:          config.Ce,
@@ -553,7 +568,7 @@ func ObservePlusPlus(state *VivaldiPlusPlusState, peerID int, peerCoord *Vivaldi
 			// 为了简化，我们使用坐标距离作为近似
 			if len(coords) > refPoint && len(coords) > peerID {
 				// 使用真实地理距离作为近似
-				tbj = Distance(coords[refPoint], coords[peerID]) + FixedDelay
+				tbj = config.MeasureRTT(coords, refPoint, peerID)
 			} else {
 				tbj = predictedRTT * 0.8 // 备用近似
 			}
@@ -625,8 +640,8 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 		config = NewVivaldiPlusPlusConfig()
 	}
 
-	// 设置伪随机数种子（保证实验可重复性）
-	rand.Seed(config.RandSeed)
+	// 使用独立的随机流（保证实验可重复性，且不受全局随机状态影响）
+	rng := rand.New(rand.NewSource(config.RandSeed))
 
 	fmt.Printf("开始生成Vivaldi++虚拟坐标（%d轮，%d维，种子=%d）...\n", rounds, config.Dim, config.RandSeed)
 	fmt.Printf("配置: 固定邻居=%d, 每轮采样=%d, R_min=%d, e_switch=%.2f, RTT窗口=%d\n",
@@ -635,7 +650,7 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 	// 初始化所有节点的状态
 	states := make([]*VivaldiPlusPlusState, n)
 	for i := 0; i < n; i++ {
-		states[i] = NewVivaldiPlusPlusState(i, config.Dim, config)
+		states[i] = NewVivaldiPlusPlusState(i, config.Dim, config, rng)
 	}
 
 	// 为每个节点分配固定的邻居集合（128个）
@@ -651,7 +666,7 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 		}
 
 		// 随机打乱
-		rand.Shuffle(len(candidates), func(a, b int) {
+		rng.Shuffle(len(candidates), func(a, b int) {
 			candidates[a], candidates[b] = candidates[b], candidates[a]
 		})
 
@@ -715,7 +730,7 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 				// 随机打乱固定邻居列表
 				shuffled := make([]int, len(state.FixedNeighbors))
 				copy(shuffled, state.FixedNeighbors)
-				rand.Shuffle(len(shuffled), func(a, b int) {
+				rng.Shuffle(len(shuffled), func(a, b int) {
 					shuffled[a], shuffled[b] = shuffled[b], shuffled[a]
 				})
 
@@ -739,7 +754,7 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 				}
 
 				// 先选稳定节点
-				rand.Shuffle(len(stableNeighbors), func(a, b int) {
+				rng.Shuffle(len(stableNeighbors), func(a, b int) {
 					stableNeighbors[a], stableNeighbors[b] = stableNeighbors[b], stableNeighbors[a]
 				})
 				for _, peerID := range stableNeighbors {
@@ -756,7 +771,7 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 							candidates = append(candidates, peerID)
 						}
 					}
-					rand.Shuffle(len(candidates), func(a, b int) {
+					rng.Shuffle(len(candidates), func(a, b int) {
 						candidates[a], candidates[b] = candidates[b], candidates[a]
 					})
 					for _, peerID := range candidates {
@@ -772,7 +787,7 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 			// 对每个邻居进行观测和更新
 			for _, j := range selectedNeighbors {
 				// 计算真实RTT（基于地理距离）
-				rtt := Distance(coords[i], coords[j]) + FixedDelay
+				rtt := config.MeasureRTT(coords, i, j)
 
 				// 记录λ违例统计（Late阶段）
 				if state.Phase == "LATE" {
@@ -784,9 +799,9 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 						}
 						tib := state.RTTTracker.GetMedianRTT(refPoint)
 						if tib < 1e-6 {
-							tib = Distance(coords[i], coords[refPoint]) + FixedDelay
+							tib = config.MeasureRTT(coords, i, refPoint)
 						}
-						tbj := Distance(coords[refPoint], coords[j]) + FixedDelay
+						tbj := config.MeasureRTT(coords, refPoint, j)
 
 						lambda := ComputeLambda(tij, tib, tbj)
 						wTIV := ComputeWTIV(lambda, config.Tau, config.EpsMin, config.Alpha)
@@ -916,14 +931,14 @@ func GenerateVirtualCoordinatePlusPlus(coords []LatLonCoordinate, rounds int, co
 	}
 	relativeErrors := make([]float64, 0, sampleSize)
 	for s := 0; s < sampleSize; s++ {
-		i := rand.Intn(n)
-		j := rand.Intn(n)
+		i := rng.Intn(n)
+		j := rng.Intn(n)
 		if i == j {
 			continue
 		}
 
 		// 真实RTT
-		realRTT := Distance(coords[i], coords[j]) + FixedDelay
+		realRTT := config.MeasureRTT(coords, i, j)
 		// 预测RTT
 		predictedRTT := DistanceVivaldi(models[i].LocalCoord, models[j].LocalCoord)
 
@@ -972,13 +987,20 @@ type ErrorDistribution struct {
 
 // EvaluateErrorDistribution 评估误差分布
 func EvaluateErrorDistribution(models []*VivaldiModel) *ErrorDistribution {
-	n := len(models)
+	errors := make([]float64, len(models))
+	for i := range models {
+		errors[i] = models[i].LocalCoord.Error
+	}
+	return ErrorDistributionOf(errors)
+}
+
+// ErrorDistributionOf 统计一组节点误差的分布（errors会被排序）
+func ErrorDistributionOf(errors []float64) *ErrorDistribution {
+	n := len(errors)
 	errorCount := make(map[string]int)
-	errors := make([]float64, n)
 
 	for i := 0; i < n; i++ {
-		err := models[i].LocalCoord.Error
-		errors[i] = err
+		err := errors[i]
 
 		if err < 0.1 {
 			errorCount["<0.1"]++
@@ -1140,25 +1162,26 @@ func AutoTuneParameters(coords []LatLonCoordinate, rounds int, outputFile string
 phase2:
 	// 2. 随机采样其他参数组合
 	fmt.Printf("\n阶段2: 随机采样测试（已测试%d个，继续测试到%d个）...\n", testCount, maxTests)
-	rand.Seed(time.Now().UnixNano())
+	// 采样使用固定种子，保证搜索过程可重复
+	rng := rand.New(rand.NewSource(DefaultSeed))
 
 	for testCount < maxTests {
 		config := NewVivaldiPlusPlusConfig()
-		config.RTTWindow = rttWindows[rand.Intn(len(rttWindows))]
-		config.CoordWindow = coordWindows[rand.Intn(len(coordWindows))]
-		config.RMin = rMins[rand.Intn(len(rMins))]
-		config.ESwitch = eSwitches[rand.Intn(len(eSwitches))]
-		config.S = sValues[rand.Intn(len(sValues))]
-		config.BMin = bMins[rand.Intn(len(bMins))]
-		config.P = pValues[rand.Intn(len(pValues))]
-		config.E0 = e0Values[rand.Intn(len(e0Values))]
-		config.Tau = tauValues[rand.Intn(len(tauValues))]
-		config.EpsMin = epsMins[rand.Intn(len(epsMins))]
-		config.Gamma = gammas[rand.Intn(len(gammas))]
-		config.Fc = fcs[rand.Intn(len(fcs))]
-		config.Alpha = alphas[rand.Intn(len(alphas))]
-		config.AnnealRate = annealRates[rand.Intn(len(annealRates))]
-		config.AnnealPeriod = annealPeriods[rand.Intn(len(annealPeriods))]
+		config.RTTWindow = rttWindows[rng.Intn(len(rttWindows))]
+		config.CoordWindow = coordWindows[rng.Intn(len(coordWindows))]
+		config.RMin = rMins[rng.Intn(len(rMins))]
+		config.ESwitch = eSwitches[rng.Intn(len(eSwitches))]
+		config.S = sValues[rng.Intn(len(sValues))]
+		config.BMin = bMins[rng.Intn(len(bMins))]
+		config.P = pValues[rng.Intn(len(pValues))]
+		config.E0 = e0Values[rng.Intn(len(e0Values))]
+		config.Tau = tauValues[rng.Intn(len(tauValues))]
+		config.EpsMin = epsMins[rng.Intn(len(epsMins))]
+		config.Gamma = gammas[rng.Intn(len(gammas))]
+		config.Fc = fcs[rng.Intn(len(fcs))]
+		config.Alpha = alphas[rng.Intn(len(alphas))]
+		config.AnnealRate = annealRates[rng.Intn(len(annealRates))]
+		config.AnnealPeriod = annealPeriods[rng.Intn(len(annealPeriods))]
 
 		result := testConfig(coords, rounds, config, testCount+1)
 		if result != nil {
@@ -1287,13 +1310,13 @@ func GenerateVirtualCoordinatePlusPlusSilent(coords []LatLonCoordinate, rounds i
 		config = NewVivaldiPlusPlusConfig()
 	}
 
-	// 设置伪随机数种子（保证实验可重复性）
-	rand.Seed(config.RandSeed)
+	// 使用独立的随机流（保证实验可重复性）
+	rng := rand.New(rand.NewSource(config.RandSeed))
 
 	// 初始化所有节点的状态
 	states := make([]*VivaldiPlusPlusState, n)
 	for i := 0; i < n; i++ {
-		states[i] = NewVivaldiPlusPlusState(i, config.Dim, config)
+		states[i] = NewVivaldiPlusPlusState(i, config.Dim, config, rng)
 	}
 
 	// 为每个节点分配固定的邻居集合（128个）
@@ -1307,7 +1330,7 @@ func GenerateVirtualCoordinatePlusPlusSilent(coords []LatLonCoordinate, rounds i
 		}
 
 		// 随机打乱
-		rand.Shuffle(len(candidates), func(a, b int) {
+		rng.Shuffle(len(candidates), func(a, b int) {
 			candidates[a], candidates[b] = candidates[b], candidates[a]
 		})
 
@@ -1341,7 +1364,7 @@ func GenerateVirtualCoordinatePlusPlusSilent(coords []LatLonCoordinate, rounds i
 				// 随机打乱固定邻居列表
 				shuffled := make([]int, len(state.FixedNeighbors))
 				copy(shuffled, state.FixedNeighbors)
-				rand.Shuffle(len(shuffled), func(a, b int) {
+				rng.Shuffle(len(shuffled), func(a, b int) {
 					shuffled[a], shuffled[b] = shuffled[b], shuffled[a]
 				})
 
@@ -1365,7 +1388,7 @@ func GenerateVirtualCoordinatePlusPlusSilent(coords []LatLonCoordinate, rounds i
 				}
 
 				// 先选稳定节点
-				rand.Shuffle(len(stableNeighbors), func(a, b int) {
+				rng.Shuffle(len(stableNeighbors), func(a, b int) {
 					stableNeighbors[a], stableNeighbors[b] = stableNeighbors[b], stableNeighbors[a]
 				})
 				for _, peerID := range stableNeighbors {
@@ -1382,7 +1405,7 @@ func GenerateVirtualCoordinatePlusPlusSilent(coords []LatLonCoordinate, rounds i
 							candidates = append(candidates, peerID)
 						}
 					}
-					rand.Shuffle(len(candidates), func(a, b int) {
+					rng.Shuffle(len(candidates), func(a, b int) {
 						candidates[a], candidates[b] = candidates[b], candidates[a]
 					})
 					for _, peerID := range candidates {
@@ -1397,7 +1420,7 @@ func GenerateVirtualCoordinatePlusPlusSilent(coords []LatLonCoordinate, rounds i
 
 			// 对每个邻居进行观测和更新
 			for _, j := range selectedNeighbors {
-				rtt := Distance(coords[i], coords[j]) + FixedDelay
+				rtt := config.MeasureRTT(coords, i, j)
 				ObservePlusPlus(state, j, states[j].Coord, rtt, round, config, coords)
 			}
 
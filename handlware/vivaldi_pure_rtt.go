@@ -68,7 +68,7 @@ func (np *NeighborPool) UpdateError(peerID int, error float64) {
 // selectNeighborsByRTT 根据真实RTT分层选择邻居
 // 策略：近邻（局部精度）+ 中距离 + 远邻（全局精度）
 func selectNeighborsByRTT(nodeID int, n int, rttCache *RTTCache, coords []LatLonCoordinate,
-	peerSetSize int, round int, totalRounds int) []int {
+	peerSetSize int, round int, totalRounds int, rng *rand.Rand) []int {
 
 	// 计算探索率（随轮次递减）
 	explorationRate := 1.0 - float64(round)/float64(totalRounds)
@@ -133,7 +133,7 @@ func selectNeighborsByRTT(nodeID int, n int, rttCache *RTTCache, coords []LatLon
 
 	// 阶段2：探索（Explore）- 随机选择新邻居
 	for len(selected) < peerSetSize {
-		candidate := rand.Intn(n)
+		candidate := rng.Intn(n)
 		if candidate != nodeID && !containsInt(selected, candidate) {
 			selected = append(selected, candidate)
 		}
@@ -147,14 +147,14 @@ func selectNeighborsByRTT(nodeID int, n int, rttCache *RTTCache, coords []LatLon
 // selectNeighborsByError 根据预测误差动态选择邻居
 // 原理：误差大的邻居多观测（提高精度），误差小的少观测（节省资源）
 func selectNeighborsByError(nodeID int, n int, pool *NeighborPool, rttCache *RTTCache,
-	coords []LatLonCoordinate, peerSetSize int) []int {
+	coords []LatLonCoordinate, peerSetSize int, rng *rand.Rand) []int {
 
 	selected := make([]int, 0, peerSetSize)
 
 	if len(pool.PredictErrors) == 0 {
 		// 冷启动：随机选择
 		for len(selected) < peerSetSize {
-			candidate := rand.Intn(n)
+			candidate := rng.Intn(n)
 			if candidate != nodeID && !containsInt(selected, candidate) {
 				selected = append(selected, candidate)
 			}
@@ -189,7 +189,7 @@ func selectNeighborsByError(nodeID int, n int, pool *NeighborPool, rttCache *RTT
 
 	// 随机探索
 	for len(selected) < peerSetSize {
-		candidate := rand.Intn(n)
+		candidate := rng.Intn(n)
 		if candidate != nodeID && !containsInt(selected, candidate) {
 			selected = append(selected, candidate)
 		}
@@ -203,7 +203,7 @@ func selectNeighborsByError(nodeID int, n int, pool *NeighborPool, rttCache *RTT
 // selectNeighborsHybrid 混合策略：RTT分层 + 误差驱动 + 锚点优先
 func selectNeighborsHybrid(nodeID int, n int, anchors []int, pool *NeighborPool,
 	rttCache *RTTCache, coords []LatLonCoordinate,
-	peerSetSize int, round int, totalRounds int) []int {
+	peerSetSize int, round int, totalRounds int, rng *rand.Rand) []int {
 
 	selected := make([]int, 0, peerSetSize)
 
@@ -212,7 +212,7 @@ func selectNeighborsHybrid(nodeID int, n int, anchors []int, pool *NeighborPool,
 	if len(anchors) > 0 && round >= totalRounds/2 {
 		shuffled := make([]int, len(anchors))
 		copy(shuffled, anchors)
-		rand.Shuffle(len(shuffled), func(i, j int) {
+		rng.Shuffle(len(shuffled), func(i, j int) {
 			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 		})
 
@@ -230,7 +230,7 @@ func selectNeighborsHybrid(nodeID int, n int, anchors []int, pool *NeighborPool,
 
 	if progress < 0.3 {
 		// 早期（0-30%）：基于RTT分层（快速建立全局拓扑）
-		rttNeighbors := selectNeighborsByRTT(nodeID, n, rttCache, coords, remaining, round, totalRounds)
+		rttNeighbors := selectNeighborsByRTT(nodeID, n, rttCache, coords, remaining, round, totalRounds, rng)
 		for _, peer := range rttNeighbors {
 			if !containsInt(selected, peer) {
 				selected = append(selected, peer)
@@ -238,7 +238,7 @@ func selectNeighborsHybrid(nodeID int, n int, anchors []int, pool *NeighborPool,
 		}
 	} else {
 		// 后期（30-100%）：错误驱动（精细优化）
-		errorNeighbors := selectNeighborsByError(nodeID, n, pool, rttCache, coords, remaining)
+		errorNeighbors := selectNeighborsByError(nodeID, n, pool, rttCache, coords, remaining, rng)
 		for _, peer := range errorNeighbors {
 			if !containsInt(selected, peer) {
 				selected = append(selected, peer)
@@ -377,6 +377,15 @@ func ObserveImproved(vm *VivaldiModel, peerID int, peerCoord *VivaldiCoordinate,
 // GenerateVirtualCoordinatePureRTT 纯RTT驱动的Vivaldi（无Geohash）
 // 修复版：移除RTT缓存，简化策略，提高收敛性能
 func GenerateVirtualCoordinatePureRTT(coords []LatLonCoordinate, rounds int, dim int) []*VivaldiModel {
+	return GenerateVirtualCoordinatePureRTTWithRTT(coords, rounds, dim, NewGeoRTTModel(coords), nil)
+}
+
+// GenerateVirtualCoordinatePureRTTWithRTT 使用指定RTT模型和随机流的纯RTT驱动Vivaldi
+func GenerateVirtualCoordinatePureRTTWithRTT(coords []LatLonCoordinate, rounds int, dim int, rttModel LatencyModel, rng *rand.Rand) []*VivaldiModel {
+	if rttModel == nil {
+		rttModel = NewGeoRTTModel(coords)
+	}
+	rng = NewRngOrDefault(rng, DefaultSeed)
 	n := len(coords)
 	models := make([]*VivaldiModel, n)
 
@@ -388,16 +397,16 @@ func GenerateVirtualCoordinatePureRTT(coords []LatLonCoordinate, rounds int, dim
 		models[i].LocalCoord.Error = VivaldiInitError
 
 		for d := 0; d < dim; d++ {
-			models[i].LocalCoord.Vector[d] = RandomBetween01() * 1000
+			models[i].LocalCoord.Vector[d] = rng.Float64() * 1000
 		}
-		models[i].LocalCoord.Height = RandomBetween01() * 100
+		models[i].LocalCoord.Height = rng.Float64() * 100
 
 		// 初始化固定邻居集（保证早期收敛效率）
 		models[i].RandomPeerSet = make([]int, VivaldiPeerSetSize)
 		for j := 0; j < VivaldiPeerSetSize; j++ {
-			peer := rand.Intn(n)
+			peer := rng.Intn(n)
 			for peer == i {
-				peer = rand.Intn(n)
+				peer = rng.Intn(n)
 			}
 			models[i].RandomPeerSet[j] = peer
 		}
@@ -439,7 +448,7 @@ func GenerateVirtualCoordinatePureRTT(coords []LatLonCoordinate, rounds int, dim
 			for _, y := range selectedNeighbors {
 				// 关键修复：每轮都重新"测量"RTT，不缓存！
 				// 虽然地理距离不变，但需要用真实距离与当前虚拟坐标距离比较
-				rtt := Distance(coords[x], coords[y]) + FixedDelay
+				rtt := rttModel.Delay(x, y)
 
 				// 使用改进的观测函数（信任度加权 + 自适应步长）
 				ObserveImproved(models[x], y, models[y].LocalCoord, rtt, round, rounds)
@@ -0,0 +1,582 @@
+package handlware
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ==================== 连续消息流工作负载 ====================
+// Simulation 每次只模拟一条孤立的广播。实际网络中交易按泊松过程持续到达、
+// 区块周期性产生，所有消息共享同一组节点上行链路。
+// StreamSimulation 在同一个事件队列中注入来自多个根节点的消息，
+// 每条消息使用独立的广播会话，所有发送经过同一个 UplinkScheduler 排队、
+// 所有接收经过同一个 DownlinkScheduler 共享下载带宽，
+// 从而统计排队延迟、吞吐量以及每条消息的延迟分布。
+
+// 消息类型
+const (
+	StreamKindTx    = "tx"    // 交易
+	StreamKindBlock = "block" // 区块
+)
+
+// WorkloadConfig 连续消息流工作负载配置
+type WorkloadConfig struct {
+	Duration      float64 // 注入消息的模拟时长（ms）
+	TxRate        float64 // 交易到达率（条/秒，泊松过程，<=0表示不产生交易）
+	TxSize        float64 // 交易大小（Bytes）
+	BlockInterval float64 // 出块间隔（ms，<=0表示不出块）
+	BlockSize     float64 // 区块大小（Bytes）
+	CompleteRatio float64 // 覆盖率达到该比例视为送达（用于吞吐量统计）
+}
+
+// NewWorkloadConfig 创建默认工作负载配置
+// 默认：60秒内每秒20笔交易（300B），每12秒一个区块（1MB）
+func NewWorkloadConfig() *WorkloadConfig {
+	return &WorkloadConfig{
+		Duration:      60000,
+		TxRate:        20,
+		TxSize:        DataSizeSmall,
+		BlockInterval: 12000,
+		BlockSize:     DataSizeLarge,
+		CompleteRatio: 0.9,
+	}
+}
+
+// StreamMessage 工作负载中的一条消息
+type StreamMessage struct {
+	ID         int     // 消息ID（按注入时间排序）
+	Kind       string  // 消息类型（StreamKindTx / StreamKindBlock）
+	Root       int     // 发起节点
+	Size       float64 // 消息大小（Bytes）
+	InjectTime float64 // 注入时刻（ms）
+}
+
+// GenerateWorkload 生成消息注入序列
+// 参数:
+//   - n: 节点数
+//   - wc: 工作负载配置
+//   - excluded: 不能作为发起节点的节点（恶意/离开节点，可为nil）
+//   - rng: 随机流（由SimContext派生）
+//
+// 返回: 按注入时间排序的消息列表
+func GenerateWorkload(n int, wc *WorkloadConfig, excluded []bool, rng *rand.Rand) []*StreamMessage {
+	pickRoot := func() int {
+		root := rng.Intn(n)
+		for excluded != nil && excluded[root] {
+			root = rng.Intn(n)
+		}
+		return root
+	}
+
+	msgs := make([]*StreamMessage, 0)
+
+	// 交易：泊松过程，到达间隔服从指数分布
+	if wc.TxRate > 0 {
+		t := rng.ExpFloat64() / wc.TxRate * 1000
+		for t < wc.Duration {
+			msgs = append(msgs, &StreamMessage{Kind: StreamKindTx, Root: pickRoot(), Size: wc.TxSize, InjectTime: t})
+			t += rng.ExpFloat64() / wc.TxRate * 1000
+		}
+	}
+
+	// 区块：固定间隔
+	if wc.BlockInterval > 0 {
+		for t := wc.BlockInterval; t <= wc.Duration; t += wc.BlockInterval {
+			msgs = append(msgs, &StreamMessage{Kind: StreamKindBlock, Root: pickRoot(), Size: wc.BlockSize, InjectTime: t})
+		}
+	}
+
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].InjectTime < msgs[j].InjectTime
+	})
+	for i, m := range msgs {
+		m.ID = i
+	}
+	return msgs
+}
+
+// StreamMessageStats 单条消息的统计
+type StreamMessageStats struct {
+	*StreamMessage
+	Coverage      float64 // 覆盖率（收到消息的正常节点 / 正常节点）
+	Delivered     bool    // 覆盖率是否达到CompleteRatio
+	AvgLatency    float64 // 平均接收延迟（相对注入时刻，ms）
+	P50Latency    float64 // 已接收正常节点延迟的中位数（ms，不含未覆盖节点）
+	P90Latency    float64 // 已接收正常节点延迟的90分位（ms，不含未覆盖节点，不是覆盖90%节点的时刻）
+	MaxLatency    float64 // 最后一个接收节点的延迟（ms）
+	Sends         int     // 发送次数（含重复、公告和请求）
+	DupMsg        int     // 重复消息数
+	Dropped       int     // 链路丢失的完整消息数
+	AvgQueueDelay float64 // 每次发送的平均排队延迟（ms）
+}
+
+// StreamResult 连续消息流模拟结果
+type StreamResult struct {
+	Messages    []*StreamMessageStats // 每条消息的统计（按消息ID排序）
+	Overall     *TestResult           // 所有消息的平均统计（延迟相对各自注入时刻）
+	TxResult    *TestResult           // 交易的平均统计
+	BlockResult *TestResult           // 区块的平均统计
+	QueueDelays *DelayHistogram       // 每次发送的排队延迟（ms，流式统计）
+	Duration    float64               // 注入时长（ms）
+	Makespan    float64               // 最后一次接收的时刻（ms）
+	BytesSent   float64               // 全网发送字节数（含重复）
+}
+
+// streamState 一条进行中的消息的广播状态
+// 消息的所有在途事件处理完后立即汇总并释放，避免同时保存所有消息的节点数组
+type streamState struct {
+	msg        *StreamMessage
+	broadcast  Broadcast
+	timed      TimedBroadcast  // 使用定时器的会话（可为nil）
+	sched      *queueScheduler // 会话调度器
+	recvFlag   []bool
+	recvTime   []float64 // 相对注入时刻的接收延迟
+	recvDist   []float64
+	depth      []int
+	recvList   []int
+	requested  []bool // 已向公告方请求过完整消息
+	dupMsg     int
+	dropped    int // 链路丢失的完整消息数
+	inFlight   int // 在途事件数
+	sends      int
+	pushes     int // 推送完整消息的次数（不含按请求发送）
+	announces  int // 公告次数
+	bytes      float64
+	queueDelay float64
+}
+
+// StreamSimulation 连续消息流模拟
+// 参数:
+//   - coords: 节点坐标数组
+//   - attackConfig: 攻击配置（恶意节点、离开节点和拜占庭节点在整个模拟期间固定）
+//   - algo: 广播算法实现（每条消息开启一个广播会话）
+//   - config: 模拟器配置（Uplink为nil时所有节点使用config.Bandwidth的单流上行链路，不支持Churn）
+//   - wc: 工作负载配置
+//   - clusterResult: 聚类结果（可选，用于统计）
+//
+// 返回: 模拟结果；config.Churn不为nil时返回错误（churn时间线只针对单次广播生成）
+func StreamSimulation(
+	coords []LatLonCoordinate,
+	attackConfig *AttackConfig,
+	algo Algorithm,
+	config *SimulatorConfig,
+	wc *WorkloadConfig,
+	clusterResult *ClusterResult,
+) (*StreamResult, error) {
+
+	if config.Churn != nil {
+		return nil, fmt.Errorf("连续消息流模拟不支持churn，请将config.Churn设为nil")
+	}
+
+	ctx := config.GetContext().Sub("stream")
+	n := len(coords)
+
+	malFlags := GenerateMaliciousNodes(n, attackConfig.MaliciousRatio, ctx.Derive("malicious"))
+	leaveFlags := GenerateLeaveNodes(n, attackConfig.NodeLeaveRatio, ctx.Derive("leave"))
+	// 拜占庭节点照常接收和转发，但延迟/选择性/合谋转发，不作为发起节点、不计入统计
+	byzExcluded := make([]bool, n)
+	for i := 0; i < n; i++ {
+		byzExcluded[i] = malFlags[i] || leaveFlags[i]
+	}
+	byzantine := NewByzantineNodes(n, attackConfig, byzExcluded, ctx.Derive("byzantine"))
+	byzRng := ctx.Derive("byzantine/relay")
+	statMalFlags := byzantine.excludeFlags(malFlags)
+	excluded := make([]bool, n)
+	eligible := 0
+	for i := 0; i < n; i++ {
+		excluded[i] = statMalFlags[i] || leaveFlags[i]
+		if !excluded[i] {
+			eligible++
+		}
+	}
+
+	msgs := GenerateWorkload(n, wc, excluded, ctx.Derive("workload"))
+	fmt.Printf("连续消息流: %d条消息, 时长%.1fs, 交易率%.1f/s, 出块间隔%.1fs\n",
+		len(msgs), wc.Duration/1000, wc.TxRate, wc.BlockInterval/1000)
+
+	// 所有消息共享节点上行链路
+	model := config.Uplink
+	if model == nil {
+		model = NewBandwidthModel(n, config.Bandwidth, 0, 1)
+	}
+	uplink := NewUplinkScheduler(model)
+	downlink := NewDownlinkScheduler(model)
+	latency := config.GetLatencyModel(coords)
+	delayRng := ctx.Derive("processing")
+
+	// 丢包判定器在整个消息流中共享（突发丢包状态跨消息保持）
+	var loss LossSession
+	if config.Loss != nil {
+		loss = config.Loss.NewSession(ctx.Derive("loss"))
+	}
+
+	sr := &StreamResult{
+		Messages:    make([]*StreamMessageStats, len(msgs)),
+		Overall:     NewTestResult(0),
+		TxResult:    NewTestResult(0),
+		BlockResult: NewTestResult(0),
+		QueueDelays: NewDelayHistogram(queueDelayBucketWidth, queueDelayBuckets),
+		Duration:    wc.Duration,
+	}
+	txCount, blockCount := 0, 0
+
+	msgQueue := NewPriorityQueue()
+	for _, m := range msgs {
+		inject := NewMessage(m.Root, m.Root, m.Root, 0, m.InjectTime, m.InjectTime)
+		inject.ID = m.ID
+		msgQueue.Push(inject)
+	}
+
+	// send 节点u在ready时刻之后向v发送一条消息，上行链路在所有消息之间排队
+	send := func(st *streamState, u, v, step int, ready float64, msgType MessageType, size float64) {
+		sendTime, finishTime := uplink.Schedule(u, v, ready, size)
+		queueDelay := sendTime - ready
+		st.sends++
+		st.queueDelay += queueDelay
+		st.bytes += size
+		sr.QueueDelays.Add(queueDelay)
+		sr.BytesSent += size
+		if loss != nil && loss.Drop(u, v) {
+			if msgType == MsgPayload {
+				st.dropped++
+			}
+			return
+		}
+
+		// 同时到达v的多条流（含不同消息）共享v的下载带宽
+		propagation := latency.Delay(u, v)
+		recvTime := downlink.Schedule(v, sendTime+propagation, finishTime+propagation, size)
+		newMsg := NewMessage(st.msg.Root, u, v, step, sendTime, recvTime)
+		newMsg.ID = st.msg.ID
+		newMsg.Type = msgType
+		newMsg.Size = size
+		msgQueue.Push(newMsg)
+		st.inFlight++
+	}
+
+	active := make(map[int]*streamState)
+	for !msgQueue.Empty() {
+		msg := msgQueue.Pop()
+		st, ok := active[msg.ID]
+		if !ok {
+			// 注入事件：开启新的广播会话
+			sm := msgs[msg.ID]
+			st = &streamState{
+				msg:       sm,
+				broadcast: algo.NewBroadcast(sm.Root, ctx.Derive(fmt.Sprintf("algo/%d", sm.ID))),
+				recvFlag:  make([]bool, n),
+				recvTime:  make([]float64, n),
+				recvDist:  make([]float64, n),
+				depth:     make([]int, n),
+				recvList:  make([]int, 0),
+				requested: make([]bool, n),
+				inFlight:  1,
+			}
+			st.sched = &queueScheduler{queue: msgQueue, root: sm.Root, id: sm.ID, recvFlag: st.recvFlag}
+			st.sched.onPush = func() { st.inFlight++ }
+			if timed, ok := st.broadcast.(TimedBroadcast); ok {
+				st.timed = timed
+				timed.SetScheduler(st.sched)
+			}
+			active[msg.ID] = st
+		}
+		st.inFlight--
+		st.sched.now = msg.RecvTime
+		if msg.Type != MsgTimer && msg.Type != MsgScheduled {
+			sr.Makespan = MaxFloat64(sr.Makespan, msg.RecvTime)
+		}
+
+		u := msg.Dst
+		switch {
+		case msg.Type == MsgAnnounce:
+			// 收到公告：尚未持有且未请求过时，向公告方请求完整消息
+			if !st.recvFlag[u] && !st.requested[u] {
+				st.requested[u] = true
+				send(st, u, msg.Src, msg.Step, msg.RecvTime, MsgRequest, config.RequestSize)
+			}
+		case msg.Type == MsgRequest:
+			// 收到请求：持有消息的正常节点向请求方发送完整消息
+			if st.recvFlag[u] && !malFlags[u] && !leaveFlags[u] {
+				targets, extra := byzantine.Withhold(u, []int{msg.Src}, byzRng)
+				for _, v := range targets {
+					send(st, u, v, st.depth[u]+1, msg.RecvTime+extra, MsgPayload, st.msg.Size)
+				}
+			}
+		case msg.Type == MsgTimer:
+			// 定时器到期：由会话决定从该节点立即推送的节点
+			if st.timed != nil && !malFlags[u] && !leaveFlags[u] {
+				relayList := st.timed.OnTimer(u, msg.Tag)
+				if st.recvFlag[u] {
+					targets, extra := byzantine.Withhold(u, relayList, byzRng)
+					for _, v := range targets {
+						send(st, u, v, st.depth[u]+1, msg.RecvTime+extra, MsgPayload, st.msg.Size)
+					}
+					st.pushes += len(targets)
+				}
+			}
+		case msg.Type == MsgScheduled:
+			// 延迟发送：恶意节点和离开节点不执行，未收到消息的节点只能发送请求
+			src, msgType := msg.Src, MessageType(msg.Tag)
+			if !malFlags[src] && !leaveFlags[src] && (st.recvFlag[src] || msgType == MsgRequest) {
+				targets, extra := byzantine.Withhold(src, []int{u}, byzRng)
+				if len(targets) > 0 {
+					size := config.MessageSize(msgType)
+					if msgType == MsgPayload {
+						size = st.msg.Size
+						st.pushes++
+					} else if msgType == MsgAnnounce {
+						st.announces++
+					}
+					send(st, src, u, st.depth[src]+1, msg.RecvTime+extra, msgType, size)
+				}
+			}
+		case st.recvFlag[u]:
+			st.dupMsg++
+		default:
+			st.recvFlag[u] = true
+			st.recvTime[u] = msg.RecvTime - st.msg.InjectTime
+			st.recvDist[u] = msg.RecvTime - msg.SendTime
+			st.recvList = append(st.recvList, u)
+			if u != st.msg.Root {
+				st.depth[u] = st.depth[msg.Src] + 1
+			}
+
+			if !malFlags[u] && !leaveFlags[u] {
+				relayList, announceList := respondRelays(st.broadcast, msg)
+				ready := msg.RecvTime + CalculateProcessingDelayWithRng(delayRng)
+
+				// 拜占庭节点：扣留部分目标并延迟转发
+				if byzantine != nil && byzantine.Flags[u] {
+					var extra float64
+					relayList, extra = byzantine.Withhold(u, relayList, byzRng)
+					announceList, _ = byzantine.Withhold(u, announceList, byzRng)
+					ready += extra
+				}
+
+				for _, v := range relayList {
+					send(st, u, v, msg.Step+1, ready, MsgPayload, st.msg.Size)
+				}
+				for _, v := range announceList {
+					send(st, u, v, msg.Step+1, ready, MsgAnnounce, config.AnnounceSize)
+				}
+				st.pushes += len(relayList)
+				st.announces += len(announceList)
+			}
+		}
+
+		// 消息的所有在途事件处理完毕，汇总统计
+		if st.inFlight == 0 {
+			stats, res := summarizeStreamMessage(st, eligible, wc.CompleteRatio, statMalFlags, leaveFlags, clusterResult)
+			sr.Messages[st.msg.ID] = stats
+			AccumulateResults(sr.Overall, res)
+			if st.msg.Kind == StreamKindBlock {
+				AccumulateResults(sr.BlockResult, res)
+				blockCount++
+			} else {
+				AccumulateResults(sr.TxResult, res)
+				txCount++
+			}
+			delete(active, msg.ID)
+		}
+	}
+
+	AverageResults(sr.Overall, txCount+blockCount)
+	AverageResults(sr.TxResult, txCount)
+	AverageResults(sr.BlockResult, blockCount)
+
+	fmt.Printf("连续消息流模拟完成，最后接收时刻 %.1fs\n", sr.Makespan/1000)
+	return sr, nil
+}
+
+// summarizeStreamMessage 汇总一条消息的统计（TestResult风格 + 消息级指标）
+func summarizeStreamMessage(st *streamState, eligible int, completeRatio float64,
+	malFlags, leaveFlags []bool, clusterResult *ClusterResult) (*StreamMessageStats, *TestResult) {
+
+	// 收集已接收正常节点的接收延迟（st.recvList只包含收到消息的节点）
+	delays := make([]float64, 0, len(st.recvList))
+	for _, u := range st.recvList {
+		if !malFlags[u] && !leaveFlags[u] {
+			delays = append(delays, st.recvTime[u])
+		}
+	}
+
+	res := NewTestResult(0)
+	collectBroadcastStats(res, st.recvFlag, st.recvTime, st.recvDist, st.depth, st.recvList, st.dupMsg, st.dropped, 0, malFlags, leaveFlags, clusterResult)
+	res.AvgBytes = st.bytes
+	res.AvgBytesSaved = float64(st.pushes+st.announces)*st.msg.Size - st.bytes
+	finalizeResult(res, 1)
+
+	stats := &StreamMessageStats{
+		StreamMessage: st.msg,
+		AvgLatency:    Mean(delays),
+		P50Latency:    Percentile(delays, 0.5),
+		P90Latency:    Percentile(delays, 0.9),
+		MaxLatency:    Percentile(delays, 1.0),
+		Sends:         st.sends,
+		DupMsg:        st.dupMsg,
+		Dropped:       st.dropped,
+	}
+	if eligible > 0 {
+		stats.Coverage = float64(len(delays)) / float64(eligible)
+	}
+	stats.Delivered = stats.Coverage >= completeRatio
+	if st.sends > 0 {
+		stats.AvgQueueDelay = st.queueDelay / float64(st.sends)
+	}
+	return stats, res
+}
+
+// ==================== 结果汇总 ====================
+
+// Filter 获取指定类型的消息统计（kind为空表示全部）
+func (sr *StreamResult) Filter(kind string) []*StreamMessageStats {
+	out := make([]*StreamMessageStats, 0, len(sr.Messages))
+	for _, m := range sr.Messages {
+		if kind == "" || m.Kind == kind {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Throughput 计算吞吐量：注入时长内每秒送达（覆盖率达标）的消息数
+func (sr *StreamResult) Throughput(kind string) float64 {
+	if sr.Duration <= 0 {
+		return 0
+	}
+	delivered := 0
+	for _, m := range sr.Filter(kind) {
+		if m.Delivered {
+			delivered++
+		}
+	}
+	return float64(delivered) / (sr.Duration / 1000)
+}
+
+// LatencyDistribution 获取指定类型消息的某项延迟序列，用于计算跨消息的分布
+// metric: "avg" / "p50" / "p90" / "max"
+func (sr *StreamResult) LatencyDistribution(kind, metric string) []float64 {
+	values := make([]float64, 0)
+	for _, m := range sr.Filter(kind) {
+		switch metric {
+		case "avg":
+			values = append(values, m.AvgLatency)
+		case "p50":
+			values = append(values, m.P50Latency)
+		case "p90":
+			values = append(values, m.P90Latency)
+		default:
+			values = append(values, m.MaxLatency)
+		}
+	}
+	return values
+}
+
+// PrintInfo 打印连续消息流模拟摘要
+func (sr *StreamResult) PrintInfo() {
+	fmt.Printf("========== 连续消息流结果 ==========\n")
+	for _, kind := range []string{StreamKindTx, StreamKindBlock} {
+		msgs := sr.Filter(kind)
+		if len(msgs) == 0 {
+			continue
+		}
+		p90 := sr.LatencyDistribution(kind, "p90")
+		fmt.Printf("[%s] 消息数=%d, 吞吐量=%.2f/s\n", kind, len(msgs), sr.Throughput(kind))
+		fmt.Printf("  已接收节点P90延迟: 平均%.2f ms, P50=%.2f ms, P90=%.2f ms, P99=%.2f ms\n",
+			Mean(p90), Percentile(p90, 0.5), Percentile(p90, 0.9), Percentile(p90, 0.99))
+	}
+	fmt.Printf("排队延迟: 平均%.2f ms, 标准差%.2f ms, P50=%.2f ms, P99=%.2f ms, 最大%.2f ms\n",
+		sr.QueueDelays.Mean(), sr.QueueDelays.StdDev(), sr.QueueDelays.Percentile(0.5), sr.QueueDelays.Percentile(0.99), sr.QueueDelays.Max)
+	if sr.Duration > 0 {
+		fmt.Printf("发送流量: %.2f MB (%.2f Mbps)\n", sr.BytesSent/1e6, sr.BytesSent*8/(sr.Duration/1000)/1e6)
+	}
+	fmt.Printf("平均带宽消耗: %.2f, 平均丢失消息率: %.2f\n", sr.Overall.AvgBandwidth, sr.Overall.AvgDropped)
+}
+
+// ==================== 排队延迟统计 ====================
+
+// 排队延迟直方图：1ms分桶，覆盖0~60s，更长的排队计入最后一个桶
+const (
+	queueDelayBucketWidth = 1.0
+	queueDelayBuckets     = 60000
+)
+
+// DelayHistogram 延迟的流式统计（均值/方差 + 固定分桶直方图）
+// 内存与样本数无关，百分位精确到一个分桶宽度（不超过最大值）
+type DelayHistogram struct {
+	Count       int     // 样本数
+	Max         float64 // 最大值（ms）
+	BucketWidth float64 // 分桶宽度（ms）
+	Buckets     []int   // Buckets[i] 落在 [i*BucketWidth, (i+1)*BucketWidth) 的样本数，最后一个桶包含所有更大的值
+
+	mean float64 // 均值（Welford在线算法）
+	m2   float64 // 与均值之差的平方和
+}
+
+// NewDelayHistogram 创建延迟直方图
+// 参数:
+//   - bucketWidth: 分桶宽度（ms）
+//   - buckets: 分桶数
+func NewDelayHistogram(bucketWidth float64, buckets int) *DelayHistogram {
+	return &DelayHistogram{
+		BucketWidth: bucketWidth,
+		Buckets:     make([]int, buckets),
+	}
+}
+
+// Add 记录一个样本
+func (h *DelayHistogram) Add(d float64) {
+	h.Count++
+	delta := d - h.mean
+	h.mean += delta / float64(h.Count)
+	h.m2 += delta * (d - h.mean)
+	if h.Count == 1 || d > h.Max {
+		h.Max = d
+	}
+
+	idx := int(d / h.BucketWidth)
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(h.Buckets) {
+		idx = len(h.Buckets) - 1
+	}
+	h.Buckets[idx]++
+}
+
+// Mean 样本均值，空样本返回0
+func (h *DelayHistogram) Mean() float64 {
+	return h.mean
+}
+
+// StdDev 样本标准差（无偏估计），样本少于2个时返回0
+func (h *DelayHistogram) StdDev() float64 {
+	if h.Count < 2 {
+		return 0
+	}
+	return math.Sqrt(h.m2 / float64(h.Count-1))
+}
+
+// Percentile 第p分位数（与Percentile相同的取秩方式），返回所在分桶的上界，空样本返回0
+func (h *DelayHistogram) Percentile(p float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+	seen := 0
+	for i, c := range h.Buckets {
+		seen += c
+		if seen >= rank {
+			if i == len(h.Buckets)-1 {
+				return h.Max
+			}
+			return math.Min(float64(i+1)*h.BucketWidth, h.Max)
+		}
+	}
+	return h.Max
+}
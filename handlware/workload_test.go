@@ -0,0 +1,80 @@
+package handlware_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	hw "gomercator/handlware"
+	"gomercator/handlware/algorithms"
+)
+
+// TestDelayHistogram 流式统计与保存全部样本的Mean/StdDev/Percentile一致（百分位误差不超过一个分桶）
+func TestDelayHistogram(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	h := hw.NewDelayHistogram(1.0, 1000)
+	values := make([]float64, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		d := rng.ExpFloat64() * 80 // 尾部超出直方图范围
+		h.Add(d)
+		values = append(values, d)
+	}
+
+	if math.Abs(h.Mean()-hw.Mean(values)) > 1e-9 {
+		t.Errorf("均值 %v，期望 %v", h.Mean(), hw.Mean(values))
+	}
+	if math.Abs(h.StdDev()-hw.StdDev(values)) > 1e-9 {
+		t.Errorf("标准差 %v，期望 %v", h.StdDev(), hw.StdDev(values))
+	}
+	for _, p := range []float64{0.01, 0.5, 0.9, 0.99, 1.0} {
+		got, want := h.Percentile(p), hw.Percentile(values, p)
+		if got < want || got-want > h.BucketWidth {
+			t.Errorf("%.0f%%分位 %v，期望 %v", p*100, got, want)
+		}
+	}
+	if h.Percentile(1.0) != h.Max {
+		t.Errorf("100%%分位 %v，期望最大值 %v", h.Percentile(1.0), h.Max)
+	}
+}
+
+// TestStreamSimulationRejectsChurn 连续消息流模拟拒绝churn配置
+func TestStreamSimulationRejectsChurn(t *testing.T) {
+	coords := syntheticCoords(50)
+	config := hw.NewSimulatorConfig()
+	config.Churn = hw.NewChurnConfig(0.1)
+	algo := algorithms.NewRandomFlood(len(coords), coords, 0, 4, 4, config.Ctx.Derive("random"))
+	if _, err := hw.StreamSimulation(coords, hw.NewAttackConfig(), algo, config, hw.NewWorkloadConfig(), nil); err == nil {
+		t.Fatal("配置churn时应返回错误")
+	}
+}
+
+// TestStreamSimulationByzantine 拜占庭节点不计入覆盖率统计，且延迟转发使延迟变大
+func TestStreamSimulationByzantine(t *testing.T) {
+	coords := syntheticCoords(200)
+	n := len(coords)
+	wc := hw.NewWorkloadConfig()
+	wc.Duration = 5000
+	wc.BlockInterval = 0
+
+	run := func(attackConfig *hw.AttackConfig) *hw.StreamResult {
+		config := hw.NewSimulatorConfig()
+		algo := algorithms.NewRandomFlood(n, coords, 0, 8, 8, config.Ctx.Derive("random"))
+		sr, err := hw.StreamSimulation(coords, attackConfig, algo, config, wc, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sr
+	}
+	honest := run(hw.NewAttackConfig())
+	attackConfig := hw.NewAttackConfig()
+	attackConfig.ByzantineRatio = 0.3
+	attackConfig.ByzantineDelay = 500
+	byz := run(attackConfig)
+
+	if len(byz.Messages) == 0 || byz.Overall.Coverage > 1 {
+		t.Fatalf("消息数 %d，覆盖率 %v", len(byz.Messages), byz.Overall.Coverage)
+	}
+	if byz.Overall.AvgLatency <= honest.Overall.AvgLatency {
+		t.Errorf("拜占庭延迟转发后平均延迟 %v 不大于无攻击时的 %v", byz.Overall.AvgLatency, honest.Overall.AvgLatency)
+	}
+}
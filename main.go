@@ -1,18 +1,78 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
-	"math/rand"
+	"strings"
 	"time"
 
 	"gomercator/handlware"
 	"gomercator/handlware/algorithms"
 )
 
+// experiments 可选实验（命令行 -experiment 指定，逗号分隔，按给出的顺序在自动参数调节之前运行）
+var experiments = []struct {
+	Name string // 命令行中的实验名称
+	Desc string // 实验说明
+}{
+	{"stream", "连续消息流负载"},
+	{"churn", "churn率扫描"},
+	{"outage", "区域故障与网络分区"},
+	{"eclipse", "Eclipse/Sybil攻击"},
+	{"targeted", "针对性攻击扫描"},
+	{"fakecoord", "伪造坐标攻击实验"},
+	{"vivaldi-attack", "Vivaldi坐标攻击"},
+	{"tree", "广播树分析"},
+	{"paired", "配对比较"},
+	{"node-service", "节点服务质量"},
+	{"groups", "按节点分组分解结果"},
+}
+
+// experimentUsage 可选实验列表（用于命令行帮助和错误提示）
+func experimentUsage() string {
+	names := make([]string, len(experiments))
+	for i, e := range experiments {
+		names[i] = fmt.Sprintf("%s（%s）", e.Name, e.Desc)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseExperiments 解析 -experiment 参数（逗号分隔），不认识的实验名称返回错误
+func parseExperiments(value string) ([]string, error) {
+	selected := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, e := range experiments {
+			if e.Name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("未知的实验 %s，可选: %s", name, experimentUsage())
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
 func main() {
-	// 设置随机数种子
-	rand.Seed(100)
+	// 命令行参数：-experiment 选择在自动参数调节之前运行的可选实验，-workers 设置多根节点模拟的并发数
+	experimentFlag := flag.String("experiment", "", "可选实验（逗号分隔）: "+experimentUsage())
+	workersFlag := flag.Int("workers", 1, "多根节点模拟的并发数（结果与并发数无关）")
+	flag.Parse()
+	selected, err := parseExperiments(*experimentFlag)
+	if err != nil {
+		log.Fatalf("解析命令行参数失败: %v", err)
+	}
+
+	// 设置随机数种子：所有随机性都来自 SimContext 派生的子随机流
+	seed := handlware.DefaultSeed
 
 	fmt.Println("========================================")
 	fmt.Println("   MERCATOR 广播算法模拟器 (Go版本)")
@@ -36,14 +96,57 @@ func main() {
 	fmt.Printf("成功读取 %d 个节点的坐标\n\n", n)
 
 	// 2. 配置模拟参数
-	//reptTime := 1
+	reptTime := 1
 	malNode := 0.0
 	attackConfig := handlware.NewAttackConfig()
 	attackConfig.MaliciousRatio = malNode
+	// 拜占庭节点（可选）：照常接收，但延迟转发、选择性扣留或只向合谋节点转发
+	// attackConfig.ByzantineRatio = 0.1
+	// attackConfig.ByzantineDelay = 200.0        // 固定延迟（ms）
+	// attackConfig.ByzantineDelayJitter = 100.0  // 随机延迟上限（ms）
+	// attackConfig.ByzantineWithholdRatio = 0.5  // 对每个目标扣留的概率
+	// attackConfig.ByzantineColluding = false    // 只向其他拜占庭节点转发
 
 	simConfig := handlware.NewSimulatorConfig()
 	simConfig.Bandwidth = 33000000.0 // 33 Mbps
 	simConfig.DataSize = 300.0       // 300 Bytes
+	simConfig.Latency = handlware.NewGeoLatencyModel(coords)
+	simConfig.Ctx = handlware.NewSimContext(seed)
+	simConfig.Workers = *workersFlag
+
+	// 延迟下界（可选）：全连接 / 度受限最短路树的最早到达时刻，结果中输出各百分位延迟与下界之比
+	// simConfig.Oracle = handlware.NewLatencyOracle()
+
+	// 上行带宽串行化（可选）：1MB区块下大扇出节点需要依次发送
+	// simConfig.DataSize = handlware.DataSizeLarge
+	// simConfig.Uplink = handlware.NewBandwidthModelFromClasses(n, []handlware.BandwidthClass{
+	// 	{Name: "datacenter", Ratio: 0.2, Upload: 1000e6, Download: 1000e6},
+	// 	{Name: "residential", Ratio: 0.8, Upload: 33e6, Download: 100e6},
+	// }, 4, simConfig.Ctx.Derive("bandwidth"))
+	// simConfig.Uplink.PrintInfo()
+
+	// 链路丢包（可选）：均匀丢包 / 距离相关丢包 / Gilbert-Elliott突发丢包 / 永久失效链路，可组合使用
+	// simConfig.Loss = handlware.NewCombinedLoss(
+	// 	handlware.NewFailedLinkLoss(nil, 0.05, simConfig.Ctx.Seed),
+	// 	handlware.NewGilbertElliottLoss(0.05, 0.3, 0.01, 0.6),
+	// )
+
+	// 广播过程中的节点动态（可选）：崩溃 / 主动离开 / 重新加入 / 新节点加入
+	// simConfig.Churn = handlware.NewChurnConfig(0.1)
+
+	// 使用实测RTT矩阵（可选）：广播使用单向延迟 RTT/2，Vivaldi使用完整RTT，缺失部分由地理模型补齐
+	// rttMatrix, err := handlware.ReadRTTMatrixSparse("./rtt_matrix.txt", true)
+	// if err != nil {
+	// 	log.Fatalf("读取RTT矩阵失败: %v", err)
+	// }
+	// pointCoords, err := handlware.ReadMeasurementPointCoords("./rtt_points.txt", rttMatrix)
+	// if err != nil {
+	// 	log.Fatalf("读取测量点坐标失败: %v", err)
+	// }
+	// nodeMap := handlware.MapNodesToNearest(coords, pointCoords, 0)
+	// simConfig.Latency = handlware.NewOneWayLatencyModel(rttMatrix, nodeMap, handlware.NewGeoLatencyModel(coords))
+	// simConfig.RTT = handlware.NewRTTLatencyModel(rttMatrix, nodeMap, handlware.NewGeoRTTModel(coords))
+	// fmt.Printf("RTT矩阵: %d个测量点，覆盖率 %.1f%%\n\n", rttMatrix.Size(), rttMatrix.Coverage()*100)
 
 	// 清空输出文件
 	// os.Remove("sim_output.csv")
@@ -67,6 +170,37 @@ func main() {
 	// runMercatorMercury(n, coords, reptTime, attackConfig, simConfig)
 	// fmt.Println()
 
+	// 3.3 可选实验（命令行 -experiment 指定，按给出的顺序运行）
+	for _, name := range selected {
+		switch name {
+		case "stream":
+			runStreamWorkload(n, coords, attackConfig, simConfig)
+		case "churn":
+			runChurnSweep(n, coords, reptTime, attackConfig, simConfig)
+		case "outage":
+			runOutageScenarios(n, coords, reptTime, attackConfig, simConfig)
+		case "eclipse":
+			runEclipseExperiment(coords, simConfig)
+		case "targeted":
+			runTargetedAttackSweep(n, coords, reptTime, attackConfig, simConfig)
+		case "fakecoord":
+			fmt.Println("步骤 2.3/6: 运行伪造坐标攻击实验...")
+			fmt.Println("----------------------------------------")
+			runFakeCoordExperiment(n, coords, reptTime, attackConfig, simConfig)
+			fmt.Println()
+		case "vivaldi-attack":
+			runVivaldiAttackSweep(coords, simConfig)
+		case "tree":
+			runTreeAnalysis(n, coords, reptTime, attackConfig, simConfig)
+		case "paired":
+			runPairedComparison(n, coords, reptTime, attackConfig, simConfig)
+		case "node-service":
+			runNodeService(n, coords, reptTime, attackConfig, simConfig)
+		case "groups":
+			runGroupBreakdown(n, coords, reptTime, attackConfig, simConfig)
+		}
+	}
+
 	// 3.5 运行 MERCATOR ADAPTIVE 算法
 	// fmt.Println("步骤 2.5/6: 运行 MERCATOR ADAPTIVE 算法...")
 	// fmt.Println("----------------------------------------")
@@ -147,6 +281,8 @@ func main() {
 	fmt.Println("   结果已保存到:")
 	fmt.Println("   - sim_output.csv (详细结果)")
 	fmt.Println("   - fig.csv (图表数据)")
+	fmt.Println("   - metric_summary.csv (各指标的标准差与置信区间)")
+	fmt.Println("   - load_fairness.csv (节点负载的基尼系数与前1%占比)")
 	fmt.Println("========================================")
 }
 
@@ -187,6 +323,17 @@ func runMercator(n int, coords []handlware.LatLonCoordinate, reptTime int,
 					if err != nil {
 						log.Printf("写入图表数据失败: %v", err)
 					}
+
+					// 各指标在根节点之间的标准差与bootstrap置信区间
+					label := fmt.Sprintf("%s_g%d_b%d_k%d_f%d", algo.GetAlgoName(), geoPrec, bucketSize, k0Threshold, karyFactor)
+					summaries := result.Summaries(handlware.ConfidenceLevel, handlware.BootstrapResamples, simConfig.Ctx.Derive("bootstrap/"+label))
+					if err := handlware.WriteMetricSummaryCSV("metric_summary.csv", label, summaries); err != nil {
+						log.Printf("写入指标统计失败: %v", err)
+					}
+					// 节点上行/下行负载的集中程度（K-ary K0树 vs 泛洪/gossip）
+					if err := handlware.WriteLoadFairnessCSV("load_fairness.csv", label, result.Nodes.LoadFairness()); err != nil {
+						log.Printf("写入负载分布失败: %v", err)
+					}
 					fmt.Printf("完成参数: GEO_PRECISION=%d, BUCKET_SIZE=%d, K0_THRESHOLD=%d, KARY_FACTOR=%d\n",
 						geoPrec, bucketSize, k0Threshold, karyFactor)
 					fmt.Println("----------------------------------------")
@@ -196,7 +343,7 @@ func runMercator(n int, coords []handlware.LatLonCoordinate, reptTime int,
 	}
 
 	// //测试k0gossip策略
-	// algoGossip := algorithms.NewMercatorGossip(algo, 8)
+	// algoGossip := algorithms.NewMercatorGossip(algo, 8, simConfig.Ctx.Derive("mercator_gossip"))
 	// resultgossip := handlware.Simulation(reptTime, coords, attackConfig, algoGossip, simConfig, nil)
 	// // 输出结果
 	// err = handlware.WriteSimulationResults("sim_output.csv", resultgossip, algo.GetAlgoName(), n, attackConfig.MaliciousRatio)
@@ -338,6 +485,59 @@ func runMercatorMercury(n int, coords []handlware.LatLonCoordinate, reptTime int
 	fmt.Println("----------------------------------------")
 }
 
+// runFakeCoordExperiment 运行伪造坐标攻击实验（Mercator各变体）
+func runFakeCoordExperiment(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	startTime := time.Now()
+
+	// 各变体使用与单独运行时相同的参数
+	geoPrec := 3
+	bucketSize := 6
+	k0Threshold := 9999
+	karyFactor := 3
+	k0SampleSize := 10
+	hubFanout := 8
+
+	variants := []*handlware.FakeCoordVariant{
+		{
+			Name: "mercator", GeoPrec: geoPrec, BucketSize: bucketSize, K0Threshold: k0Threshold, KaryFactor: karyFactor,
+			Build: func(realCoords, displayCoords []handlware.LatLonCoordinate) handlware.Algorithm {
+				return algorithms.NewMercator(n, realCoords, displayCoords, 0, geoPrec, bucketSize, k0Threshold, karyFactor)
+			},
+		},
+		{
+			Name: "mercator_sampled", GeoPrec: geoPrec, BucketSize: bucketSize, K0Threshold: k0Threshold, KaryFactor: karyFactor,
+			Build: func(realCoords, displayCoords []handlware.LatLonCoordinate) handlware.Algorithm {
+				return algorithms.NewMercatorSampled(n, realCoords, displayCoords, 0, geoPrec, bucketSize, k0Threshold, karyFactor, k0SampleSize)
+			},
+		},
+		{
+			Name: "mercator_mercury", GeoPrec: geoPrec, BucketSize: bucketSize, K0Threshold: k0Threshold, KaryFactor: karyFactor,
+			Build: func(realCoords, displayCoords []handlware.LatLonCoordinate) handlware.Algorithm {
+				return algorithms.NewMercatorMercury(n, realCoords, displayCoords, 0, geoPrec, bucketSize, k0Threshold, karyFactor, k0SampleSize, hubFanout)
+			},
+		},
+		{
+			Name: "mercator_adaptive", GeoPrec: 1, BucketSize: bucketSize, K0Threshold: 100, KaryFactor: karyFactor,
+			Build: func(realCoords, displayCoords []handlware.LatLonCoordinate) handlware.Algorithm {
+				return algorithms.NewMercatorAdaptive(n, realCoords, displayCoords, 0, 1, 6, 100, bucketSize, karyFactor)
+			},
+		},
+	}
+
+	fc := handlware.NewFakeCoordConfig()
+	fc.GeoPrec = geoPrec
+	fc.OutputDir = "fake_coord"
+	fc.Verify = handlware.NewVerifyConfig() // 基于RTT的坐标验证（nil表示不验证）
+
+	handlware.FakeCoordSweep(reptTime, coords, attackConfig, variants, simConfig, fc)
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("伪造坐标攻击实验完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
 // runMercury 运行MERCURY算法
 func runMercury(n int, coords []handlware.LatLonCoordinate, vmodels []*handlware.VivaldiModel,
 	clusterResult *handlware.ClusterResult, reptTime int, attackConfig *handlware.AttackConfig,
@@ -357,7 +557,7 @@ func runMercury(n int, coords []handlware.LatLonCoordinate, vmodels []*handlware
 
 	// 创建Mercury算法实例
 	algo := algorithms.NewMercury(n, coords, vmodels, clusterResult, 0,
-		rootFanout, secondFanout, fanout, innerDeg, enableNearest)
+		rootFanout, secondFanout, fanout, innerDeg, enableNearest, simConfig.Ctx.Derive("mercury"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, clusterResult)
@@ -398,7 +598,7 @@ func runMercuryLocal(n int, coords []handlware.LatLonCoordinate, reptTime int,
 
 	// 创建MercuryLocal算法实例
 	algo := algorithms.NewMercuryLocal(n, coords, 0, neighborCount, k, vivaldiRounds,
-		rootFanout, secondFanout, fanout, innerDeg, enableNearest)
+		rootFanout, secondFanout, fanout, innerDeg, enableNearest, simConfig.RTT, simConfig.Ctx.Derive("mercury_local"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
@@ -426,7 +626,7 @@ func runRandom(n int, coords []handlware.LatLonCoordinate, reptTime int,
 	startTime := time.Now()
 
 	// 创建Random Flood算法实例
-	algo := algorithms.NewRandomFlood(n, coords, 0, 8, 8)
+	algo := algorithms.NewRandomFlood(n, coords, 0, 8, 8, simConfig.Ctx.Derive("random"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
@@ -482,7 +682,7 @@ func runPerigee(n int, coords []handlware.LatLonCoordinate, reptTime int,
 	startTime := time.Now()
 
 	// 创建Perigee算法实例
-	algo := algorithms.NewPerigeeUCB(n, coords, 0, 6, 6, 8)
+	algo := algorithms.NewPerigeeUCB(n, coords, 0, 6, 6, 8, simConfig.Latency, simConfig.Ctx.Derive("perigee"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
@@ -518,7 +718,7 @@ func runKadcast(n int, coords []handlware.LatLonCoordinate, reptTime int,
 	fmt.Printf("参数: K=%d, Fanout=%d, NumBits=%d\n", config.K, config.Fanout, config.NumBits)
 
 	// 创建Kadcast算法实例
-	algo := algorithms.NewKadcast(n, coords, config)
+	algo := algorithms.NewKadcast(n, coords, config, simConfig.Ctx.Derive("kadcast"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
@@ -555,7 +755,7 @@ func runETH(n int, coords []handlware.LatLonCoordinate, reptTime int,
 	fmt.Printf("参数: K=%d, Fanout=%d, NumBits=%d\n", config.K, config.Fanout, config.NumBits)
 
 	// 创建ETH算法实例
-	algo := algorithms.NewETH(n, coords, config)
+	algo := algorithms.NewETH(n, coords, config, simConfig.Ctx.Derive("eth"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
@@ -591,8 +791,9 @@ func runVivaldiPlusPlusRelay(n int, coords []handlware.LatLonCoordinate, reptTim
 	fmt.Printf("参数: Vivaldi++ rounds=100, Warmup=%d轮×%d交易, D=%d, eta_rand=%.2f\n",
 		warmupRounds, txPerRound, relayConfig.D, relayConfig.EtaRand)
 
-	// 创建 Vivaldi++ 传播策略算法实例
-	algo := algorithms.NewVivaldiPlusPlusRelay(n, coords, vivaldiConfig, relayConfig, warmupRounds, txPerRound)
+	// 创建 Vivaldi++ 传播策略算法实例（按模拟器的链路延迟模型预热）
+	algo := algorithms.NewVivaldiPlusPlusRelay(n, coords, vivaldiConfig, relayConfig, warmupRounds, txPerRound, simConfig.Latency,
+		simConfig.Ctx.Derive("vivaldi_plusplus_relay"))
 
 	// 运行模拟
 	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
@@ -612,3 +813,309 @@ func runVivaldiPlusPlusRelay(n int, coords []handlware.LatLonCoordinate, reptTim
 	fmt.Printf("Vivaldi++ Relay 完成，耗时: %s\n", elapsed)
 	fmt.Println("----------------------------------------")
 }
+
+// newDefaultMercator 创建可选实验使用的MERCATOR实例（真实坐标和显示坐标相同）
+func newDefaultMercator(n int, coords []handlware.LatLonCoordinate) *algorithms.Mercator {
+	geoPrec := 3
+	bucketSize := 6
+	k0Threshold := 9999
+	karyFactor := 3
+	return algorithms.NewMercator(n, coords, coords, 0, geoPrec, bucketSize, k0Threshold, karyFactor)
+}
+
+// runStreamWorkload 运行连续消息流负载：交易按泊松过程、区块按固定间隔从不同根节点注入，共享节点上行链路
+func runStreamWorkload(n int, coords []handlware.LatLonCoordinate,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行连续消息流负载...")
+	startTime := time.Now()
+
+	algo := newDefaultMercator(n, coords)
+	workload := handlware.NewWorkloadConfig()
+
+	// 运行模拟
+	streamResult, err := handlware.StreamSimulation(coords, attackConfig, algo, simConfig, workload, nil)
+	if err != nil {
+		log.Printf("连续消息流模拟失败: %v", err)
+		return
+	}
+	streamResult.PrintInfo()
+
+	// 输出结果
+	err = handlware.WriteStreamMessagesCSV("stream_messages.csv", streamResult)
+	if err != nil {
+		log.Printf("写入消息流结果失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("连续消息流负载完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runChurnSweep 运行churn率扫描：比较MERCATOR与Random Flood在广播过程中节点崩溃/离开/加入时的覆盖率和延迟
+func runChurnSweep(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行churn率扫描...")
+	startTime := time.Now()
+
+	algos := []handlware.Algorithm{
+		newDefaultMercator(n, coords),
+		algorithms.NewRandomFlood(n, coords, 0, 8, 8, simConfig.Ctx.Derive("random")),
+	}
+	rates := []float64{0, 0.05, 0.1, 0.2, 0.4}
+
+	// 运行模拟
+	churnResults := handlware.ChurnSweep(reptTime, coords, attackConfig, algos, simConfig, rates)
+
+	// 输出结果
+	err := handlware.WriteChurnSweepCSV("churn_sweep.csv", churnResults)
+	if err != nil {
+		log.Printf("写入churn扫描结果失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("churn率扫描完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runOutageScenarios 运行区域故障与网络分区场景：欧洲整体下线 / 切断跨洋链路，按区域统计存活节点的覆盖率
+func runOutageScenarios(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行区域故障与网络分区场景...")
+	startTime := time.Now()
+
+	algo := newDefaultMercator(n, coords)
+	regions := handlware.RegionClusters(handlware.RegionsByBoxes(coords, handlware.BoxAmericas, handlware.BoxEurope, handlware.BoxAsia))
+	regionNames := []string{"americas", "europe", "asia", "other"}
+
+	scenarios := []*handlware.OutageScenario{
+		{Name: "europe", Failed: handlware.BoundingBoxOutageNodes(coords, handlware.BoxEurope)},
+		{Name: "atlantic", Partition: handlware.NewBoundingBoxPartition(coords, handlware.BoxAmericas)},
+	}
+
+	for _, scenario := range scenarios {
+		// 运行模拟
+		result := handlware.OutageSimulation(reptTime, coords, attackConfig, algo, simConfig, scenario, regions)
+
+		// 输出结果
+		err := handlware.WriteRegionCoverageCSV("region_coverage_"+scenario.Name+".csv", scenario.Name, result, regions, regionNames)
+		if err != nil {
+			log.Printf("写入区域覆盖率失败: %v", err)
+		}
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("区域故障场景完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runEclipseExperiment 运行Eclipse/Sybil攻击：在受害者各桶对应的坐标处部署Sybil节点，度量路由表控制比例和广播审查频率
+func runEclipseExperiment(coords []handlware.LatLonCoordinate, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行Eclipse/Sybil攻击实验...")
+	startTime := time.Now()
+
+	// Sybil攻击参数
+	victim := 0
+	sybilCount := 200
+	bucketSize := 6
+	k0Threshold := 9999
+	karyFactor := 3
+
+	sc := handlware.NewSybilConfig(victim, sybilCount)
+	sybilNet := handlware.NewSybilNetwork(coords, sc, simConfig.Ctx.Derive("sybil"))
+
+	// 创建MERCATOR算法实例（Geohash精度与Sybil放置使用的精度一致）
+	algo := algorithms.NewMercator(sybilNet.Size(), sybilNet.RealCoords, sybilNet.DisplayCoords, 0, sc.GeoPrec, bucketSize, k0Threshold, karyFactor)
+
+	// Sybil网络的节点数多于诚实网络：延迟模型按扩展后的真实坐标重建，不使用按诚实节点生成的上行带宽模型
+	sybilConfig := *simConfig
+	sybilConfig.Latency = nil
+	sybilConfig.Uplink = nil
+
+	// 运行实验
+	eclipseResult := handlware.EclipseExperiment(sybilNet, algo, &sybilConfig)
+
+	// 输出结果
+	err := handlware.WriteSecurityReportCSV("security_report.csv", []*handlware.EclipseResult{eclipseResult})
+	if err != nil {
+		log.Printf("写入安全报告失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("Eclipse/Sybil攻击实验完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runTargetedAttackSweep 运行针对性攻击扫描：按入度/出度/介数/Hub/K0代表节点移除节点，得到覆盖率随移除比例变化的曲线
+func runTargetedAttackSweep(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行针对性攻击扫描...")
+	startTime := time.Now()
+
+	algo := newDefaultMercator(n, coords)
+	strategies := []handlware.TargetStrategy{handlware.TargetRandom, handlware.TargetInDegree, handlware.TargetOutDegree,
+		handlware.TargetBetweenness, handlware.TargetHub, handlware.TargetK0Representative}
+	fractions := []float64{0.01, 0.02, 0.05, 0.1, 0.2}
+
+	// 运行模拟
+	targetedResults := handlware.TargetedAttackSweep(reptTime, coords, attackConfig, algo, simConfig, strategies, fractions)
+
+	// 输出结果
+	err := handlware.WriteTargetedSweepCSV("targeted_sweep.csv", targetedResults)
+	if err != nil {
+		log.Printf("写入针对性攻击结果失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("针对性攻击扫描完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runVivaldiAttackSweep 运行Vivaldi坐标攻击实验：膨胀 / 震荡 / 温水煮青蛙攻击下，比较各更新规则在有无防御时诚实节点的误差分布
+func runVivaldiAttackSweep(coords []handlware.LatLonCoordinate, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行Vivaldi坐标攻击实验...")
+	startTime := time.Now()
+
+	// 攻击参数
+	attackerRatio := 0.1
+	variants := []handlware.VivaldiVariant{handlware.VivaldiVariantBasic, handlware.VivaldiVariantImproved, handlware.VivaldiVariantPlusPlus}
+	attacks := []handlware.VivaldiAttackType{handlware.VivaldiAttackNone, handlware.VivaldiAttackInflation,
+		handlware.VivaldiAttackOscillation, handlware.VivaldiAttackFrogBoiling}
+	defenses := []handlware.VivaldiDefenseFactory{nil,
+		func(n int) handlware.VivaldiDefense { return handlware.NewOutlierDefense(n) },
+		func(n int) handlware.VivaldiDefense { return handlware.NewKalmanDefense(n) }}
+
+	// 运行实验
+	vivaldiResults := handlware.VivaldiAttackSweep(coords, handlware.VivaldiUpdateRound, attackerRatio,
+		variants, attacks, defenses, simConfig.RTT, simConfig.Ctx)
+
+	// 输出结果
+	err := handlware.WriteVivaldiAttackCSV("vivaldi_attack.csv", vivaldiResults)
+	if err != nil {
+		log.Printf("写入Vivaldi攻击结果失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("Vivaldi坐标攻击实验完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runTreeAnalysis 运行广播树分析：按(重复, 根节点)导出每次广播的传播树和边伸展/子树/出度/最长路径指标
+func runTreeAnalysis(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行广播树分析...")
+	startTime := time.Now()
+
+	algo := newDefaultMercator(n, coords)
+
+	// 运行模拟（传播树保存在result.Trees中）
+	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
+	treeMetrics := handlware.AnalyzeTrees(result.Trees, simConfig.GetLatencyModel(coords))
+
+	// 输出结果
+	err := handlware.WriteBroadcastTreesCSV("broadcast_trees.csv", algo.GetAlgoName(), result.Trees, treeMetrics)
+	if err != nil {
+		log.Printf("写入广播树失败: %v", err)
+	}
+
+	err = handlware.WriteTreeMetricsCSV("tree_metrics.csv", algo.GetAlgoName(), treeMetrics)
+	if err != nil {
+		log.Printf("写入广播树指标失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("广播树分析完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runPairedComparison 运行配对比较：相同种子下根节点序列相同，逐根节点检验GEO_PRECISION=2是否优于GEO_PRECISION=3
+func runPairedComparison(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行配对比较...")
+	startTime := time.Now()
+
+	// 两组配置只有GEO_PRECISION不同
+	bucketSize := 6
+	k0Threshold := 1
+	karyFactor := 3
+	algoA := algorithms.NewMercator(n, coords, coords, 0, 2, bucketSize, k0Threshold, karyFactor)
+	algoB := algorithms.NewMercator(n, coords, coords, 0, 3, bucketSize, k0Threshold, karyFactor)
+
+	// 运行模拟
+	resultA := handlware.Simulation(reptTime, coords, attackConfig, algoA, simConfig, nil)
+	resultB := handlware.Simulation(reptTime, coords, attackConfig, algoB, simConfig, nil)
+	comparisons := handlware.CompareAllPaired("mercator_g2", "mercator_g3", resultA, resultB,
+		handlware.ConfidenceLevel, handlware.BootstrapResamples, simConfig.Ctx.Derive("paired"))
+
+	// 输出结果
+	err := handlware.WritePairedComparisonCSV("paired_comparison.csv", comparisons)
+	if err != nil {
+		log.Printf("写入配对比较结果失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("配对比较完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runNodeService 运行节点服务质量统计：每个节点在所有根节点广播中的平均/95分位延迟、跳数、重复消息数和未收到比例，
+// 带经纬度和K0桶大小导出，关联Geohash格子的稀疏程度
+func runNodeService(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行节点服务质量统计...")
+	startTime := time.Now()
+
+	algo := newDefaultMercator(n, coords)
+
+	// 运行模拟（每个节点的累积统计保存在result.Nodes中）
+	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
+
+	// 输出结果
+	err := handlware.WriteNodeServiceCSV("node_service.csv", algo.GetAlgoName(), result.Nodes.Services(), coords, handlware.K0BucketSizes(algo.KBuckets))
+	if err != nil {
+		log.Printf("写入节点服务质量失败: %v", err)
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("节点服务质量统计完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}
+
+// runGroupBreakdown 运行按节点分组的结果分解：geohash前缀 / 大洲 / 任意K的聚类，同一次模拟按多种分组写出
+func runGroupBreakdown(n int, coords []handlware.LatLonCoordinate, reptTime int,
+	attackConfig *handlware.AttackConfig, simConfig *handlware.SimulatorConfig) {
+
+	fmt.Println("\n运行按节点分组的结果分解...")
+	startTime := time.Now()
+
+	algo := newDefaultMercator(n, coords)
+	regions := handlware.RegionClusters(handlware.RegionsByBoxes(coords, handlware.BoxAmericas, handlware.BoxEurope, handlware.BoxAsia))
+	groupings := []*handlware.NodeGrouping{
+		handlware.GroupByGeohash(coords, 2),
+		handlware.GroupByContinent(coords),
+		handlware.GroupByCluster("regions", regions),
+	}
+
+	// 运行模拟
+	result := handlware.Simulation(reptTime, coords, attackConfig, algo, simConfig, nil)
+
+	// 输出结果（每种分组一个文件，重复运行时覆盖）
+	for _, grouping := range groupings {
+		err := handlware.WriteGroupBreakdownCSV("group_breakdown_"+grouping.Name+".csv", algo.GetAlgoName(), result.Nodes.Breakdown(grouping))
+		if err != nil {
+			log.Printf("写入分组结果失败: %v", err)
+		}
+	}
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("按节点分组的结果分解完成，耗时: %s\n", elapsed)
+	fmt.Println("----------------------------------------")
+}